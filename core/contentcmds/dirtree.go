@@ -0,0 +1,21 @@
+package contentcmds
+
+import (
+	"context"
+	"sync"
+
+	"github.com/jmigpin/editor/core"
+)
+
+// Expands/collapses directories and opens files in directory rows in tree mode.
+func DirTree(ctx context.Context, erow *core.ERow, index int) (error, bool) {
+	handled := false
+	var wg sync.WaitGroup
+	wg.Add(1)
+	erow.Ed.UI.RunOnUIGoRoutine(func() {
+		defer wg.Done()
+		handled = core.DirTreeOpenEntry(erow, index)
+	})
+	wg.Wait()
+	return nil, handled
+}
@@ -0,0 +1,29 @@
+package contentcmds
+
+import (
+	"context"
+
+	"github.com/jmigpin/editor/core"
+	"github.com/jmigpin/editor/util/parseutil"
+)
+
+// Expands/collapses a value in the godebug inspect row.
+func GoDebugInspectToggle(ctx context.Context, erow *core.ERow, index int) (error, bool) {
+	if erow.Info.Name() != core.GoDebugInspectRowName {
+		return nil, false
+	}
+
+	rw := erow.Row.TextArea.TextCursor.RW()
+	line, _, err := parseutil.IndexLineColumn(rw, index)
+	if err != nil {
+		return err, true
+	}
+
+	erow.Ed.UI.RunOnUIGoRoutine(func() {
+		if err := erow.Ed.GoDebug.InspectToggle(line - 1); err != nil {
+			erow.Ed.Error(err)
+		}
+	})
+
+	return nil, true
+}
@@ -0,0 +1,56 @@
+package contentcmds
+
+import (
+	"context"
+	"strconv"
+	"unicode"
+
+	"github.com/jmigpin/editor/core"
+	"github.com/jmigpin/editor/util/iout/iorw"
+	"github.com/jmigpin/editor/util/scanutil"
+)
+
+// Selects the godebug step of a watch row entry ("#<step> ...").
+func GoDebugWatchSelect(ctx context.Context, erow *core.ERow, index int) (error, bool) {
+	if erow.Info.Name() != core.GoDebugWatchRowName {
+		return nil, false
+	}
+
+	ta := erow.Row.TextArea
+
+	// limit reading
+	rw := ta.TextCursor.RW()
+	rd := iorw.NewLimitedReader(rw, index, index, 1000)
+
+	step, err := watchEntryStep(rd, index)
+	if err != nil {
+		return nil, false
+	}
+
+	erow.Ed.UI.RunOnUIGoRoutine(func() {
+		rowPos := erow.Ed.GoodRowPos() // needs ui goroutine
+		if err := erow.Ed.GoDebug.SelectArrivalIndex(step, rowPos); err != nil {
+			erow.Ed.Error(err)
+		}
+	})
+
+	return nil, true
+}
+
+func watchEntryStep(rd iorw.Reader, index int) (int, error) {
+	ls, err := iorw.LineStartIndex(rd, index)
+	if err != nil {
+		return 0, err
+	}
+
+	sc := scanutil.NewScanner(rd)
+	sc.SetStartPos(ls)
+	if !sc.Match.Rune('#') {
+		return 0, sc.Errorf("#")
+	}
+	sc.Advance()
+	if !sc.Match.FnLoop(unicode.IsDigit) {
+		return 0, sc.Errorf("digits")
+	}
+	return strconv.Atoi(sc.Value())
+}
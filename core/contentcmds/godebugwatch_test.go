@@ -0,0 +1,24 @@
+package contentcmds
+
+import (
+	"testing"
+
+	"github.com/jmigpin/editor/util/iout/iorw"
+)
+
+func TestWatchEntryStep1(t *testing.T) {
+	s := "watch: \"a\": main.go\n#12\tg1\tmain.go:5\ta=1\n#130\tg7\tmain.go:5\ta=2"
+	rd := iorw.NewStringReader(s)
+	for _, u := range []struct{ index, step int }{{22, 12}, {30, 12}, {45, 130}, {len(s), 130}} {
+		step, err := watchEntryStep(rd, u.index)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if step != u.step {
+			t.Fatalf("index %v: got %v, expected %v", u.index, step, u.step)
+		}
+	}
+	if _, err := watchEntryStep(rd, 3); err == nil {
+		t.Fatal("expecting error")
+	}
+}
@@ -81,6 +81,7 @@ func goplsQuery(ctx context.Context, erow *core.ERow, index int) error {
 			FilePos:               filePos,
 			RowPos:                rowPos,
 			FlashVisibleOffsets:   true,
+			JumpList:              true,
 			NewIfNotExistent:      true,
 			NewIfOffsetNotVisible: true,
 		}
@@ -142,6 +143,7 @@ func goGuru(ctx context.Context, erow *core.ERow, index int) error {
 			FilePos:               filePos,
 			RowPos:                rowPos,
 			FlashVisibleOffsets:   true,
+			JumpList:              true,
 			NewIfNotExistent:      true,
 			NewIfOffsetNotVisible: true,
 		}
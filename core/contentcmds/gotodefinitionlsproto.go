@@ -75,6 +75,7 @@ func GoToDefinitionLSProto(ctx context.Context, erow *core.ERow, index int) (err
 			FilePos:               filePos,
 			RowPos:                rowPos,
 			FlashVisibleOffsets:   true,
+			JumpList:              true,
 			NewIfNotExistent:      true,
 			NewIfOffsetNotVisible: true,
 		}
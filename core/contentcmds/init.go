@@ -7,6 +7,13 @@ import (
 func init() {
 	// order matters
 
+	// user defined rules (priority >= 0)
+	core.ContentCmds.Append("plumbing", Plumbing)
+
+	// only run on the godebug rows
+	core.ContentCmds.Append("godebugwatch", GoDebugWatchSelect)
+	core.ContentCmds.Append("godebuginspect", GoDebugInspectToggle)
+
 	core.ContentCmds.Append("gotodefinition_lsproto", GoToDefinitionLSProto)
 
 	// "gopls query" might work where lsproto might fail (no views in session)
@@ -15,6 +22,12 @@ func init() {
 	// opensession runs before openfilename to avoid failing if a file with that name exists in the current directory
 	core.ContentCmds.Append("opensession", OpenSession)
 
+	// directory rows in tree mode (before openfilename opens a new row)
+	core.ContentCmds.Append("dirtree", DirTree)
+
 	core.ContentCmds.Append("openfilename", OpenFilename)
 	core.ContentCmds.Append("openurl", OpenURL)
+
+	// user defined rules (negative priority)
+	core.ContentCmds.Append("plumbing_fallback", PlumbingFallback)
 }
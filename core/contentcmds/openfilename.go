@@ -26,7 +26,11 @@ func OpenFilename(ctx context.Context, erow *core.ERow, index int) (error, bool)
 		rd = iorw.NewLimitedReader(rw, index, index, 1000)
 	}
 
-	res, err := parseutil.ParseResource(rd, index)
+	parse := parseutil.ParseResource
+	if erow.ByteOffsets() {
+		parse = parseutil.ParseResourceByteOffsets
+	}
+	res, err := parse(rd, index)
 	if err != nil {
 		return err, false
 	}
@@ -34,7 +38,7 @@ func OpenFilename(ctx context.Context, erow *core.ERow, index int) (error, bool)
 	filePos := parseutil.NewFilePosFromResource(res)
 
 	// consider middle path (index position) if line/col are not present
-	if considerMiddle && filePos.Line == 0 && filePos.Column == 0 {
+	if considerMiddle && filePos.Line == 0 && filePos.Column == 0 && filePos.Offset < 0 {
 		k := index - res.ExpandedMin
 		if k <= 0 {
 			// don't consider middle for these cases
@@ -82,6 +86,7 @@ func OpenFilename(ctx context.Context, erow *core.ERow, index int) (error, bool)
 			FilePos:               filePos,
 			RowPos:                rowPos,
 			FlashVisibleOffsets:   true,
+			JumpList:              true,
 			NewIfNotExistent:      true,
 			NewIfOffsetNotVisible: true,
 		}
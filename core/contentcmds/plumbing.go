@@ -0,0 +1,219 @@
+package contentcmds
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jmigpin/editor/core"
+	"github.com/jmigpin/editor/util/iout/iorw"
+	"github.com/jmigpin/editor/util/osutil"
+	"github.com/jmigpin/editor/util/parseutil"
+)
+
+// User defined rules, read on each click (changes don't need a restart).
+var PlumbingFilename = filepath.Join(osutil.HomeEnvVar(), ".editor_plumbing.json")
+
+// Rules with priority >= 0 run before the other content cmds. Negative priorities run after (fallbacks).
+func Plumbing(ctx context.Context, erow *core.ERow, index int) (error, bool) {
+	return plumbing(ctx, erow, index, true)
+}
+
+func PlumbingFallback(ctx context.Context, erow *core.ERow, index int) (error, bool) {
+	return plumbing(ctx, erow, index, false)
+}
+
+func plumbing(ctx context.Context, erow *core.ERow, index int, first bool) (error, bool) {
+	rules, err := ReadPlumbRules(PlumbingFilename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, false
+		}
+		return err, true
+	}
+	rules = filterPlumbRules(rules, first)
+	if len(rules) == 0 {
+		return nil, false
+	}
+
+	// text to match: selection or line at index
+	ta := erow.Row.TextArea
+	var rd iorw.Reader
+	if ta.TextCursor.SelectionOn() {
+		a, b := ta.TextCursor.SelectionIndexes()
+		rd = iorw.NewLimitedReader(ta.TextCursor.RW(), a, b, 0)
+	} else {
+		rd = iorw.NewLimitedReader(ta.TextCursor.RW(), index, index, 1000)
+	}
+	b, err := iorw.ReadFullSlice(rd)
+	if err != nil {
+		return err, false
+	}
+	i := index - rd.Min()
+	if i < 0 || i > len(b) {
+		i = 0 // index outside the selection
+	}
+	text, k := plumbLine(b, i)
+
+	rule, args, ok := matchPlumbRules(rules, text, k)
+	if !ok {
+		return nil, false
+	}
+	if err := rule.run(erow, args); err != nil {
+		return fmt.Errorf("%v: %w", rule.Name, err), true
+	}
+	return nil, true
+}
+
+// Line at index i, and the index relative to the line.
+func plumbLine(b []byte, i int) (string, int) {
+	s := 0
+	if k := bytes.LastIndexByte(b[:i], '\n'); k >= 0 {
+		s = k + 1
+	}
+	e := len(b)
+	if k := bytes.IndexByte(b[i:], '\n'); k >= 0 {
+		e = i + k
+	}
+	return string(b[s:e]), i - s
+}
+
+//----------
+
+type PlumbRule struct {
+	Name     string
+	Pattern  string // regular expression
+	Action   string // "open", "cmd" or "url"
+	Arg      string // template: $0 (whole match), $1, ${name} (captured groups). For "cmd", each field is one argument of the cmd (no shell).
+	Priority int    // higher runs first
+
+	re *regexp.Regexp
+}
+
+func (rule *PlumbRule) run(erow *core.ERow, args []string) error {
+	switch rule.Action {
+	case "url":
+		return osutil.OpenBrowser(args[0])
+	case "open":
+		return plumbOpen(erow, args[0])
+	case "cmd":
+		if len(args) == 0 {
+			return fmt.Errorf("empty cmd")
+		}
+		erow.Ed.UI.RunOnUIGoRoutine(func() {
+			// not run with a shell: the captured text can't inject cmds
+			core.ExternalCmdFromArgs(erow, args, nil)
+		})
+		return nil
+	default:
+		return fmt.Errorf("unknown action: %q", rule.Action)
+	}
+}
+
+// Arg format: <filename(:line)?(:col)?>, relative to the row directory.
+func plumbOpen(erow *core.ERow, arg string) error {
+	filePos, err := parseutil.ParseFilePos(arg)
+	if err != nil {
+		return err
+	}
+	filePos.Filename = erow.Ed.HomeVars.Decode(filePos.Filename)
+	filename, _, ok := core.FindFileInfo(filePos.Filename, erow.Info.Dir())
+	if !ok {
+		return fmt.Errorf("fileinfo not found: %q", filePos.Filename)
+	}
+	filePos.Filename = filename
+
+	erow.Ed.UI.RunOnUIGoRoutine(func() {
+		conf := &core.OpenFileERowConfig{
+			FilePos:               filePos,
+			RowPos:                erow.Ed.GoodRowPos(),
+			FlashVisibleOffsets:   true,
+			JumpList:              true,
+			NewIfNotExistent:      true,
+			NewIfOffsetNotVisible: true,
+		}
+		core.OpenFileERow(erow.Ed, conf)
+	})
+	return nil
+}
+
+//----------
+
+func ReadPlumbRules(filename string) ([]*PlumbRule, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePlumbRules(b)
+}
+
+// Parses a json array of rules, sorted by priority (stable).
+func ParsePlumbRules(b []byte) ([]*PlumbRule, error) {
+	rules := []*PlumbRule{}
+	if err := json.Unmarshal(b, &rules); err != nil {
+		return nil, fmt.Errorf("plumbing: %w", err)
+	}
+	for i, rule := range rules {
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule%d", i)
+		}
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("plumbing: %v: %w", rule.Name, err)
+		}
+		rule.re = re
+		switch rule.Action {
+		case "open", "cmd", "url":
+		default:
+			return nil, fmt.Errorf("plumbing: %v: unknown action: %q", rule.Name, rule.Action)
+		}
+	}
+	sort.SliceStable(rules, func(a, b int) bool {
+		return rules[a].Priority > rules[b].Priority
+	})
+	return rules, nil
+}
+
+func filterPlumbRules(rules []*PlumbRule, first bool) []*PlumbRule {
+	u := []*PlumbRule{}
+	for _, rule := range rules {
+		if (rule.Priority >= 0) == first {
+			u = append(u, rule)
+		}
+	}
+	return u
+}
+
+// Returns the first rule with a match that contains the index, and the expanded args (one arg, or the cmd args).
+func matchPlumbRules(rules []*PlumbRule, text string, index int) (*PlumbRule, []string, bool) {
+	for _, rule := range rules {
+		for _, m := range rule.re.FindAllStringSubmatchIndex(text, -1) {
+			if index < m[0] || index >= m[1] {
+				continue
+			}
+			return rule, rule.expand(text, m), true
+		}
+	}
+	return nil, nil, false
+}
+
+func (rule *PlumbRule) expand(text string, m []int) []string {
+	if rule.Action != "cmd" {
+		arg := rule.re.ExpandString(nil, rule.Arg, text, m)
+		return []string{string(arg)}
+	}
+	// expand each field: the captured text stays in one arg
+	u := []string{}
+	for _, f := range strings.Fields(rule.Arg) {
+		arg := rule.re.ExpandString(nil, f, text, m)
+		u = append(u, string(arg))
+	}
+	return u
+}
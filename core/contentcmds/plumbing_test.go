@@ -0,0 +1,99 @@
+package contentcmds
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPlumbRules1(t *testing.T) {
+	b := []byte(`[
+		{"name":"jira","pattern":"JIRA-([0-9]+)","action":"url","arg":"https://tracker/browse/JIRA-$1"},
+		{"name":"pr","pattern":"#(?P<n>[0-9]+)","action":"url","arg":"https://host/pull/${n}","priority":1},
+		{"name":"commit","pattern":"\\b[0-9a-f]{7,40}\\b","action":"cmd","arg":"git show $0","priority":-1}
+	]`)
+	rules, err := ParsePlumbRules(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rules[0].Name != "pr" || rules[2].Name != "commit" {
+		t.Fatalf("bad order: %v %v %v", rules[0].Name, rules[1].Name, rules[2].Name)
+	}
+
+	type test struct {
+		text  string
+		index int
+		first bool
+		name  string // empty: no match
+		arg   string
+	}
+	tests := []test{
+		{"see JIRA-123 now", 6, true, "jira", "https://tracker/browse/JIRA-123"},
+		{"see JIRA-123 now", 12, true, "", ""}, // after the match
+		{"see JIRA-123 now", 2, true, "", ""},
+		{"fixed in #1234.", 10, true, "pr", "https://host/pull/1234"},
+		{"commit 1a2b3c4d", 9, true, "", ""},
+		{"commit 1a2b3c4d", 9, false, "commit", "git show 1a2b3c4d"},
+		{"JIRA-1 JIRA-2", 8, true, "jira", "https://tracker/browse/JIRA-2"},
+	}
+	for _, tt := range tests {
+		rs := filterPlumbRules(rules, tt.first)
+		rule, args, ok := matchPlumbRules(rs, tt.text, tt.index)
+		if tt.name == "" {
+			if ok {
+				t.Fatalf("%q:%v: unexpected match: %v", tt.text, tt.index, rule.Name)
+			}
+			continue
+		}
+		if !ok || rule.Name != tt.name || strings.Join(args, " ") != tt.arg {
+			t.Fatalf("%q:%v: got %v %v %q", tt.text, tt.index, ok, rule, args)
+		}
+	}
+}
+
+func TestPlumbRulesCmdArgs(t *testing.T) {
+	b := []byte(`[{"name":"pr","pattern":"#(\\S+)","action":"cmd","arg":"gh pr view $1"}]`)
+	rules, err := ParsePlumbRules(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// shell metacharacters in the captured text stay in one arg
+	text := "see #1;rm$(x)|`y`&&z"
+	_, args, ok := matchPlumbRules(rules, text, 5)
+	if !ok {
+		t.Fatal("no match")
+	}
+	want := []string{"gh", "pr", "view", "1;rm$(x)|`y`&&z"}
+	if len(args) != len(want) {
+		t.Fatalf("%q", args)
+	}
+	for i := range want {
+		if args[i] != want[i] {
+			t.Fatalf("%q", args)
+		}
+	}
+}
+
+func TestPlumbRulesErrors(t *testing.T) {
+	bad := []string{
+		`[{"pattern":"(","action":"url"}]`,
+		`[{"pattern":"a","action":"mail"}]`,
+		`{}`,
+	}
+	for _, s := range bad {
+		if _, err := ParsePlumbRules([]byte(s)); err == nil {
+			t.Fatalf("expecting error: %v", s)
+		}
+	}
+}
+
+func TestPlumbLine(t *testing.T) {
+	b := []byte("aaa\nbb JIRA-1\ncc")
+	s, k := plumbLine(b, 8)
+	if s != "bb JIRA-1" || k != 4 {
+		t.Fatalf("%q %v", s, k)
+	}
+	s, k = plumbLine(b, 0)
+	if s != "aaa" || k != 0 {
+		t.Fatalf("%q %v", s, k)
+	}
+}
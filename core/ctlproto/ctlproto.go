@@ -0,0 +1,247 @@
+// Control protocol to talk to a running editor over a unix socket. Messages are json objects, one per line.
+package ctlproto
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sync"
+)
+
+// Environment variable with the socket address, set for the processes started by the editor.
+const EnvVar = "edSock"
+
+// Request methods.
+const (
+	MethodOpen      = "open"      // OpenParams -> RowResult
+	MethodRows      = "rows"      // nil -> []*Row
+	MethodGetText   = "getText"   // TextParams -> TextResult
+	MethodSetText   = "setText"   // SetTextParams -> nil
+	MethodRunCmd    = "runCmd"    // RunCmdParams -> nil
+	MethodSubscribe = "subscribe" // SubscribeParams -> nil (events are sent after)
+)
+
+// Methods implemented by rpc plugins (called by the editor).
+const (
+	MethodPluginLoad         = "onLoad"       // nil -> PluginInfo
+	MethodPluginToolbarCmd   = "toolbarCmd"   // ToolbarCmdParams -> nil
+	MethodPluginContentCmd   = "contentCmd"   // ContentCmdParams -> HandledResult
+	MethodPluginAutoComplete = "autoComplete" // AutoCompleteParams -> AutoCompleteResult
+)
+
+// Event names.
+const (
+	EventSave     = "save"
+	EventRowOpen  = "rowOpen"
+	EventRowClose = "rowClose"
+	EventRowState = "rowState"
+)
+
+//----------
+
+type Request struct {
+	Id     int             `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// Response to a request (same id), or an event (zero id) after subscribing.
+type Response struct {
+	Id     int             `json:"id,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+	Event  *Event          `json:"event,omitempty"`
+}
+
+// Request or response, for peers that receive both (ex: rpc plugins).
+type Message struct {
+	Id     int             `json:"id,omitempty"`
+	Method string          `json:"method,omitempty"`
+	Params json.RawMessage `json:"params,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+	Event  *Event          `json:"event,omitempty"`
+}
+
+type Event struct {
+	Name     string `json:"name"`
+	Row      int    `json:"row,omitempty"`
+	Filename string `json:"filename,omitempty"`
+	State    string `json:"state,omitempty"` // rowState
+	Value    bool   `json:"value,omitempty"` // rowState
+}
+
+//----------
+
+// Line/column are one-based and used if line is bigger than zero, otherwise the offset is used.
+type OpenParams struct {
+	Filename string `json:"filename"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+	Offset   int    `json:"offset,omitempty"`
+}
+
+type RowResult struct {
+	Row int `json:"row"`
+}
+
+type Row struct {
+	Id     int    `json:"id"`
+	Name   string `json:"name"`
+	Dir    bool   `json:"dir,omitempty"`
+	Edited bool   `json:"edited,omitempty"`
+	Active bool   `json:"active,omitempty"`
+}
+
+// Row zero is the active row.
+type TextParams struct {
+	Row       int  `json:"row,omitempty"`
+	Selection bool `json:"selection,omitempty"`
+}
+
+type TextResult struct {
+	Text string `json:"text"`
+}
+
+type SetTextParams struct {
+	Row       int    `json:"row,omitempty"`
+	Selection bool   `json:"selection,omitempty"` // replace only the selection
+	Text      string `json:"text"`
+}
+
+// Runs an internal cmd (ex: "Reload", "GotoLine 10"). Row zero is the active row.
+type RunCmdParams struct {
+	Row int    `json:"row,omitempty"`
+	Cmd string `json:"cmd"`
+}
+
+type SubscribeParams struct {
+	Events []string `json:"events"`
+}
+
+//----------
+
+// Hooks implemented by an rpc plugin.
+type PluginInfo struct {
+	ToolbarCmds  []string `json:"toolbarCmds,omitempty"` // cmd names handled
+	ContentCmd   bool     `json:"contentCmd,omitempty"`
+	AutoComplete bool     `json:"autoComplete,omitempty"`
+}
+
+type ToolbarCmdParams struct {
+	Row int    `json:"row,omitempty"` // zero if run from the root toolbar without an active row
+	Cmd string `json:"cmd"`
+}
+
+type ContentCmdParams struct {
+	Row   int `json:"row"`
+	Index int `json:"index"`
+}
+
+type HandledResult struct {
+	Handled bool `json:"handled"`
+}
+
+type AutoCompleteParams struct {
+	Row   int `json:"row"`
+	Index int `json:"index"`
+}
+
+type AutoCompleteResult struct {
+	Handled bool   `json:"handled"`
+	Text    string `json:"text,omitempty"`
+}
+
+//----------
+
+type Client struct {
+	conn net.Conn
+	enc  *json.Encoder
+	dec  *json.Decoder
+
+	mu     sync.Mutex
+	lastId int
+
+	// events received while waiting for a response
+	Events chan *Event
+}
+
+func Dial(addr string) (*Client, error) {
+	conn, err := net.Dial("unix", addr)
+	if err != nil {
+		return nil, err
+	}
+	cli := &Client{
+		conn:   conn,
+		enc:    json.NewEncoder(conn),
+		dec:    json.NewDecoder(bufio.NewReader(conn)),
+		Events: make(chan *Event, 64),
+	}
+	return cli, nil
+}
+
+func (cli *Client) Close() error {
+	return cli.conn.Close()
+}
+
+// Sends a request and waits for the response. The result is decoded into res if not nil. Not to be used concurrently with ReadEvent.
+func (cli *Client) Call(method string, params, res interface{}) error {
+	cli.mu.Lock()
+	defer cli.mu.Unlock()
+
+	cli.lastId++
+	req := &Request{Id: cli.lastId, Method: method}
+	if params != nil {
+		b, err := json.Marshal(params)
+		if err != nil {
+			return err
+		}
+		req.Params = b
+	}
+	if err := cli.enc.Encode(req); err != nil {
+		return err
+	}
+
+	for {
+		resp := &Response{}
+		if err := cli.dec.Decode(resp); err != nil {
+			return err
+		}
+		if resp.Event != nil {
+			select {
+			case cli.Events <- resp.Event:
+			default: // drop
+			}
+			continue
+		}
+		if resp.Id != req.Id {
+			return fmt.Errorf("ctlproto: unexpected response id: %v", resp.Id)
+		}
+		if resp.Error != "" {
+			return fmt.Errorf("%v", resp.Error)
+		}
+		if res != nil && len(resp.Result) > 0 {
+			return json.Unmarshal(resp.Result, res)
+		}
+		return nil
+	}
+}
+
+// Waits for the next event (after subscribing).
+func (cli *Client) ReadEvent() (*Event, error) {
+	select {
+	case ev := <-cli.Events:
+		return ev, nil
+	default:
+	}
+	for {
+		resp := &Response{}
+		if err := cli.dec.Decode(resp); err != nil {
+			return nil, err
+		}
+		if resp.Event != nil {
+			return resp.Event, nil
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package ctlproto
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestClientCall(t *testing.T) {
+	dir, err := ioutil.TempDir("", "editor_ctlproto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	addr := filepath.Join(dir, "s.sock")
+
+	ln, err := net.Listen("unix", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	// server: sends an event before each response
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		dec := json.NewDecoder(conn)
+		enc := json.NewEncoder(conn)
+		for {
+			req := &Request{}
+			if err := dec.Decode(req); err != nil {
+				return
+			}
+			_ = enc.Encode(&Response{Event: &Event{Name: EventSave, Filename: "/a.txt"}})
+			p := &TextParams{}
+			_ = json.Unmarshal(req.Params, p)
+			if p.Row == 0 {
+				_ = enc.Encode(&Response{Id: req.Id, Error: "no active row"})
+				continue
+			}
+			b, _ := json.Marshal(&TextResult{Text: req.Method})
+			_ = enc.Encode(&Response{Id: req.Id, Result: b})
+		}
+	}()
+
+	cli, err := Dial(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	res := &TextResult{}
+	if err := cli.Call(MethodGetText, &TextParams{Row: 1}, res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Text != MethodGetText {
+		t.Fatalf("got %q", res.Text)
+	}
+	if err := cli.Call(MethodGetText, &TextParams{}, nil); err == nil {
+		t.Fatal("expecting error")
+	}
+
+	// events received while waiting for the responses
+	ev, err := cli.ReadEvent()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev.Name != EventSave || ev.Filename != "/a.txt" {
+		t.Fatalf("got %#v", ev)
+	}
+}
+
+func TestOpenParams(t *testing.T) {
+	p, err := openParams("/a/b/c.go:12:3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Filename != "/a/b/c.go" || p.Line != 12 || p.Column != 3 {
+		t.Fatalf("%#v", p)
+	}
+
+	// existing file with a colon in the name
+	dir, err := ioutil.TempDir("", "editor_ctlproto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "a:1")
+	if err := ioutil.WriteFile(name, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	p, err = openParams(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Filename != name || p.Line != 0 {
+		t.Fatalf("%#v", p)
+	}
+}
+
+func TestUserSocketDir(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no permissions check")
+	}
+	dir, err := ioutil.TempDir("", "editor_ctlproto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	old := os.Getenv("XDG_RUNTIME_DIR")
+	defer os.Setenv("XDG_RUNTIME_DIR", old)
+	os.Setenv("XDG_RUNTIME_DIR", dir)
+
+	sdir, err := UserSocketDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sdir != filepath.Join(dir, "editor") {
+		t.Fatal(sdir)
+	}
+
+	// accessible by other users
+	if err := os.Chmod(sdir, 0777); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := UserSocketDir(); err == nil {
+		t.Fatal("expecting error")
+	}
+
+	// symlink to a private dir
+	if err := os.Remove(sdir); err != nil {
+		t.Fatal(err)
+	}
+	other := filepath.Join(dir, "other")
+	if err := os.Mkdir(other, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(other, sdir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := UserSocketDir(); err == nil {
+		t.Fatal("expecting error")
+	}
+}
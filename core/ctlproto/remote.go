@@ -0,0 +1,98 @@
+package ctlproto
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/jmigpin/editor/util/parseutil"
+)
+
+// Directory for the sockets of the user: "$XDG_RUNTIME_DIR/editor", or a per-user directory in the temporary dir. Created with 0700. Fails if the directory is not private to the user (ex: created first by another user).
+func UserSocketDir() (string, error) {
+	dir := filepath.Join(os.TempDir(), fmt.Sprintf("editor_user%d", os.Getuid()))
+	if d := os.Getenv("XDG_RUNTIME_DIR"); d != "" {
+		dir = filepath.Join(d, "editor")
+	}
+	if err := os.Mkdir(dir, 0700); err != nil && !os.IsExist(err) {
+		return "", err
+	}
+	if err := checkPrivateDir(dir); err != nil {
+		return "", fmt.Errorf("socket dir: %v: %w", dir, err)
+	}
+	return dir, nil
+}
+
+// Address of the first editor instance of the user (other instances listen on a per-process address in the same directory).
+func UserAddr() (string, error) {
+	dir, err := UserSocketDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "editor.sock"), nil
+}
+
+// Address of the editor that started this process, or of the user first instance.
+func DefaultAddr() (string, error) {
+	if addr := os.Getenv(EnvVar); addr != "" {
+		return addr, nil
+	}
+	return UserAddr()
+}
+
+//----------
+
+// Opens the files (<filename:line?:col?>) in the editor. If wait is set, returns only after the opened rows are closed (ex: usage as $EDITOR).
+func (cli *Client) OpenFiles(filenames []string, wait bool) error {
+	if wait {
+		p := &SubscribeParams{Events: []string{EventRowClose}}
+		if err := cli.Call(MethodSubscribe, p, nil); err != nil {
+			return err
+		}
+	}
+
+	rows := map[int]bool{}
+	for _, s := range filenames {
+		p, err := openParams(s)
+		if err != nil {
+			return err
+		}
+		res := &RowResult{}
+		if err := cli.Call(MethodOpen, p, res); err != nil {
+			return fmt.Errorf("%v: %w", s, err)
+		}
+		rows[res.Row] = true
+	}
+
+	for wait && len(rows) > 0 {
+		ev, err := cli.ReadEvent()
+		if err != nil {
+			return err
+		}
+		if ev.Name == EventRowClose {
+			delete(rows, ev.Row)
+		}
+	}
+	return nil
+}
+
+func openParams(s string) (*OpenParams, error) {
+	p := &OpenParams{Filename: s}
+
+	// existing filename (ex: with spaces or colons), otherwise parse position
+	if _, err := os.Stat(s); err != nil {
+		fp, err := parseutil.ParseFilePos(s)
+		if err != nil {
+			return nil, err
+		}
+		p.Filename = parseutil.RemoveFilenameEscapes(fp.Filename, parseutil.Escape, parseutil.PathSeparator)
+		p.Line, p.Column = fp.Line, fp.Column
+	}
+
+	u, err := filepath.Abs(p.Filename)
+	if err != nil {
+		return nil, err
+	}
+	p.Filename = u
+	return p, nil
+}
@@ -0,0 +1,31 @@
+// +build !windows
+
+package ctlproto
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// Directory owned by the user, not a symlink, and without permissions for others.
+func checkPrivateDir(dir string) error {
+	fi, err := os.Lstat(dir)
+	if err != nil {
+		return err
+	}
+	if !fi.IsDir() {
+		return fmt.Errorf("not a directory")
+	}
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fmt.Errorf("unable to get owner")
+	}
+	if int(st.Uid) != os.Getuid() {
+		return fmt.Errorf("not owned by the user")
+	}
+	if fi.Mode().Perm()&0077 != 0 {
+		return fmt.Errorf("permissions allow other users: %v", fi.Mode().Perm())
+	}
+	return nil
+}
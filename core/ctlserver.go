@@ -0,0 +1,577 @@
+package core
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/jmigpin/editor/core/ctlproto"
+	"github.com/jmigpin/editor/core/toolbarparser"
+	"github.com/jmigpin/editor/ui"
+	"github.com/jmigpin/editor/util/evreg"
+	"github.com/jmigpin/editor/util/parseutil"
+)
+
+// Control server: allows scripts and external programs to talk to the editor (see ctlproto pkg). Requests are handled in the UI goroutine. Also handles the requests of rpc plugins.
+type CtlServer struct {
+	ed   *Editor
+	addr string
+	ln   net.Listener
+	wg   sync.WaitGroup
+
+	ids struct {
+		sync.Mutex
+		rows   map[*ERow]int
+		rowIds map[int]*ERow
+		lastId int
+	}
+
+	conns map[*ctlConn]bool // UI goroutine
+	regs  []*evreg.Regist
+}
+
+func NewCtlServer(ed *Editor) *CtlServer {
+	srv := &CtlServer{ed: ed, conns: map[*ctlConn]bool{}}
+	srv.ids.rows = map[*ERow]int{}
+	srv.ids.rowIds = map[int]*ERow{}
+	srv.registerEvents()
+	return srv
+}
+
+// Listens on a unix socket.
+func (srv *CtlServer) Listen() error {
+	// first instance of the user listens on the user address (used by remote clients)
+	addr, err := ctlproto.UserAddr()
+	if err != nil {
+		return err
+	}
+	if conn, err := net.Dial("unix", addr); err == nil {
+		_ = conn.Close() // another instance is running
+		name := fmt.Sprintf("editor_%d.sock", os.Getpid())
+		addr = filepath.Join(filepath.Dir(addr), name)
+	}
+	_ = os.Remove(addr) // old socket (not listening)
+	ln, err := net.Listen("unix", addr)
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(addr, 0600); err != nil {
+		_ = ln.Close()
+		return err
+	}
+	srv.ln, srv.addr = ln, addr
+
+	srv.wg.Add(1)
+	go func() {
+		defer srv.wg.Done()
+		srv.acceptLoop()
+	}()
+	return nil
+}
+
+// Empty if not listening.
+func (srv *CtlServer) Addr() string {
+	return srv.addr
+}
+
+func (srv *CtlServer) Close() {
+	if srv.ln != nil {
+		_ = srv.ln.Close() // also removes the socket file
+		srv.wg.Wait()
+	}
+	for _, reg := range srv.regs {
+		reg.Unregister()
+	}
+}
+
+//----------
+
+func (srv *CtlServer) acceptLoop() {
+	for {
+		conn, err := srv.ln.Accept()
+		if err != nil {
+			return // closed
+		}
+		srv.newConn(conn)
+	}
+}
+
+func (srv *CtlServer) newConn(rwc io.ReadWriteCloser) *ctlConn {
+	cc := &ctlConn{
+		srv:        srv,
+		rwc:        rwc,
+		sendq:      make(chan interface{}, 256),
+		subscribed: map[string]bool{},
+	}
+	cc.calls.m = map[int]chan *ctlproto.Message{}
+	srv.ed.UI.RunOnUIGoRoutine(func() {
+		srv.conns[cc] = true
+	})
+	go cc.sendLoop()
+	go cc.receiveLoop()
+	return cc
+}
+
+//----------
+
+func (srv *CtlServer) registerEvents() {
+	reg := func(eid EEventId, fn func(interface{})) {
+		srv.regs = append(srv.regs, srv.ed.EEvents.Register(eid, fn))
+	}
+	reg(PostNewERowEEventId, func(ev0 interface{}) {
+		ev := ev0.(*PostNewERowEEvent)
+		srv.emit(&ctlproto.Event{
+			Name:     ctlproto.EventRowOpen,
+			Row:      srv.rowId(ev.ERow),
+			Filename: ev.ERow.Info.Name(),
+		})
+	})
+	reg(PreRowCloseEEventId, func(ev0 interface{}) {
+		ev := ev0.(*PreRowCloseEEvent)
+		srv.emit(&ctlproto.Event{
+			Name:     ctlproto.EventRowClose,
+			Row:      srv.rowId(ev.ERow),
+			Filename: ev.ERow.Info.Name(),
+		})
+		srv.removeRowId(ev.ERow)
+	})
+	reg(PostFileSaveEEventId, func(ev0 interface{}) {
+		ev := ev0.(*PostFileSaveEEvent)
+		srv.emit(&ctlproto.Event{
+			Name:     ctlproto.EventSave,
+			Filename: ev.Info.Name(),
+		})
+	})
+	reg(RowStateChangeEEventId, func(ev0 interface{}) {
+		ev := ev0.(*RowStateChangeEEvent)
+		srv.emit(&ctlproto.Event{
+			Name:     ctlproto.EventRowState,
+			Row:      srv.rowId(ev.ERow),
+			Filename: ev.ERow.Info.Name(),
+			State:    ctlRowStateNames[ev.State],
+			Value:    ev.Value,
+		})
+	})
+}
+
+func (srv *CtlServer) emit(ev *ctlproto.Event) {
+	for cc := range srv.conns {
+		if cc.subscribed[ev.Name] {
+			if !cc.send(&ctlproto.Response{Event: ev}, false) {
+				cc.dropped++ // reported when the conn closes
+			}
+		}
+	}
+}
+
+//----------
+
+func (srv *CtlServer) rowId(erow *ERow) int {
+	srv.ids.Lock()
+	defer srv.ids.Unlock()
+	id, ok := srv.ids.rows[erow]
+	if !ok {
+		srv.ids.lastId++
+		id = srv.ids.lastId
+		srv.ids.rows[erow] = id
+		srv.ids.rowIds[id] = erow
+	}
+	return id
+}
+
+func (srv *CtlServer) removeRowId(erow *ERow) {
+	srv.ids.Lock()
+	defer srv.ids.Unlock()
+	id := srv.ids.rows[erow]
+	delete(srv.ids.rows, erow)
+	delete(srv.ids.rowIds, id)
+}
+
+// Zero id is the active row.
+func (srv *CtlServer) erow(id int) (*ERow, error) {
+	if id == 0 {
+		erow, ok := srv.ed.ActiveERow()
+		if !ok {
+			return nil, fmt.Errorf("no active row")
+		}
+		return erow, nil
+	}
+	srv.ids.Lock()
+	erow, ok := srv.ids.rowIds[id]
+	srv.ids.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("row not found: %v", id)
+	}
+	return erow, nil
+}
+
+//----------
+
+// UI goroutine.
+func (srv *CtlServer) handle(cc *ctlConn, req *ctlproto.Request) (interface{}, error) {
+	decode := func(v interface{}) error {
+		if len(req.Params) == 0 {
+			return fmt.Errorf("missing params")
+		}
+		return json.Unmarshal(req.Params, v)
+	}
+	switch req.Method {
+	case ctlproto.MethodOpen:
+		p := &ctlproto.OpenParams{}
+		if err := decode(p); err != nil {
+			return nil, err
+		}
+		return srv.open(p)
+	case ctlproto.MethodRows:
+		return srv.listRows(), nil
+	case ctlproto.MethodGetText:
+		p := &ctlproto.TextParams{}
+		if err := decode(p); err != nil {
+			return nil, err
+		}
+		return srv.getText(p)
+	case ctlproto.MethodSetText:
+		p := &ctlproto.SetTextParams{}
+		if err := decode(p); err != nil {
+			return nil, err
+		}
+		return nil, srv.setText(p)
+	case ctlproto.MethodRunCmd:
+		p := &ctlproto.RunCmdParams{}
+		if err := decode(p); err != nil {
+			return nil, err
+		}
+		return nil, srv.runCmd(p)
+	case ctlproto.MethodSubscribe:
+		p := &ctlproto.SubscribeParams{}
+		if err := decode(p); err != nil {
+			return nil, err
+		}
+		for _, name := range p.Events {
+			cc.subscribed[name] = true
+		}
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown method: %q", req.Method)
+	}
+}
+
+func (srv *CtlServer) open(p *ctlproto.OpenParams) (interface{}, error) {
+	if !filepath.IsAbs(p.Filename) {
+		return nil, fmt.Errorf("filename not absolute: %v", p.Filename)
+	}
+	conf := &OpenFileERowConfig{
+		FilePos: &parseutil.FilePos{
+			Filename: p.Filename,
+			Offset:   p.Offset,
+			Line:     p.Line,
+			Column:   p.Column,
+		},
+		RowPos:              srv.ed.GoodRowPos(),
+		FlashVisibleOffsets: true,
+		NewIfNotExistent:    true,
+	}
+	if _, err := openFileERow2(srv.ed, conf); err != nil {
+		return nil, err
+	}
+
+	// opened row: active or first in the ui
+	info := srv.ed.ReadERowInfo(p.Filename)
+	erow, ok := srv.ed.ActiveERow()
+	if !ok || erow.Info != info {
+		erows := info.ERowsInUIOrder()
+		if len(erows) == 0 {
+			return nil, fmt.Errorf("row not opened: %v", p.Filename)
+		}
+		erow = erows[0]
+	}
+	return &ctlproto.RowResult{Row: srv.rowId(erow)}, nil
+}
+
+func (srv *CtlServer) listRows() []*ctlproto.Row {
+	active, _ := srv.ed.ActiveERow()
+	u := []*ctlproto.Row{}
+	for _, erow := range srv.ed.ERows() {
+		u = append(u, &ctlproto.Row{
+			Id:     srv.rowId(erow),
+			Name:   erow.Info.Name(),
+			Dir:    erow.Info.IsDir(),
+			Edited: erow.Row.HasState(ui.RowStateEdited),
+			Active: erow == active,
+		})
+	}
+	return u
+}
+
+func (srv *CtlServer) getText(p *ctlproto.TextParams) (interface{}, error) {
+	erow, err := srv.erow(p.Row)
+	if err != nil {
+		return nil, err
+	}
+	tc := erow.Row.TextArea.TextCursor
+	a, b := tc.RW().Min(), tc.RW().Max()
+	if p.Selection {
+		if !tc.SelectionOn() {
+			return nil, fmt.Errorf("no selection")
+		}
+		a, b = tc.SelectionIndexes()
+	}
+	s, err := tc.RW().ReadNSliceAt(a, b-a)
+	if err != nil {
+		return nil, err
+	}
+	return &ctlproto.TextResult{Text: string(s)}, nil
+}
+
+func (srv *CtlServer) setText(p *ctlproto.SetTextParams) error {
+	erow, err := srv.erow(p.Row)
+	if err != nil {
+		return err
+	}
+	tc := erow.Row.TextArea.TextCursor
+	a, b := tc.RW().Min(), tc.RW().Max()
+	if p.Selection {
+		if !tc.SelectionOn() {
+			return fmt.Errorf("no selection")
+		}
+		a, b = tc.SelectionIndexes()
+	}
+
+	// one undoable edit
+	tc.BeginEdit()
+	defer tc.EndEdit()
+	if err := tc.RW().Overwrite(a, b-a, []byte(p.Text)); err != nil {
+		return err
+	}
+	if p.Selection {
+		tc.SetSelection(a, a+len(p.Text))
+	} else {
+		tc.SetSelectionOff()
+		tc.SetIndex(0)
+	}
+	return nil
+}
+
+func (srv *CtlServer) runCmd(p *ctlproto.RunCmdParams) error {
+	data := toolbarparser.Parse(p.Cmd)
+	if len(data.Parts) == 0 || len(data.Parts[0].Args) == 0 {
+		return fmt.Errorf("missing cmd")
+	}
+	part := data.Parts[0]
+	name := part.Args[0].UnquotedStr()
+	cmd, ok := InternalCmds[name]
+	if !ok {
+		return fmt.Errorf("unknown internal cmd: %v", name)
+	}
+
+	args := &InternalCmdArgs{Ed: srv.ed, Part: part}
+	if !cmd.RootTbOnly {
+		erow, err := srv.erow(p.Row)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := erow.newInternalCmdCtx()
+		defer cancel()
+		args.ERow = erow
+		args.Ctx = ctx
+	} else {
+		args.Ctx = context.Background()
+	}
+	return cmd.Fn(args)
+}
+
+//----------
+
+var ctlRowStateNames = map[ui.RowState]string{
+	ui.RowStateActive:             "active",
+	ui.RowStateExecuting:          "executing",
+	ui.RowStateEdited:             "edited",
+	ui.RowStateFsDiffer:           "fsDiffer",
+	ui.RowStateNotExist:           "notExist",
+	ui.RowStateDuplicate:          "duplicate",
+	ui.RowStateDuplicateHighlight: "duplicateHighlight",
+	ui.RowStateAnnotations:        "annotations",
+	ui.RowStateAnnotationsEdited:  "annotationsEdited",
+	ui.RowStateWatching:           "watching",
+}
+
+//----------
+
+// Control server connection (socket client or rpc plugin). Handles requests from the peer, and allows calling the peer (rpc plugins).
+type ctlConn struct {
+	srv        *CtlServer
+	rwc        io.ReadWriteCloser
+	sendq      chan interface{}
+	subscribed map[string]bool // UI goroutine
+	dropped    int             // UI goroutine: events dropped (peer not reading)
+
+	sendState struct {
+		sync.Mutex
+		closed bool
+	}
+	calls struct {
+		sync.Mutex
+		lastId int
+		m      map[int]chan *ctlproto.Message
+	}
+}
+
+func (cc *ctlConn) receiveLoop() {
+	defer cc.closeSend()
+	dec := json.NewDecoder(bufio.NewReader(cc.rwc))
+	for {
+		msg := &ctlproto.Message{}
+		if err := dec.Decode(msg); err != nil {
+			return // closed or bad input
+		}
+		if msg.Method == "" {
+			cc.response(msg)
+			continue
+		}
+		resp := cc.handle(msg)
+		cc.send(resp, true)
+	}
+}
+
+func (cc *ctlConn) handle(msg *ctlproto.Message) *ctlproto.Response {
+	req := &ctlproto.Request{Id: msg.Id, Method: msg.Method, Params: msg.Params}
+	resp := &ctlproto.Response{Id: req.Id}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	cc.srv.ed.UI.RunOnUIGoRoutine(func() {
+		defer wg.Done()
+		res, err := cc.srv.handle(cc, req)
+		if err != nil {
+			resp.Error = err.Error()
+			return
+		}
+		if res != nil {
+			b, err := json.Marshal(res)
+			if err != nil {
+				resp.Error = err.Error()
+				return
+			}
+			resp.Result = b
+		}
+	})
+	wg.Wait()
+	return resp
+}
+
+func (cc *ctlConn) sendLoop() {
+	enc := json.NewEncoder(cc.rwc)
+	var err error
+	for v := range cc.sendq {
+		if err != nil {
+			continue // drain until closed
+		}
+		if err = enc.Encode(v); err != nil {
+			_ = cc.rwc.Close() // ends the receive loop
+		}
+	}
+	_ = cc.rwc.Close()
+}
+
+// Returns false if closed, or if not blocking and the peer is not reading (events are dropped).
+func (cc *ctlConn) send(v interface{}, block bool) bool {
+	cc.sendState.Lock()
+	defer cc.sendState.Unlock()
+	if cc.sendState.closed {
+		return false
+	}
+	if block {
+		cc.sendq <- v // the send loop never stops reading until closed
+		return true
+	}
+	select {
+	case cc.sendq <- v:
+		return true
+	default:
+		return false
+	}
+}
+
+func (cc *ctlConn) closeSend() {
+	cc.sendState.Lock()
+	cc.sendState.closed = true
+	close(cc.sendq)
+	cc.sendState.Unlock()
+
+	cc.calls.Lock()
+	for id, ch := range cc.calls.m {
+		close(ch) // pending calls
+		delete(cc.calls.m, id)
+	}
+	cc.calls.Unlock()
+
+	cc.srv.ed.UI.RunOnUIGoRoutine(func() {
+		delete(cc.srv.conns, cc)
+		if cc.dropped > 0 {
+			cc.srv.ed.Errorf("ctlserver: dropped %v events (peer not reading)", cc.dropped)
+		}
+	})
+}
+
+//----------
+
+// Calls a method of the peer (rpc plugins). Should not be used in the UI goroutine (the peer might need the UI goroutine to answer).
+func (cc *ctlConn) call(ctx context.Context, method string, params, res interface{}) error {
+	req := &ctlproto.Request{Method: method}
+	if params != nil {
+		b, err := json.Marshal(params)
+		if err != nil {
+			return err
+		}
+		req.Params = b
+	}
+
+	ch := make(chan *ctlproto.Message, 1)
+	cc.calls.Lock()
+	cc.calls.lastId++
+	req.Id = cc.calls.lastId
+	cc.calls.m[req.Id] = ch
+	cc.calls.Unlock()
+	defer func() {
+		cc.calls.Lock()
+		delete(cc.calls.m, req.Id)
+		cc.calls.Unlock()
+	}()
+
+	if !cc.send(req, true) {
+		return fmt.Errorf("connection closed")
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case msg, ok := <-ch:
+		if !ok {
+			return fmt.Errorf("connection closed")
+		}
+		if msg.Error != "" {
+			return fmt.Errorf("%v", msg.Error)
+		}
+		if res != nil && len(msg.Result) > 0 {
+			return json.Unmarshal(msg.Result, res)
+		}
+		return nil
+	}
+}
+
+func (cc *ctlConn) response(msg *ctlproto.Message) {
+	cc.calls.Lock()
+	defer cc.calls.Unlock()
+	if ch, ok := cc.calls.m[msg.Id]; ok {
+		ch <- msg // buffered
+		delete(cc.calls.m, msg.Id)
+	}
+}
+
+func (cc *ctlConn) close() {
+	_ = cc.rwc.Close()
+}
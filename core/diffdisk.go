@@ -0,0 +1,92 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jmigpin/editor/util/diffutil"
+)
+
+// Lines of the row content, the file on disk, and the content of the last load/save (base). UI goroutine.
+func diskDiffContents(erow *ERow) (row, disk, base []string, _ error) {
+	info := erow.Info
+	if !info.IsFileButNotDir() {
+		return nil, nil, nil, fmt.Errorf("not a file")
+	}
+	b, err := erow.Row.TextArea.Bytes()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	d, err := info.readFsFile() // also updates the fs hash
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return diffutil.SplitLines(b), diffutil.SplitLines(d), diffutil.SplitLines(info.savedBytes), nil
+}
+
+// Unified diff from the file on disk to the row content, with clickable positions of the row. UI goroutine.
+func DiffDisk(erow *ERow) error {
+	row, disk, _, err := diskDiffContents(erow)
+	if err != nil {
+		return err
+	}
+	ed := erow.Ed
+	filename := ed.HomeVars.Encode(erow.Info.Name())
+	erow2, isNew := ed.ExistingOrNewERow("+DiffDisk")
+	if !isNew {
+		erow2.Flash()
+	}
+	erow2.Row.TextArea.SetStrClearHistory("")
+	erow2.Exec.Start(func(ctx context.Context, w io.Writer) error {
+		hunks := diffutil.DiffLines(disk, row)
+		if len(hunks) == 0 {
+			_, err := fmt.Fprintf(w, "%v: no differences\n", filename)
+			return err
+		}
+		buf := &bytes.Buffer{}
+		err := diffutil.WriteUnified(buf, filename+" (disk)", filename+" (row)", disk, row, hunks, 3)
+		if err != nil {
+			return err
+		}
+		return writeDiffPositions(w, buf.Bytes(), filename)
+	})
+	return nil
+}
+
+// Three-way merge of the row edits and the changes on disk, using the content of the last load/save as base. The result replaces the row content as one undoable edit, with the conflicts marked. The file on disk becomes the new base (saving writes the merge). UI goroutine.
+func MergeDisk(erow *ERow) (int, error) {
+	info := erow.Info
+	if info.savedBytes == nil {
+		return 0, fmt.Errorf("no saved content to use as base")
+	}
+	row, disk, base, err := diskDiffContents(erow)
+	if err != nil {
+		return 0, err
+	}
+	res, conflicts := diffutil.Merge3(base, row, disk, "row", "disk")
+	b := []byte(strings.Join(res, ""))
+
+	tc := erow.Row.TextArea.TextCursor
+	ci := tc.Index()
+	tc.BeginEdit()
+	if err := tc.RW().Overwrite(tc.RW().Min(), tc.RW().Max()-tc.RW().Min(), b); err != nil {
+		tc.EndEdit()
+		return 0, err
+	}
+	if ci > tc.RW().Max() {
+		ci = tc.RW().Max()
+	}
+	tc.SetIndex(ci)
+	tc.EndEdit()
+
+	// the disk content is the new base
+	d := []byte(strings.Join(disk, ""))
+	info.setSavedHash(info.fsHash.hash, len(d))
+	info.savedBytes = d
+	info.UpdateEditedRowState()
+
+	return conflicts, nil
+}
@@ -0,0 +1,378 @@
+package core
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jmigpin/editor/ui"
+	"github.com/jmigpin/editor/util/diffutil"
+	"github.com/jmigpin/editor/util/drawutil/drawer4"
+	"github.com/jmigpin/editor/util/evreg"
+	"github.com/jmigpin/editor/util/iout/iorw"
+	"github.com/jmigpin/editor/util/parseutil"
+)
+
+// Side-by-side diff of two rows: changed lines and words are highlighted, and the scrolling is synchronized. The diff is updated (in the background) after the rows are edited. Should be used in the UI goroutine.
+type DiffView struct {
+	rows    [2]*ERow
+	hunks   []*diffutil.Hunk
+	regs    []*evreg.Regist
+	syncing bool // scrolling the other row
+	timer   *time.Timer
+	seq     int // current update, results of older updates are discarded
+	stopped bool
+}
+
+// Delay after the last edit to update the diff.
+var diffViewUpdateDelay = 300 * time.Millisecond
+
+//----------
+
+// Arguments are filenames (relative to the row directory), "this" (the row running the cmd) or "other" (the previously active row). The files are opened in two new columns. Rows that are not files (ex: cmd output, directories, +Messages) are diffed with a copy of their content in a "+Diff:<name>" row.
+func DiffCmd(ed *Editor, erow *ERow, a, b string) (*DiffView, error) {
+	srcs := [2]*diffViewSource{}
+	for i, arg := range []string{a, b} {
+		src, err := diffViewArgSource(ed, erow, arg)
+		if err != nil {
+			return nil, err
+		}
+		srcs[i] = src
+	}
+	if srcs[0].name != "" && srcs[0].name == srcs[1].name {
+		srcs[1].name += "(2)" // same row on both sides
+	}
+
+	dv := &DiffView{}
+	cols := []*ui.Column{}
+	for i, src := range srcs {
+		col := ed.NewColumn()
+		cols = append(cols, col)
+		erow2, err := src.newERow(ed, col)
+		if err != nil {
+			for _, col := range cols {
+				col.Close()
+			}
+			return nil, err
+		}
+		dv.rows[i] = erow2
+	}
+
+	for i, erow2 := range dv.rows {
+		i := i
+		if erow2.diffView != nil {
+			erow2.diffView.Stop()
+		}
+		erow2.diffView = dv
+		ta := erow2.Row.TextArea
+		reg1 := ta.EvReg.Add(ui.TextAreaSetStrEventId, func(ev interface{}) {
+			dv.updateLater()
+		})
+		reg2 := ta.EvReg.Add(ui.TextAreaScrollEventId, func(ev interface{}) {
+			dv.syncScroll(i)
+		})
+		dv.regs = append(dv.regs, reg1, reg2)
+	}
+	dv.update()
+	return dv, nil
+}
+
+//----------
+
+// Content of one side: a file, or a copy of the content of a row that is not a file.
+type diffViewSource struct {
+	filename string
+	name     string // row with the copy
+	bytes    []byte
+}
+
+func diffViewArgSource(ed *Editor, erow *ERow, arg string) (*diffViewSource, error) {
+	switch arg {
+	case "this":
+		if erow == nil {
+			return nil, fmt.Errorf("no row")
+		}
+		return diffViewRowSource(erow)
+	case "other":
+		e := ed.prevActiveERow
+		if e == nil {
+			return nil, fmt.Errorf("other: no previously active row")
+		}
+		return diffViewRowSource(e)
+	default:
+		dir := ""
+		if erow != nil {
+			dir = erow.Info.Dir()
+		}
+		name := ed.HomeVars.Decode(arg)
+		filename, fi, ok := FindFileInfo(name, dir)
+		if !ok {
+			return nil, fmt.Errorf("file not found: %v", arg)
+		}
+		if fi.IsDir() {
+			return nil, fmt.Errorf("not a file: %v", arg)
+		}
+		return &diffViewSource{filename: filename}, nil
+	}
+}
+
+func diffViewRowSource(erow *ERow) (*diffViewSource, error) {
+	if erow.Info.IsFileButNotDir() {
+		return &diffViewSource{filename: erow.Info.Name()}, nil
+	}
+	b, err := erow.Row.TextArea.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	name := "+Diff:" + erow.Ed.HomeVars.Encode(erow.Info.Name())
+	return &diffViewSource{name: name, bytes: append([]byte(nil), b...)}, nil
+}
+
+func (src *diffViewSource) newERow(ed *Editor, col *ui.Column) (*ERow, error) {
+	rowPos := ui.NewRowPos(col, nil)
+	if src.filename != "" {
+		return ed.ReadERowInfo(src.filename).NewERow(rowPos)
+	}
+	// replace a previous copy (special rows have only one instance)
+	info := ed.ReadERowInfo(src.name)
+	for _, e := range append([]*ERow{}, info.ERows...) {
+		e.Row.Close()
+	}
+	info = ed.ReadERowInfo(src.name) // closing the rows deletes the info
+	erow, err := info.NewERow(rowPos)
+	if err != nil {
+		return nil, err
+	}
+	if err := erow.Row.TextArea.SetBytesClearHistory(src.bytes); err != nil {
+		erow.Row.Close()
+		return nil, err
+	}
+	return erow, nil
+}
+
+// Called when one of the rows is closed, or the rows are used in a new diff view.
+func (dv *DiffView) Stop() {
+	for _, reg := range dv.regs {
+		reg.Unregister()
+	}
+	dv.regs = nil
+	if dv.timer != nil {
+		dv.timer.Stop()
+	}
+	dv.stopped = true
+	for _, erow := range dv.rows {
+		if erow.diffView == dv {
+			erow.diffView = nil
+			erow.Row.TextArea.SetDiff(nil, nil)
+		}
+	}
+}
+
+//----------
+
+func (dv *DiffView) updateLater() {
+	dv.seq++ // pending results have outdated offsets
+	if dv.timer != nil {
+		dv.timer.Stop()
+	}
+	dv.timer = time.AfterFunc(diffViewUpdateDelay, func() {
+		dv.rows[0].Ed.UI.RunOnUIGoRoutine(func() {
+			if !dv.stopped {
+				dv.update()
+			}
+		})
+	})
+}
+
+// Computes the diff in the background with a copy of the rows content.
+func (dv *DiffView) update() {
+	bs := [2][]byte{}
+	for i, erow := range dv.rows {
+		b, err := erow.Row.TextArea.Bytes()
+		if err != nil {
+			erow.Ed.Error(err)
+			return
+		}
+		bs[i] = append([]byte(nil), b...) // might share the row buffer
+	}
+	dv.seq++
+	seq := dv.seq
+	go func() {
+		a, b := diffutil.SplitLines(bs[0]), diffutil.SplitLines(bs[1])
+		hunks := diffutil.DiffLines(a, b)
+		la, wa, lb, wb := diffViewEntries(a, b, hunks)
+		dv.rows[0].Ed.UI.RunOnUIGoRoutine(func() {
+			if dv.stopped || dv.seq != seq {
+				return // superseded
+			}
+			dv.hunks = hunks
+			dv.rows[0].Row.TextArea.SetDiff(la, wa)
+			dv.rows[1].Row.TextArea.SetDiff(lb, wb)
+		})
+	}()
+}
+
+// Line entries and word entries of both sides. Lines of changes with lines on both sides are paired as modified (with the changed words), the other lines are removed/added.
+func diffViewEntries(a, b []string, hunks []*diffutil.Hunk) (la []*drawer4.ChangeEntry, wa []*drawer4.DiffWord, lb []*drawer4.ChangeEntry, wb []*drawer4.DiffWord) {
+	oa, ob := diffLineOffsets(a), diffLineOffsets(b)
+	entry := func(lines []string, offsets []int, i int, kind drawer4.ChangeKind) *drawer4.ChangeEntry {
+		s, e := offsets[i], offsets[i+1]
+		if e > s && lines[i][len(lines[i])-1] == '\n' {
+			e-- // exclude the newline
+		}
+		return &drawer4.ChangeEntry{Start: s, End: e, Kind: kind}
+	}
+	words := func(offset int, rs []*diffutil.Range) []*drawer4.DiffWord {
+		u := []*drawer4.DiffWord{}
+		for _, r := range rs {
+			u = append(u, &drawer4.DiffWord{Start: offset + r.Start, End: offset + r.End})
+		}
+		return u
+	}
+	for _, h := range hunks {
+		na, nb := h.A2-h.A1, h.B2-h.B1
+		for k := 0; k < na || k < nb; k++ {
+			i, j := h.A1+k, h.B1+k
+			switch {
+			case k < na && k < nb:
+				la = append(la, entry(a, oa, i, drawer4.ChangeModified))
+				lb = append(lb, entry(b, ob, j, drawer4.ChangeModified))
+				ra, rb := diffutil.WordDiff(a[i], b[j])
+				wa = append(wa, words(oa[i], ra)...)
+				wb = append(wb, words(ob[j], rb)...)
+			case k < na:
+				la = append(la, entry(a, oa, i, drawer4.ChangeDeleted))
+			default:
+				lb = append(lb, entry(b, ob, j, drawer4.ChangeAdded))
+			}
+		}
+	}
+	return
+}
+
+// Start offset of each line, plus the end offset.
+func diffLineOffsets(lines []string) []int {
+	u := make([]int, len(lines)+1)
+	for i, l := range lines {
+		u[i+1] = u[i] + len(l)
+	}
+	return u
+}
+
+//----------
+
+// Scrolls the other row to the line that corresponds to the first visible line of row i.
+func (dv *DiffView) syncScroll(i int) {
+	if dv.syncing {
+		return
+	}
+	dv.syncing = true
+	defer func() { dv.syncing = false }()
+
+	ta := dv.rows[i].Row.TextArea
+	line, _, err := parseutil.IndexLineColumn(ta.TextCursor.RW(), ta.RuneOffset())
+	if err != nil {
+		return
+	}
+	line2 := diffMapLine(dv.hunks, line-1, i == 0)
+
+	ta2 := dv.rows[1-i].Row.TextArea
+	o, err := parseutil.LineColumnIndex(ta2.TextCursor.RW(), line2+1, 1)
+	if err != nil {
+		o = ta2.Len()
+	}
+	ta2.SetRuneOffset(o)
+}
+
+// Maps a (zero-based) line of one side to the other side. Lines inside a change map to the corresponding line of the other side (or the last/first line of the change).
+func diffMapLine(hunks []*diffutil.Hunk, line int, aToB bool) int {
+	delta := 0
+	for _, h := range hunks {
+		s1, s2, o1, o2 := h.A1, h.A2, h.B1, h.B2
+		if !aToB {
+			s1, s2, o1, o2 = h.B1, h.B2, h.A1, h.A2
+		}
+		if line < s1 {
+			break
+		}
+		if line < s2 {
+			k := line - s1
+			if n := o2 - o1; k >= n {
+				k = n - 1
+			}
+			if k < 0 {
+				k = 0
+			}
+			return o1 + k
+		}
+		delta = o2 - s2
+	}
+	return line + delta
+}
+
+//----------
+
+// Moves the cursor to the start of the next (or previous) change of the row.
+func (dv *DiffView) gotoChange(erow *ERow, next bool) error {
+	side := 0
+	if erow == dv.rows[1] {
+		side = 1
+	}
+	ta := erow.Row.TextArea
+	rd := ta.TextCursor.RW()
+	line, _, err := parseutil.IndexLineColumn(rd, ta.TextCursor.Index())
+	if err != nil {
+		return err
+	}
+	line-- // zero-based
+
+	starts := []int{}
+	for _, h := range dv.hunks {
+		s := h.A1
+		if side == 1 {
+			s = h.B1
+		}
+		if len(starts) == 0 || starts[len(starts)-1] != s {
+			starts = append(starts, s)
+		}
+	}
+	target := -1
+	if next {
+		for _, s := range starts {
+			if s > line {
+				target = s
+				break
+			}
+		}
+	} else {
+		for k := len(starts) - 1; k >= 0; k-- {
+			if starts[k] < line {
+				target = starts[k]
+				break
+			}
+		}
+	}
+	if target < 0 {
+		return fmt.Errorf("no more changes")
+	}
+	o := diffLineStart(rd, target)
+	ta.TextCursor.SetSelectionOff()
+	ta.TextCursor.SetIndex(o)
+	erow.MakeRangeVisibleAndFlash(o, 0)
+	return nil
+}
+
+func diffLineStart(rd iorw.Reader, line int) int {
+	o, err := parseutil.LineColumnIndex(rd, line+1, 1)
+	if err != nil {
+		return rd.Max()
+	}
+	return o
+}
+
+// Internal cmds NextDiff/PrevDiff.
+func DiffViewGotoChange(erow *ERow, next bool) error {
+	if erow.diffView == nil {
+		return fmt.Errorf("not a diff row")
+	}
+	return erow.diffView.gotoChange(erow, next)
+}
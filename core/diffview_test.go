@@ -0,0 +1,58 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/jmigpin/editor/util/diffutil"
+	"github.com/jmigpin/editor/util/drawutil/drawer4"
+)
+
+func TestDiffViewEntries(t *testing.T) {
+	a := diffutil.SplitLines([]byte("a\nf(x)\nc\nd\n"))
+	b := diffutil.SplitLines([]byte("a\nf(y)\nnew\nc\n"))
+	hunks := diffutil.DiffLines(a, b)
+	la, wa, lb, wb := diffViewEntries(a, b, hunks)
+
+	// a: "f(x)" modified, "d" removed
+	if len(la) != 2 {
+		t.Fatalf("%+v", la)
+	}
+	if *la[0] != (drawer4.ChangeEntry{Start: 2, End: 6, Kind: drawer4.ChangeModified}) {
+		t.Fatalf("%+v", la[0])
+	}
+	if *la[1] != (drawer4.ChangeEntry{Start: 9, End: 10, Kind: drawer4.ChangeDeleted}) {
+		t.Fatalf("%+v", la[1])
+	}
+	if len(wa) != 1 || *wa[0] != (drawer4.DiffWord{Start: 4, End: 5}) {
+		t.Fatalf("%+v", wa)
+	}
+
+	// b: "f(y)" modified, "new" added
+	if len(lb) != 2 {
+		t.Fatalf("%+v", lb)
+	}
+	if *lb[1] != (drawer4.ChangeEntry{Start: 7, End: 10, Kind: drawer4.ChangeAdded}) {
+		t.Fatalf("%+v", lb[1])
+	}
+	if len(wb) != 1 || *wb[0] != (drawer4.DiffWord{Start: 4, End: 5}) {
+		t.Fatalf("%+v", wb)
+	}
+}
+
+func TestDiffMapLine(t *testing.T) {
+	hunks := []*diffutil.Hunk{
+		{A1: 1, A2: 1, B1: 1, B2: 3}, // 2 lines added
+		{A1: 3, A2: 6, B1: 5, B2: 6}, // 3 lines replaced by 1
+	}
+	type tc struct{ line, want int }
+	for _, u := range []tc{{0, 0}, {1, 3}, {2, 4}, {3, 5}, {5, 5}, {6, 6}, {9, 9}} {
+		if v := diffMapLine(hunks, u.line, true); v != u.want {
+			t.Fatalf("a->b %v: %v, want %v", u.line, v, u.want)
+		}
+	}
+	for _, u := range []tc{{0, 0}, {1, 1}, {2, 1}, {3, 1}, {4, 2}, {5, 3}, {6, 6}} {
+		if v := diffMapLine(hunks, u.line, false); v != u.want {
+			t.Fatalf("b->a %v: %v, want %v", u.line, v, u.want)
+		}
+	}
+}
@@ -0,0 +1,358 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jmigpin/editor/core/fswatcher"
+	"github.com/jmigpin/editor/util/mathutil"
+	"github.com/jmigpin/editor/util/osutil"
+	"github.com/jmigpin/editor/util/parseutil"
+)
+
+// Directory row listing as a tree: clicking a directory entry expands/collapses its children in place. Expanded directories are watched for changes. Should only be used in the UI goroutine.
+type DirTree struct {
+	erow     *ERow
+	expanded map[string]bool // slash paths relative to the row directory
+	lines    []string        // entry of each listed line (relative slash path)
+	texts    []string        // text of each listed line, to detect replaced content
+
+	w       *fswatcher.MuxUser
+	watched map[string]bool
+	ctx     context.Context
+	cancel  context.CancelFunc
+	seq     int // current render
+}
+
+// Delay to refresh the listing after changes on disk.
+var dirTreeRefreshDelay = 150 * time.Millisecond
+
+//----------
+
+// Sets the row in tree mode (or updates the expanded directories if already set).
+func EnableDirTree(erow *ERow, expanded []string) error {
+	if !erow.Info.IsDir() {
+		return fmt.Errorf("not a directory")
+	}
+	dt := erow.tree
+	if dt == nil {
+		w := erow.Ed.DirWatcher.NewUser()
+		*w.OpMask() = fswatcher.Create | fswatcher.Remove | fswatcher.Rename
+
+		dt = &DirTree{
+			erow:     erow,
+			expanded: map[string]bool{},
+			w:        w,
+			watched:  map[string]bool{},
+		}
+		ctx, cancel := context.WithCancel(erow.ctx) // ends on row close
+		dt.ctx, dt.cancel = ctx, cancel
+		go dt.loop(ctx)
+		erow.tree = dt
+	}
+	for _, u := range expanded {
+		dt.expanded[u] = true
+	}
+	dt.render()
+	return nil
+}
+
+func IsDirTree(erow *ERow) bool {
+	return erow.tree != nil
+}
+
+// Back to the flat listing (not reloaded). Should also be called when the row content is replaced by something else (ex: cmd output).
+func DisableDirTree(erow *ERow) {
+	if erow.tree != nil {
+		erow.tree.Stop()
+	}
+}
+
+func (dt *DirTree) Stop() {
+	dt.cancel()
+	if dt.erow.tree == dt {
+		dt.erow.tree = nil
+	}
+}
+
+// Sorted expanded directories (sessions).
+func (dt *DirTree) Expanded() []string {
+	u := []string{}
+	for k := range dt.expanded {
+		u = append(u, k)
+	}
+	sort.Strings(u)
+	return u
+}
+
+//----------
+
+func (dt *DirTree) toggle(rel string) {
+	if dt.expanded[rel] {
+		// collapse sub directories as well
+		for k := range dt.expanded {
+			if k == rel || strings.HasPrefix(k, rel+"/") {
+				delete(dt.expanded, k)
+			}
+		}
+	} else {
+		dt.expanded[rel] = true
+	}
+	dt.render()
+}
+
+// Lists in the background (doesn't use the row exec to not cancel/replace a running cmd). Previous renders are discarded.
+func (dt *DirTree) render() {
+	erow := dt.erow
+	dir := erow.Info.Name()
+	expanded := map[string]bool{}
+	for k := range dt.expanded {
+		expanded[k] = true
+	}
+	dt.seq++
+	seq := dt.seq
+	ctx := dt.ctx
+	go func() {
+		b, lines, seen, err := dirTreeListing(ctx, dir, expanded)
+		erow.Ed.UI.RunOnUIGoRoutine(func() {
+			if erow.tree != dt || dt.seq != seq {
+				return // stopped or superseded
+			}
+			if err != nil {
+				if ctx.Err() == nil {
+					erow.Ed.Error(err)
+				}
+				return
+			}
+			// directories that no longer exist
+			for k := range dt.expanded {
+				if !seen[k] {
+					delete(dt.expanded, k)
+				}
+			}
+			dt.lines = lines
+			dt.texts = strings.Split(string(b), "\n")
+
+			// keep position on refresh
+			ta := erow.Row.TextArea
+			ci, ro := ta.TextCursor.Index(), ta.RuneOffset()
+			if err := ta.SetBytesClearHistory(b); err != nil {
+				erow.Ed.Error(err)
+			}
+			ta.TextCursor.SetIndex(mathutil.Smallest(ci, ta.Len()))
+			ta.SetRuneOffset(mathutil.Smallest(ro, ta.Len()))
+
+			dt.updateWatched()
+		})
+	}()
+}
+
+//----------
+
+// Listing with the children of the expanded directories indented. Returns the entry of each line and the expanded directories found.
+func dirTreeListing(ctx context.Context, dir string, expanded map[string]bool) ([]byte, []string, map[string]bool, error) {
+	buf := &bytes.Buffer{}
+	lines := []string{".."}
+	seen := map[string]bool{}
+	buf.WriteString(".." + string(os.PathSeparator) + "\n")
+
+	var list func(rel string, depth int) error
+	list = func(rel string, depth int) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		f, err := os.Open(filepath.Join(dir, filepath.FromSlash(rel)))
+		if err != nil {
+			return nil // skip (ex: removed meanwhile)
+		}
+		fis, err := f.Readdir(-1)
+		f.Close()
+		if err != nil {
+			return nil
+		}
+		sort.Sort(ByListOrder(fis))
+		for _, fi := range fis {
+			rel2 := path.Join(rel, fi.Name())
+			s := strings.Repeat(dirTreeIndent, depth) + parseutil.EscapeFilename(fi.Name())
+			if fi.IsDir() {
+				s += string(os.PathSeparator)
+			}
+			buf.WriteString(s + "\n")
+			lines = append(lines, rel2)
+			if fi.IsDir() && expanded[rel2] {
+				seen[rel2] = true
+				if err := list(rel2, depth+1); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if err := list("", 0); err != nil {
+		return nil, nil, nil, err
+	}
+	return buf.Bytes(), lines, seen, nil
+}
+
+var dirTreeIndent = "    "
+
+//----------
+
+func (dt *DirTree) updateWatched() {
+	dir := dt.erow.Info.Name()
+	want := map[string]bool{dir: true}
+	for k := range dt.expanded {
+		want[filepath.Join(dir, filepath.FromSlash(k))] = true
+	}
+	for k := range want {
+		if !dt.watched[k] {
+			if err := dt.w.Add(k); err == nil {
+				dt.watched[k] = true
+			}
+		}
+	}
+	for k := range dt.watched {
+		if !want[k] {
+			_ = dt.w.Remove(k)
+			delete(dt.watched, k)
+		}
+	}
+}
+
+func (dt *DirTree) loop(ctx context.Context) {
+	defer dt.w.Close()
+
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case ev := <-dt.w.Events():
+			switch t := ev.(type) {
+			case error:
+				dt.erow.Ed.Error(t)
+			case *fswatcher.Event:
+				timer.Reset(dirTreeRefreshDelay)
+			}
+		case <-timer.C:
+			dt.erow.Ed.UI.RunOnUIGoRoutine(func() {
+				if dt.erow.tree == dt {
+					dt.render()
+				}
+			})
+		}
+	}
+}
+
+//----------
+
+// Entry (relative slash path) at the line of the index. Fails if the line text is not the listed one (ex: content edited).
+func (dt *DirTree) entryAtIndex(index int) (string, bool) {
+	b, err := dt.erow.Row.TextArea.Bytes()
+	if err != nil || index > len(b) {
+		return "", false
+	}
+	line := bytes.Count(b[:index], []byte("\n"))
+	if line >= len(dt.lines) || line >= len(dt.texts) {
+		return "", false
+	}
+	s := bytes.LastIndexByte(b[:index], '\n') + 1
+	e := bytes.IndexByte(b[index:], '\n')
+	if e < 0 {
+		e = len(b)
+	} else {
+		e += index
+	}
+	if string(b[s:e]) != dt.texts[line] {
+		return "", false
+	}
+	return dt.lines[line], true
+}
+
+// Content cmd for tree rows: expands/collapses directories, opens files. The parent directory entry is not handled. UI goroutine.
+func DirTreeOpenEntry(erow *ERow, index int) bool {
+	dt := erow.tree
+	if dt == nil {
+		return false
+	}
+	rel, ok := dt.entryAtIndex(index)
+	if !ok || rel == ".." {
+		return false
+	}
+	filename := filepath.Join(erow.Info.Name(), filepath.FromSlash(rel))
+	fi, err := os.Stat(filename)
+	if err != nil {
+		erow.Ed.Error(err)
+		return true
+	}
+	if fi.IsDir() {
+		dt.toggle(rel)
+		return true
+	}
+	conf := &OpenFileERowConfig{
+		FilePos:             &parseutil.FilePos{Filename: filename},
+		RowPos:              erow.Ed.GoodRowPos(),
+		FlashVisibleOffsets: true,
+		NewIfNotExistent:    true,
+		JumpList:            true,
+	}
+	OpenFileERow(erow.Ed, conf)
+	return true
+}
+
+//----------
+
+// Filename of the entry at the text cursor of a directory row (tree or flat listing).
+func DirRowEntryAtCursor(erow *ERow) (string, bool) {
+	if !erow.Info.IsDir() {
+		return "", false
+	}
+	ta := erow.Row.TextArea
+	ci := ta.TextCursor.Index()
+	if dt := erow.tree; dt != nil {
+		rel, ok := dt.entryAtIndex(ci)
+		if !ok || rel == ".." {
+			return "", false
+		}
+		return filepath.Join(erow.Info.Name(), filepath.FromSlash(rel)), true
+	}
+
+	// flat listing: line with the relative path
+	s, err := ta.LineStartIndex(ci)
+	if err != nil {
+		return "", false
+	}
+	e, _, err := ta.LineEndIndex(ci)
+	if err != nil {
+		return "", false
+	}
+	b, err := ta.TextCursor.RW().ReadNSliceAt(s, e-s)
+	if err != nil {
+		return "", false
+	}
+	name := strings.TrimSpace(string(b))
+	name = parseutil.RemoveFilenameEscapes(name, osutil.EscapeRune, os.PathSeparator)
+	name = strings.TrimSuffix(name, string(os.PathSeparator))
+	if name == "" || name == ".." {
+		return "", false
+	}
+	return filepath.Join(erow.Info.Name(), name), true
+}
+
+// Updates the listing of a directory row after file operations.
+func RefreshDirRow(erow *ERow) {
+	if erow.tree != nil {
+		erow.tree.render()
+		return
+	}
+	erow.Reload()
+}
@@ -0,0 +1,51 @@
+package core
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDirTreeListing(t *testing.T) {
+	dir, err := ioutil.TempDir("", "editor_dirtree")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"a/b/c.txt", "a/d.txt", "e.txt"} {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expanded := map[string]bool{"a": true, "x": true}
+	b, lines, seen, err := dirTreeListing(context.Background(), dir, expanded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sep := string(os.PathSeparator)
+	want := strings.Join([]string{
+		".." + sep,
+		"a" + sep,
+		dirTreeIndent + "b" + sep,
+		dirTreeIndent + "d.txt",
+		"e.txt",
+	}, "\n") + "\n"
+	if string(b) != want {
+		t.Fatalf("got:\n%s\nexpecting:\n%s", b, want)
+	}
+	if strings.Join(lines, ",") != "..,a,a/b,a/d.txt,e.txt" {
+		t.Fatal(lines)
+	}
+	if !seen["a"] || seen["x"] {
+		t.Fatal(seen)
+	}
+}
@@ -25,18 +25,27 @@ type Editor struct {
 	UI                *ui.UI
 	HomeVars          *HomeVars
 	Watcher           fswatcher.Watcher
+	DirWatcher        *fswatcher.MuxWatcher // directory contents, shared (ex: watch cmd, tree rows)
 	RowReopener       *RowReopener
+	JumpList          *JumpList
+	Marks             *Marks
+	ErrorList         *ErrorList
+	Git               *Git
 	GoDebug           *GoDebugInstance
 	LSProtoMan        *lsproto.Manager
 	InlineComplete    *InlineComplete
 	Plugins           *Plugins
+	CtlServer         *CtlServer
 	EEvents           *EEvents // editor events (used by plugins)
 	FsCaseInsensitive bool     // filesystem
+	LineNumbers       bool     // rows default (without the $lineNumbers toolbar var)
 
 	dndh *DndHandler
 	ifbw *InfoFloatBoxWrap
 
 	erowInfos map[string]*ERowInfo // use ed.ERowInfo*() to access
+
+	prevActiveERow *ERow // row that was active before the current active row
 }
 
 func NewEditor(opt *Options) (*Editor, error) {
@@ -53,6 +62,10 @@ func NewEditor(opt *Options) (*Editor, error) {
 	ed.GoDebug = NewGoDebugInstance(ed)
 	ed.InlineComplete = NewInlineComplete(ed)
 	ed.EEvents = NewEEvents()
+	ed.JumpList = NewJumpList(ed)
+	ed.Marks = NewMarks(ed)
+	ed.ErrorList = NewErrorList(ed)
+	ed.Git = NewGit(ed)
 
 	if err := ed.init(opt); err != nil {
 		return nil, err
@@ -63,11 +76,17 @@ func NewEditor(opt *Options) (*Editor, error) {
 	go ed.fswatcherEventLoop()
 	ed.uiEventLoop() // blocks
 
+	ed.Plugins.Close()
+	ed.CtlServer.Close()
+
 	return ed, nil
 }
 
 //----------
 
+// Max number of directories watched by the shared directories watcher.
+var dirWatcherMaxNames = 4096
+
 func (ed *Editor) init(opt *Options) error {
 	// fs watcher + gwatcher
 	w, err := fswatcher.NewFsnWatcher()
@@ -76,7 +95,15 @@ func (ed *Editor) init(opt *Options) error {
 	}
 	ed.Watcher = fswatcher.NewGWatcher(w)
 
+	// shared directories watcher
+	w2, err := fswatcher.NewFsnWatcher()
+	if err != nil {
+		return err
+	}
+	ed.DirWatcher = fswatcher.NewMuxWatcher(w2, dirWatcherMaxNames)
+
 	ed.setupTheme(opt)
+	ed.LineNumbers = opt.LineNumbers
 	event.UseMultiKey = opt.UseMultiKey
 
 	// user interface
@@ -87,6 +114,12 @@ func (ed *Editor) init(opt *Options) error {
 	ed.UI = ui0
 	ed.setupUIRoot()
 
+	// control server (scripts, external programs, rpc plugins)
+	ed.CtlServer = NewCtlServer(ed)
+	if err := ed.CtlServer.Listen(); err != nil {
+		ed.Error(err)
+	}
+
 	// TODO: ensure it has the window measure
 	ed.EnsureOneColumn()
 
@@ -349,7 +382,7 @@ func (ed *Editor) setupRootMenuToolbar() {
 ColorTheme
 CtxutilCallsState
 FontRunes | FontTheme 
-GoDebug
+GoDebug | GoDebugWatch | GoDebugTest | GoDebugCoverage
 GoRename
 GotoLine 
 NewColumn
@@ -504,6 +537,17 @@ func (ed *Editor) setupPlugins(opt *Options) error {
 			return err
 		}
 	}
+	a = strings.Split(opt.RPCPlugins, ",")
+	for _, s := range a {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		err := ed.Plugins.AddRPCPath(s)
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -746,6 +790,8 @@ func (ed *Editor) SetAnnotations(req EdAnnotationsRequester, ta *ui.TextArea, on
 		for _, erow := range ed.ERows() {
 			if erow.Row.TextArea == ta {
 				ed.GoDebug.UpdateUIERowInfo(erow.Info)
+				ed.Git.updateERow(erow)
+				ed.ErrorList.updateERow(erow)
 			}
 		}
 	}
@@ -764,6 +810,8 @@ func (ed *Editor) CanModifyAnnotations(req EdAnnotationsRequester, ta *ui.TextAr
 		return true
 	case EdAnnReqInlineComplete:
 		return true
+	case EdAnnReqErrorList, EdAnnReqGitBlame:
+		return !ed.InlineComplete.IsOn(ta)
 	default:
 		panic(req)
 	}
@@ -774,6 +822,8 @@ type EdAnnotationsRequester int
 const (
 	EdAnnReqGoDebug EdAnnotationsRequester = iota
 	EdAnnReqInlineComplete
+	EdAnnReqErrorList
+	EdAnnReqGitBlame
 )
 
 //----------
@@ -819,13 +869,15 @@ type Options struct {
 	ScrollBarWidth int
 	ScrollBarLeft  bool
 	Shadows        bool
+	LineNumbers    bool
 
 	SessionName string
 	Filenames   []string
 
 	UseMultiKey bool
 
-	Plugins string
+	Plugins    string
+	RPCPlugins string
 
 	LSProtos RegistrationsOpt
 }
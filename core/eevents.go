@@ -1,11 +1,13 @@
 package core
 
 import (
+	"github.com/jmigpin/editor/core/toolbarparser"
 	"github.com/jmigpin/editor/ui"
 	"github.com/jmigpin/editor/util/evreg"
+	"github.com/jmigpin/editor/util/uiutil/widget"
 )
 
-// Editor events.
+// Editor events. Callbacks run in the UI goroutine.
 type EEvents struct {
 	reg *evreg.Register
 }
@@ -31,6 +33,14 @@ const (
 	PostFileSaveEEventId
 	PreRowCloseEEventId
 	RowStateChangeEEventId
+	PreFileSaveEEventId
+	TextChangedEEventId
+	CursorMovedEEventId
+	PreInternalCmdEEventId
+	PostInternalCmdEEventId
+	ExternalCmdStartEEventId
+	ExternalCmdEndEEventId
+	SessionOpenedEEventId
 )
 
 type PostNewERowEEvent struct {
@@ -50,3 +60,49 @@ type RowStateChangeEEvent struct {
 	State ui.RowState
 	Value bool // the new value
 }
+
+// Callbacks can transform the bytes to be saved, or set an error to cancel the save.
+type PreFileSaveEEvent struct {
+	Info  *ERowInfo
+	Bytes []byte
+	Err   error
+}
+
+// Edits in the row textarea, emitted at most once per delay (textChangedEEventDelay) with the write ops accumulated since the last event. Offsets of each op are relative to the text after the previous ops.
+type TextChangedEEvent struct {
+	ERow     *ERow
+	WriteOps []*widget.RWWriteOpCb
+}
+
+// Cursor index changed in the row textarea (user input, jump list, cmds, ...). Changes during the same UI event are coalesced.
+type CursorMovedEEvent struct {
+	ERow  *ERow
+	Index int
+}
+
+type PreInternalCmdEEvent struct {
+	ERow *ERow // nil if run from the root toolbar without an active row
+	Part *toolbarparser.Part
+}
+
+type PostInternalCmdEEvent struct {
+	ERow *ERow
+	Part *toolbarparser.Part
+	Err  error
+}
+
+type ExternalCmdStartEEvent struct {
+	ERow *ERow
+	Args []string
+}
+
+type ExternalCmdEndEEvent struct {
+	ERow     *ERow
+	Args     []string
+	Err      error
+	ExitCode int // -1 if the process didn't exit normally (ex: canceled)
+}
+
+type SessionOpenedEEvent struct {
+	Name string
+}
@@ -7,11 +7,13 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/jmigpin/editor/core/toolbarparser"
 	"github.com/jmigpin/editor/ui"
 	"github.com/jmigpin/editor/util/iout"
 	"github.com/jmigpin/editor/util/uiutil/event"
+	"github.com/jmigpin/editor/util/uiutil/widget"
 )
 
 //----------
@@ -26,7 +28,24 @@ type ERow struct {
 	highlightDuplicates           bool
 	disableTextAreaSetStrCallback bool
 
-	termFilter bool
+	termFilter  bool
+	byteOffsets bool     // content locations are byte offsets
+	ptyVar      bool     // run cmds under a pseudo-terminal
+	pty         *ERowPty // running pty cmd input (UI goroutine)
+	watch       *WatchCmd
+	fileFinder  *FileFinder
+	tree        *DirTree
+	diffView    *DiffView
+
+	// editor events (UI goroutine)
+	cursorMoved struct {
+		index   int
+		pending bool
+	}
+	textChanged struct {
+		ops   []*widget.RWWriteOpCb
+		timer *time.Timer
+	}
 
 	ctx       context.Context // erow general context
 	cancelCtx context.CancelFunc
@@ -54,6 +73,9 @@ func NewERow(ed *Editor, info *ERowInfo, rowPos *ui.RowPos) *ERow {
 	erow.initHandlers()
 	erow.parseToolbar() // after handlers are set
 	erow.setupTextAreaSyntaxHighlight()
+	erow.Ed.Marks.updateERow(erow)
+	erow.Ed.Git.updateERow(erow)
+	erow.Ed.ErrorList.updateERow(erow)
 
 	ctx0 := context.Background() // TODO: editor ctx
 	erow.ctx, erow.cancelCtx = context.WithCancel(ctx0)
@@ -104,6 +126,10 @@ func (erow *ERow) initHandlers() {
 		erow.Info.SetRowsStrFromMaster(erow)
 	})
 	// textarea edit
+	// textarea cursor index (any source: input, jump list, cmds)
+	row.TextArea.EvReg.Add(ui.TextAreaCursorIndexEventId, func(ev0 interface{}) {
+		erow.cursorMovedLater()
+	})
 	row.TextArea.EvReg.Add(ui.TextAreaWriteOpEventId, func(ev0 interface{}) {
 		ev := ev0.(*ui.TextAreaWriteOpEvent)
 		// update duplicate edits to keep offset/cursor in position
@@ -114,6 +140,35 @@ func (erow *ERow) initHandlers() {
 				}
 				e.Row.TextArea.UpdateWriteOp(ev.WriteOp)
 			}
+			erow.Ed.Marks.UpdateWriteOp(erow.Info.Name(), ev.WriteOp)
+			erow.Ed.Git.UpdateWriteOp(erow.Info, ev.WriteOp)
+		}
+		if erow.pty != nil {
+			erow.pty.updateWriteOp(ev.WriteOp)
+		}
+		erow.addTextChangedWriteOp(ev.WriteOp)
+	})
+	// textarea keys: pty input, jump list (before the textedit moves the cursor)
+	row.TextArea.EvReg.Add(ui.TextAreaKeyDownEventId, func(ev0 interface{}) {
+		ev := ev0.(*ui.TextAreaKeyDownEvent)
+		if erow.pty != nil {
+			ev.Handled = erow.pty.keyDown(ev.KeyDown)
+			if ev.Handled {
+				return
+			}
+		}
+		mods := ev.KeyDown.Mods.ClearLocks()
+		switch {
+		case mods.Is(event.ModAlt) && ev.KeyDown.KeySym == event.KSymLeft:
+			if err := erow.Ed.JumpList.Back(erow); err != nil {
+				erow.Ed.Error(err)
+			}
+			ev.Handled = true
+		case mods.Is(event.ModAlt) && ev.KeyDown.KeySym == event.KSymRight:
+			if err := erow.Ed.JumpList.Forward(erow); err != nil {
+				erow.Ed.Error(err)
+			}
+			ev.Handled = true
 		}
 	})
 	// textarea content cmds
@@ -133,6 +188,11 @@ func (erow *ERow) initHandlers() {
 		// Allow the input event (`tab` key press) to function normally if the inlinecomplete is not being handled (ex: no lsproto server is registered for this filename extension)
 		ev.Handled = event.Handled(handled)
 	})
+	// textarea fold
+	row.TextArea.EvReg.Add(ui.TextAreaFoldEventId, func(ev0 interface{}) {
+		ev := ev0.(*ui.TextAreaFoldEvent)
+		ev.Handled = ToggleFoldLSProto(erow, ev)
+	})
 	// key shortcuts
 	row.EvReg.Add(ui.RowInputEventId, func(ev0 interface{}) {
 		erow.Ed.InlineComplete.CancelOnCursorChange()
@@ -168,6 +228,27 @@ func (erow *ERow) initHandlers() {
 		ev := &PreRowCloseEEvent{ERow: erow}
 		erow.Ed.EEvents.emit(PreRowCloseEEventId, ev)
 
+		if erow.watch != nil {
+			erow.watch.Stop()
+		}
+		if erow.fileFinder != nil {
+			erow.fileFinder.Stop()
+		}
+		if erow.tree != nil {
+			erow.tree.Stop()
+		}
+		if erow.diffView != nil {
+			erow.diffView.Stop()
+		}
+		if erow.Ed.prevActiveERow == erow {
+			erow.Ed.prevActiveERow = nil
+		}
+		if erow.textChanged.timer != nil {
+			erow.textChanged.timer.Stop()
+		}
+		erow.Ed.ErrorList.removeERow(erow)
+		erow.Ed.Git.removeERow(erow)
+
 		// cancel general context
 		erow.cancelCtx()
 
@@ -198,6 +279,52 @@ func (erow *ERow) initHandlers() {
 
 //----------
 
+// Delay to coalesce write ops into one text changed event.
+var textChangedEEventDelay = 250 * time.Millisecond
+
+func (erow *ERow) addTextChangedWriteOp(u *widget.RWWriteOpCb) {
+	erow.textChanged.ops = append(erow.textChanged.ops, u)
+	if erow.textChanged.timer != nil {
+		return // already scheduled
+	}
+	erow.textChanged.timer = time.AfterFunc(textChangedEEventDelay, func() {
+		erow.Ed.UI.RunOnUIGoRoutine(erow.emitTextChanged)
+	})
+}
+
+func (erow *ERow) emitTextChanged() {
+	ops := erow.textChanged.ops
+	erow.textChanged.ops = nil
+	erow.textChanged.timer = nil
+	if len(ops) == 0 || erow.ctx.Err() != nil { // closed
+		return
+	}
+	ev := &TextChangedEEvent{ERow: erow, WriteOps: ops}
+	erow.Ed.EEvents.emit(TextChangedEEventId, ev)
+}
+
+// Coalesces the cursor index changes of the current UI event into one cursor moved event.
+func (erow *ERow) cursorMovedLater() {
+	if erow.cursorMoved.pending {
+		return
+	}
+	erow.cursorMoved.pending = true
+	erow.Ed.UI.RunOnUIGoRoutine(erow.emitCursorMoved)
+}
+
+func (erow *ERow) emitCursorMoved() {
+	erow.cursorMoved.pending = false
+	i := erow.Row.TextArea.TextCursor.Index()
+	if i == erow.cursorMoved.index || erow.ctx.Err() != nil { // closed
+		return
+	}
+	erow.cursorMoved.index = i
+	ev := &CursorMovedEEvent{ERow: erow, Index: i}
+	erow.Ed.EEvents.emit(CursorMovedEEventId, ev)
+}
+
+//----------
+
 func (erow *ERow) parseToolbar() {
 	str := erow.Row.Toolbar.Str()
 
@@ -242,6 +369,13 @@ func (erow *ERow) parseToolbarVars() {
 		erow.Row.TextArea.SetThemeFont(nil)
 	}
 
+	// $lineNumbers
+	lineNumbers := erow.Ed.LineNumbers
+	if v, ok := vmap["$lineNumbers"]; ok {
+		lineNumbers = v == "" || strings.ToLower(v) == "true"
+	}
+	erow.Row.TextArea.EnableLineNumbers(lineNumbers)
+
 	// $termFilter
 	erow.termFilter = false
 	if v, ok := vmap["$termFilter"]; ok {
@@ -249,6 +383,27 @@ func (erow *ERow) parseToolbarVars() {
 			erow.termFilter = true
 		}
 	}
+
+	// $byteOffsets
+	erow.byteOffsets = false
+	if v, ok := vmap["$byteOffsets"]; ok {
+		if v == "" || strings.ToLower(v) == "true" {
+			erow.byteOffsets = true
+		}
+	}
+
+	// $pty
+	erow.ptyVar = false
+	if v, ok := vmap["$pty"]; ok {
+		if v == "" || strings.ToLower(v) == "true" {
+			erow.ptyVar = true
+		}
+	}
+}
+
+// Locations in the row content (":<n>") are byte offsets (ex: "grep -b" output). Set with the $byteOffsets toolbar var.
+func (erow *ERow) ByteOffsets() bool {
+	return erow.byteOffsets
 }
 
 func (erow *ERow) setVarFontTheme(s string) error {
@@ -338,6 +493,9 @@ func (erow *ERow) TextAreaAppendBytesUIWriter() io.Writer {
 		erow.Ed.UI.RunOnUIGoRoutine(func() {
 			defer wg.Done()
 			err2 = ta.AppendBytesClearHistory(b)
+			if erow.pty != nil {
+				erow.pty.inputStart = ta.TextCursor.RW().Max()
+			}
 		})
 		wg.Wait()
 		return len(b), err2
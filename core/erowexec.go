@@ -85,3 +85,9 @@ func (eexec *ERowExec) Stop() {
 		eexec.mu.cancel()
 	}
 }
+
+func (eexec *ERowExec) Running() bool {
+	eexec.mu.Lock()
+	defer eexec.mu.Unlock()
+	return eexec.mu.cancel != nil
+}
@@ -31,6 +31,8 @@ type ERowInfo struct {
 		size int
 		hash []byte
 	}
+	// content of the last load/save (base to merge changes on disk)
+	savedBytes []byte
 
 	// filesystem hash (reflects changes by other programs)
 	fsHash struct {
@@ -297,6 +299,10 @@ func (info *ERowInfo) ReloadDir(erow *ERow) error {
 	if !info.IsDir() {
 		return fmt.Errorf("not a directory")
 	}
+	if erow.tree != nil {
+		erow.tree.render()
+		return nil
+	}
 	ListDirERow(erow, erow.Info.Name(), false, true)
 	return nil
 }
@@ -321,6 +327,7 @@ func (info *ERowInfo) NewFileERow(rowPos *ui.RowPos) (*ERow, error) {
 
 	// update data
 	info.setSavedHash(info.fsHash.hash, len(b))
+	info.savedBytes = b
 
 	// new erow (no other rows exist)
 	erow := NewERow(info.Ed, info, rowPos)
@@ -337,6 +344,7 @@ func (info *ERowInfo) ReloadFile() error {
 
 	// update data
 	info.setSavedHash(info.fsHash.hash, len(b))
+	info.savedBytes = b
 
 	// update all erows
 	info.SetRowsBytes(b)
@@ -371,6 +379,14 @@ func (info *ERowInfo) SaveFile() error {
 		}
 	}
 
+	// editor events: allow plugins to transform or cancel
+	pev := &PreFileSaveEEvent{Info: info, Bytes: b}
+	info.Ed.EEvents.emit(PreFileSaveEEventId, pev)
+	if pev.Err != nil {
+		return pev.Err
+	}
+	b = pev.Bytes
+
 	// save
 	err = info.saveFsFile(b)
 	if err != nil {
@@ -380,6 +396,8 @@ func (info *ERowInfo) SaveFile() error {
 	// update all erows (including row saved states)
 	info.SetRowsBytes(b)
 
+	info.Ed.Git.UpdateInfo(info)
+
 	// editor events
 	ev := &PostFileSaveEEvent{Info: info}
 	info.Ed.EEvents.emit(PostFileSaveEEventId, ev)
@@ -432,6 +450,7 @@ func (info *ERowInfo) saveFsFile(b []byte) error {
 	info.readFileInfo() // get new modtime
 	info.setFsHash(h)
 	info.setSavedHash(h, len(b))
+	info.savedBytes = b
 
 	return nil
 }
@@ -443,6 +462,7 @@ func (info *ERowInfo) UpdateDiskEvent() {
 	info.readFileInfo()
 	if info.IsFileButNotDir() {
 		info.updateFsHashIfNeeded()
+		info.Ed.Git.UpdateInfo(info)
 	}
 }
 
@@ -524,6 +544,9 @@ func (info *ERowInfo) UpdateActiveRowState(erow *ERow) {
 	// disable first the previous active row
 	for _, er := range info.Ed.ERows() {
 		if er != erow {
+			if er.Row.HasState(ui.RowStateActive) {
+				info.Ed.prevActiveERow = er // diff "other" row
+			}
 			info.updateRowState(er, ui.RowStateActive, false)
 		}
 	}
@@ -580,6 +603,8 @@ func (info *ERowInfo) SetRowsStrFromMaster(erow *ERow) {
 	info.UpdateEditedRowState()
 
 	info.Ed.GoDebug.UpdateUIERowInfo(info)
+	info.Ed.Git.updateInfo(info)
+	info.Ed.ErrorList.updateInfo(info.Name())
 }
 
 //----------
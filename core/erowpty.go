@@ -0,0 +1,198 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/jmigpin/editor/core/toolbarparser"
+	"github.com/jmigpin/editor/util/osutil"
+	"github.com/jmigpin/editor/util/uiutil/event"
+	"github.com/jmigpin/editor/util/uiutil/widget"
+)
+
+// Input of a process running under a pseudo-terminal. The text typed after the last output is sent to the process on enter. Should only be used in the UI goroutine.
+type ERowPty struct {
+	erow       *ERow
+	master     *os.File
+	in         chan []byte
+	inputStart int // offset after the last output
+	size       struct{ cols, rows int }
+}
+
+//----------
+
+func (p *ERowPty) keyDown(ev *event.KeyDown) bool {
+	tc := p.erow.Row.TextArea.TextCursor
+	inInput := !tc.SelectionOn() && tc.Index() >= p.inputStart
+	m := ev.Mods.ClearLocks()
+	switch {
+	case m.Is(event.ModNone) && ev.KeySym == event.KSymReturn:
+		if !inInput {
+			return false
+		}
+		p.sendInput(true)
+		return true
+	case m.Is(event.ModCtrl) && ev.KeySym == event.KSymC:
+		if tc.SelectionOn() { // allow copy
+			return false
+		}
+		p.write([]byte{3}) // ETX: interrupt (the terminal sends SIGINT)
+		return true
+	case m.Is(event.ModCtrl) && ev.KeySym == event.KSymD:
+		if !inInput {
+			return false
+		}
+		p.sendInput(false)
+		p.write([]byte{4}) // EOT: end of input
+		return true
+	}
+	return false
+}
+
+// Sends the text typed after the last output.
+func (p *ERowPty) sendInput(newline bool) {
+	ta := p.erow.Row.TextArea
+	rw := ta.TextCursor.RW()
+	a, b := p.inputStart, rw.Max()
+	if a > b {
+		a = b
+	}
+	in0, err := rw.ReadNSliceAt(a, b-a)
+	if err != nil {
+		p.erow.Ed.Error(err)
+		return
+	}
+	in := append([]byte{}, in0...)
+	if newline {
+		in = append(in, '\n')
+		if err := ta.AppendBytesClearHistory([]byte("\n")); err != nil {
+			p.erow.Ed.Error(err)
+		}
+	}
+	p.inputStart = rw.Max()
+	ta.TextCursor.SetSelectionOff()
+	ta.TextCursor.SetIndex(p.inputStart)
+
+	p.updateSize()
+	if len(in) > 0 {
+		p.write(in)
+	}
+}
+
+func (p *ERowPty) write(b []byte) {
+	select {
+	case p.in <- b:
+	default:
+		p.erow.Ed.Errorf("pty: input buffer is full")
+	}
+}
+
+// Updates the terminal size if the row dimensions changed.
+func (p *ERowPty) updateSize() {
+	cols, rows := p.erow.Row.TextArea.TextSize()
+	if cols == p.size.cols && rows == p.size.rows {
+		return
+	}
+	p.size.cols, p.size.rows = cols, rows
+	if err := osutil.SetPtySize(p.master, cols, rows); err != nil {
+		p.erow.Ed.Error(err)
+	}
+}
+
+// Keeps the input start position updated with the edits before it.
+func (p *ERowPty) updateWriteOp(u *widget.RWWriteOpCb) {
+	p.inputStart = u.UpdateOffset(p.inputStart)
+}
+
+//----------
+
+// Runs an interactive shell (or the given cmd) under a pseudo-terminal. File rows run the cmd in a new row of the file directory.
+func ShellCmd(erow *ERow, part *toolbarparser.Part) error {
+	cargs := []string{"sh", "-i"}
+	if args := part.Args[1:]; len(args) > 0 {
+		cargs = osutil.ShellRunArgs(shellCmdArgsStr(args)...)
+	}
+	switch {
+	case erow.Info.IsDir():
+		env := populateEnvVars(erow, cargs)
+		externalCmdDir(erow, cargs, nil, env, nil, true)
+	case erow.Info.IsFileButNotDir():
+		externalCmdNewDirRow(erow, cargs, nil, nil, true)
+	default:
+		return fmt.Errorf("unable to run shell for erow: %v", erow.Info.Name())
+	}
+	return nil
+}
+
+//----------
+
+// Runs the cmd under a pseudo-terminal. The output escape sequences are filtered if the filter is set.
+func externalCmdDirPty(ctx context.Context, erow *ERow, cargs []string, env []string, w io.Writer, cols, rows int, filter bool) error {
+	// row not laid out yet (ex: new row), use common defaults
+	if cols <= 0 || rows <= 0 {
+		cols, rows = 80, 24
+	}
+
+	cmd := osutil.NewCmd(ctx, cargs...)
+	cmd.Dir = erow.Info.Name()
+	cmd.Env = append(env, "TERM=dumb")
+	master, err := cmd.SetupPty(cols, rows, false) // input is shown in the row
+	if err != nil {
+		return err
+	}
+	defer master.Close()
+
+	p := &ERowPty{erow: erow, master: master, in: make(chan []byte, 64)}
+	p.size.cols, p.size.rows = cols, rows
+	erow.Ed.UI.RunOnUIGoRoutine(func() {
+		p.inputStart = erow.Row.TextArea.TextCursor.RW().Max()
+		erow.pty = p
+	})
+	defer erow.Ed.UI.RunOnUIGoRoutine(func() {
+		if erow.pty == p {
+			erow.pty = nil
+		}
+	})
+
+	// output pid before any output
+	cmd.PreOutputCallback = func() {
+		cargsStr := strings.Join(cargs, " ")
+		fmt.Fprintf(w, "# pid %d (pty): %s\n", cmd.Process.Pid, cargsStr)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	// input
+	inCtx, inCancel := context.WithCancel(ctx)
+	defer inCancel()
+	go func() {
+		for {
+			select {
+			case <-inCtx.Done():
+				return
+			case b := <-p.in:
+				_, _ = master.Write(b) // errors if the process ended
+			}
+		}
+	}()
+
+	// output: ends with an error after the slave is closed (all processes that use it have ended)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		var rd io.Reader = master
+		if filter {
+			rd = NewTerminalFilter(erow, rd)
+		}
+		_, _ = io.Copy(w, rd)
+	}()
+
+	err = cmd.Wait()
+	<-done
+	return err
+}
@@ -0,0 +1,358 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/jmigpin/editor/ui"
+	"github.com/jmigpin/editor/util/drawutil/drawer4"
+	"github.com/jmigpin/editor/util/iout/iorw"
+	"github.com/jmigpin/editor/util/parseutil"
+)
+
+// Errors parsed from the output of cmds (compilers, tests), shown as annotations in the rows of the affected files. Should only be used in the UI goroutine.
+type ErrorList struct {
+	ed      *Editor
+	erow    *ERow // row with the output that originated the entries
+	entries []*ErrorEntry
+	index   int // current entry (-1: none)
+
+	annotated map[*ui.TextArea]bool // textareas with errorlist annotations
+}
+
+func NewErrorList(ed *Editor) *ErrorList {
+	return &ErrorList{ed: ed, index: -1, annotated: map[*ui.TextArea]bool{}}
+}
+
+// Max output size kept for parsing.
+var errorListMaxOutput = 1024 * 1024
+
+//----------
+
+// Replaces the entries with the errors parsed from the output of the row (see ParseErrorList). No entries only clears the entries if they come from the same row.
+func (el *ErrorList) SetEntries(erow *ERow, entries []*ErrorEntry) {
+	if len(entries) == 0 && el.erow != erow {
+		return
+	}
+	old := el.entries
+	el.erow = erow
+	el.entries = entries
+	el.index = -1
+	el.updateEntriesInfos(old)
+	el.updateEntriesInfos(el.entries)
+}
+
+func (el *ErrorList) Clear() {
+	old := el.entries
+	el.erow = nil
+	el.entries = nil
+	el.index = -1
+	el.updateEntriesInfos(old)
+}
+
+//----------
+
+func (el *ErrorList) Next() error {
+	if el.index+1 >= len(el.entries) {
+		return fmt.Errorf("errorlist: no next error")
+	}
+	return el.goto2(el.index + 1)
+}
+
+func (el *ErrorList) Prev() error {
+	if el.index-1 < 0 {
+		return fmt.Errorf("errorlist: no previous error")
+	}
+	return el.goto2(el.index - 1)
+}
+
+func (el *ErrorList) goto2(i int) error {
+	if el.index >= 0 {
+		prev := el.entries[el.index]
+		el.index = i
+		el.updateInfo(prev.Filename) // unselect
+	}
+	el.index = i
+	e := el.entries[i]
+
+	conf := &OpenFileERowConfig{
+		FilePos: &parseutil.FilePos{
+			Filename: e.Filename,
+			Line:     e.Line,
+			Column:   e.Column,
+		},
+		RowPos:              el.ed.GoodRowPos(),
+		FlashVisibleOffsets: true,
+		NewIfNotExistent:    true,
+		JumpList:            true,
+	}
+	OpenFileERow(el.ed, conf)
+
+	el.updateInfo(e.Filename) // select
+	el.ed.Messagef("error %d/%d: %v", i+1, len(el.entries), e.Msg)
+	return nil
+}
+
+//----------
+
+func (el *ErrorList) updateEntriesInfos(entries []*ErrorEntry) {
+	seen := map[string]bool{}
+	for _, e := range entries {
+		if !seen[e.Filename] {
+			seen[e.Filename] = true
+			el.updateInfo(e.Filename)
+		}
+	}
+}
+
+func (el *ErrorList) updateInfo(filename string) {
+	info, ok := el.ed.ERowInfo(filename)
+	if !ok {
+		return
+	}
+	for _, erow := range info.ERows {
+		el.updateERow(erow)
+	}
+}
+
+func (el *ErrorList) updateERow(erow *ERow) {
+	// godebug annotations have priority
+	if erow.Row.HasState(ui.RowStateAnnotations) {
+		return
+	}
+
+	ta := erow.Row.TextArea
+	rd := ta.TextCursor.RW()
+	anns := []*drawer4.Annotation{}
+	sel := -1
+	for i, e := range el.entries {
+		if e.Filename != erow.Info.Name() {
+			continue
+		}
+		o, err := parseutil.LineColumnIndex(rd, e.Line, e.Column)
+		if err != nil {
+			continue
+		}
+		if i == el.index {
+			sel = len(anns)
+		}
+		anns = append(anns, &drawer4.Annotation{Offset: o, Bytes: []byte(e.Msg)})
+	}
+	on := len(anns) > 0
+	if !on && !el.annotated[ta] {
+		return // don't clear other annotations
+	}
+	if !el.ed.CanModifyAnnotations(EdAnnReqErrorList, ta, "") {
+		return
+	}
+	el.ed.SetAnnotations(EdAnnReqErrorList, ta, on, sel, anns)
+	if on {
+		el.annotated[ta] = true
+	} else {
+		delete(el.annotated, ta)
+	}
+}
+
+// Should be called when the row is closed.
+func (el *ErrorList) removeERow(erow *ERow) {
+	delete(el.annotated, erow.Row.TextArea)
+}
+
+//----------
+
+type ErrorEntry struct {
+	Filename     string
+	Line, Column int
+	Msg          string
+}
+
+//----------
+
+// Only the output of compiler, vet and test cmds is parsed (ex: "grep -rn" output has the same format). The cmd can be inside a shell script (ex: "cd dir && go test").
+func IsErrorListCmd(cargs []string) bool {
+	words := []string{}
+	for _, a := range cargs {
+		words = append(words, strings.FieldsFunc(a, func(ru rune) bool {
+			return unicode.IsSpace(ru) || strings.ContainsRune(";&|()", ru)
+		})...)
+	}
+	for i, w := range words {
+		name := strings.TrimSuffix(filepath.Base(w), ".exe")
+		if name == "go" && i+1 < len(words) {
+			switch words[i+1] {
+			case "build", "vet", "test", "install", "run":
+				return true
+			}
+		}
+		if errorListCmds[name] {
+			return true
+		}
+	}
+	return false
+}
+
+var errorListCmds = map[string]bool{
+	"gcc": true, "g++": true, "cc": true, "c++": true,
+	"clang": true, "clang++": true, "make": true,
+	"staticcheck": true, "golangci-lint": true,
+}
+
+// Parses lines with "<file>:<line>:<col>?: <msg>" (go, go vet, gcc "error:"/"warning:") and go test failures ("--- FAIL: <test>" followed by "<file>_test.go:<line>: <msg>"). Relative filenames are searched in the directory. Entries of files that don't exist are discarded. Accesses the filesystem, should not be used in the UI goroutine.
+func ParseErrorList(dir string, out []byte) []*ErrorEntry {
+	rd := iorw.NewBytesReadWriter(out)
+	fr := &errorListFileResolver{dir: dir}
+	entries := []*ErrorEntry{}
+	test := "" // current failing go test
+	for k := 0; k < len(out); {
+		// line
+		i := bytes.IndexByte(out[k:], '\n')
+		if i < 0 {
+			i = len(out) - k
+		}
+		line := string(out[k : k+i])
+		start := k
+		k += i + 1
+
+		// go test state
+		trimmed := strings.TrimLeft(line, " \t")
+		if strings.HasPrefix(trimmed, "--- FAIL: ") {
+			test = ""
+			if u := strings.Fields(trimmed[len("--- FAIL: "):]); len(u) > 0 {
+				test = u[0]
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "--- PASS: ") ||
+			strings.HasPrefix(trimmed, "=== RUN") ||
+			strings.HasPrefix(line, "ok ") ||
+			strings.HasPrefix(line, "FAIL") {
+			test = ""
+			continue
+		}
+
+		e, ok := parseErrorListLine(rd, start+len(line)-len(trimmed), trimmed)
+		if !ok {
+			continue
+		}
+		filename, ok := fr.resolve(e.Filename)
+		if !ok {
+			continue
+		}
+		e.Filename = filename
+		if test != "" && strings.HasSuffix(filename, "_test.go") {
+			e.Msg = test + ": " + e.Msg
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+func parseErrorListLine(rd iorw.Reader, index int, line string) (*ErrorEntry, bool) {
+	if line == "" {
+		return nil, false
+	}
+	res, err := parseutil.ParseResource(rd, index)
+	if err != nil || res.Line == 0 || res.ExpandedMin != index {
+		return nil, false
+	}
+	if filepath.Ext(res.Path) == "" {
+		return nil, false
+	}
+
+	// message
+	pre, rest := line[:res.ExpandedMax-index], line[res.ExpandedMax-index:]
+	switch {
+	case strings.HasPrefix(rest, ":"):
+		rest = rest[1:]
+	case res.Column == 0 && strings.HasSuffix(pre, ":"):
+		// line without column, separator already parsed
+	default:
+		return nil, false // ex: stack traces "file.go:10 +0x1d"
+	}
+	msg := strings.TrimSpace(rest)
+	if strings.HasPrefix(msg, "note:") {
+		return nil, false // gcc: complements the previous error
+	}
+	if msg == "" {
+		return nil, false
+	}
+
+	e := &ErrorEntry{Filename: res.Path, Line: res.Line, Column: res.Column, Msg: msg}
+	return e, true
+}
+
+//----------
+
+type errorListFileResolver struct {
+	dir   string
+	files map[string][]string // base name -> paths (lazy)
+}
+
+func (fr *errorListFileResolver) resolve(name string) (string, bool) {
+	if filepath.IsAbs(name) {
+		return name, fileExists(name)
+	}
+	u := filepath.Join(fr.dir, name)
+	if fileExists(u) {
+		return u, true
+	}
+	// search sub directories (ex: "go test ./..." outputs filenames relative to the package directory)
+	fr.walk()
+	for _, p := range fr.files[filepath.Base(name)] {
+		if strings.HasSuffix(p, string(filepath.Separator)+filepath.Clean(name)) {
+			return p, true
+		}
+	}
+	return "", false
+}
+
+func (fr *errorListFileResolver) walk() {
+	if fr.files != nil {
+		return
+	}
+	fr.files = map[string][]string{}
+	n := 0
+	_ = filepath.Walk(fr.dir, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return nil // skip
+		}
+		if n++; n > 20000 {
+			return filepath.SkipDir
+		}
+		if fi.IsDir() {
+			if path != fr.dir && strings.HasPrefix(fi.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		base := fi.Name()
+		fr.files[base] = append(fr.files[base], path)
+		return nil
+	})
+}
+
+func fileExists(name string) bool {
+	fi, err := os.Stat(name)
+	return err == nil && !fi.IsDir()
+}
+
+//----------
+
+// Keeps the first bytes written (up to a max).
+type errorListOutput struct {
+	buf bytes.Buffer
+}
+
+func (o *errorListOutput) Write(p []byte) (int, error) {
+	if n := errorListMaxOutput - o.buf.Len(); n > 0 {
+		if len(p) < n {
+			n = len(p)
+		}
+		o.buf.Write(p[:n])
+	}
+	return len(p), nil
+}
@@ -0,0 +1,89 @@
+package core
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseErrorList(t *testing.T) {
+	dir, err := ioutil.TempDir("", "editor_errorlist")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := []string{"main.go", "a.c", "pkg/pkg_test.go"}
+	for _, f := range files {
+		u := filepath.Join(dir, f)
+		if err := os.MkdirAll(filepath.Dir(u), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(u, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out := "" +
+		"# pid 123: go build\n" +
+		"./main.go:10:5: undefined: abc\n" +
+		"main.go:11: unreachable code\n" +
+		"a.c:3:7: warning: unused variable\n" +
+		"a.c:3:7: note: declared here\n" +
+		"a.c:4:1: error: expected ';'\n" +
+		"other.go:1:1: file does not exist\n" +
+		"--- FAIL: TestPkg (0.00s)\n" +
+		"    pkg_test.go:20: got 1\n" +
+		"FAIL\n" +
+		"goroutine 1 [running]:\n" +
+		"\t" + dir + "/main.go:30 +0x1d\n"
+
+	entries := ParseErrorList(dir, []byte(out))
+	type exp struct {
+		file      string
+		line, col int
+		msg       string
+	}
+	exps := []exp{
+		{"main.go", 10, 5, "undefined: abc"},
+		{"main.go", 11, 0, "unreachable code"},
+		{"a.c", 3, 7, "warning: unused variable"},
+		{"a.c", 4, 1, "error: expected ';'"},
+		{"pkg/pkg_test.go", 20, 0, "TestPkg: got 1"},
+	}
+	if len(entries) != len(exps) {
+		for _, e := range entries {
+			t.Logf("%v", *e)
+		}
+		t.Fatalf("expecting %v entries, got %v", len(exps), len(entries))
+	}
+	for i, e := range entries {
+		x := exps[i]
+		if e.Filename != filepath.Join(dir, x.file) ||
+			e.Line != x.line || e.Column != x.col || e.Msg != x.msg {
+			t.Fatalf("%v: expecting %v, got %v", i, x, *e)
+		}
+	}
+}
+
+func TestIsErrorListCmd(t *testing.T) {
+	type in struct {
+		args []string
+		ok   bool
+	}
+	u := []in{
+		{[]string{"go", "build"}, true},
+		{[]string{"sh", "-c", "cd pkg && go test -run Abc"}, true},
+		{[]string{"sh", "-c", "GOOS=windows /usr/local/go/bin/go vet ./..."}, true},
+		{[]string{"sh", "-c", "make all"}, true},
+		{[]string{"sh", "-c", "grep -rn foo"}, false},
+		{[]string{"sh", "-c", "go env"}, false},
+		{[]string{"sh", "-c", "ls"}, false},
+	}
+	for _, w := range u {
+		if ok := IsErrorListCmd(w.args); ok != w.ok {
+			t.Fatalf("%v: expecting %v", w.args, w.ok)
+		}
+	}
+}
@@ -2,12 +2,14 @@ package core
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
-	"path/filepath"
+	"os/exec"
 	"strings"
 
+	"github.com/jmigpin/editor/core/ctlproto"
 	"github.com/jmigpin/editor/core/toolbarparser"
 	"github.com/jmigpin/editor/util/osutil"
 	"github.com/jmigpin/editor/util/parseutil"
@@ -23,7 +25,7 @@ func ExternalCmdFromArgs(erow *ERow, cargs []string, fend func(error)) {
 		externalCmdFileButNotDir(erow, cargs, fend)
 	} else if erow.Info.IsDir() {
 		env := populateEnvVars(erow, cargs)
-		externalCmdDir(erow, cargs, fend, env)
+		externalCmdDir(erow, cargs, fend, env, nil, erow.ptyVar)
 	} else {
 		erow.Ed.Errorf("unable to run external cmd for erow: %v", erow.Info.Name())
 	}
@@ -33,7 +35,12 @@ func ExternalCmdFromArgs(erow *ERow, cargs []string, fend func(error)) {
 
 // create a row with the file dir and run the cmd
 func externalCmdFileButNotDir(erow *ERow, cargs []string, fend func(error)) {
-	dir := filepath.Dir(erow.Info.Name())
+	externalCmdNewDirRow(erow, cargs, fend, nil, false)
+}
+
+// Creates a row with the erow directory and runs the cmd. The env vars are populated from the erow.
+func externalCmdNewDirRow(erow *ERow, cargs []string, fend func(error), stdin io.Reader, pty bool) {
+	dir := erow.Info.Dir()
 
 	info := erow.Ed.ReadERowInfo(dir)
 	rowPos := erow.Row.PosBelow()
@@ -41,7 +48,7 @@ func externalCmdFileButNotDir(erow *ERow, cargs []string, fend func(error)) {
 
 	env := populateEnvVars(erow, cargs)
 
-	externalCmdDir(erow2, cargs, fend, env)
+	externalCmdDir(erow2, cargs, fend, env, stdin, pty)
 }
 
 //----------
@@ -75,6 +82,10 @@ func populateEnvVars(erow *ERow, cargs []string) []string {
 			}
 		}
 	}
+	// control server address, always available for scripts
+	if addr := erow.Ed.CtlServer.Addr(); addr != "" {
+		env = append(env, ctlproto.EnvVar+"="+addr)
+	}
 	return env
 }
 
@@ -101,10 +112,25 @@ func cmdVar_getLine(erow *ERow) string {
 
 //----------
 
-func externalCmdDir(erow *ERow, cargs []string, fend func(error), env []string) {
+// If pty is set, the cmd runs under a pseudo-terminal and the stdin is ignored.
+func externalCmdDir(erow *ERow, cargs []string, fend func(error), env []string, stdin io.Reader, pty bool) {
 	if !erow.Info.IsDir() {
 		panic("not a directory")
 	}
+
+	// the output replaces the tree listing
+	DisableDirTree(erow)
+
+	// pty: terminal size, and filter escape sequences if the row writer doesn't
+	cols, rows := erow.Row.TextArea.TextSize()
+	dir := erow.Info.Name()
+	filter := !erow.termFilter
+	errList := IsErrorListCmd(cargs)
+
+	// editor events
+	ev := &ExternalCmdStartEEvent{ERow: erow, Args: cargs}
+	erow.Ed.EEvents.emit(ExternalCmdStartEEventId, ev)
+
 	erow.Exec.Start(func(ctx context.Context, w io.Writer) error {
 		// cleanup row content
 		erow.Ed.UI.RunOnUIGoRoutine(func() {
@@ -112,19 +138,54 @@ func externalCmdDir(erow *ERow, cargs []string, fend func(error), env []string)
 			erow.Row.TextArea.ClearPos()
 		})
 
-		err := externalCmdDir2(ctx, erow, cargs, env, w)
+		// keep the output to parse errors
+		out := &errorListOutput{}
+		if errList {
+			w = io.MultiWriter(w, out)
+		}
+
+		var err error
+		if pty {
+			err = externalCmdDirPty(ctx, erow, cargs, env, w, cols, rows, filter)
+		} else {
+			err = externalCmdDir2(ctx, erow, cargs, env, stdin, w)
+		}
+		if errList && ctx.Err() == nil { // not canceled
+			entries := ParseErrorList(dir, out.buf.Bytes())
+			erow.Ed.UI.RunOnUIGoRoutine(func() {
+				erow.Ed.ErrorList.SetEntries(erow, entries)
+			})
+		}
 		if fend != nil {
 			fend(err)
 		}
+
+		// editor events
+		ev := &ExternalCmdEndEEvent{ERow: erow, Args: cargs, Err: err, ExitCode: externalCmdExitCode(err)}
+		erow.Ed.UI.RunOnUIGoRoutine(func() {
+			erow.Ed.EEvents.emit(ExternalCmdEndEEventId, ev)
+		})
+
 		return err
 	})
 }
 
-func externalCmdDir2(ctx context.Context, erow *ERow, cargs []string, env []string, w io.Writer) error {
+func externalCmdExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var ee *exec.ExitError
+	if errors.As(err, &ee) {
+		return ee.ExitCode()
+	}
+	return -1
+}
+
+func externalCmdDir2(ctx context.Context, erow *ERow, cargs []string, env []string, stdin io.Reader, w io.Writer) error {
 	cmd := osutil.NewCmd(ctx, cargs...)
 	cmd.Dir = erow.Info.Name()
 	cmd.Env = env
-	if err := cmd.SetupStdio(nil, w, w); err != nil {
+	if err := cmd.SetupStdio(stdin, w, w); err != nil {
 		return err
 	}
 
@@ -167,8 +228,12 @@ func shellCmdPartArgs(part *toolbarparser.Part) []string {
 }
 
 func shellCmdPartArgsStr(part *toolbarparser.Part) []string {
+	return shellCmdArgsStr(part.Args)
+}
+
+func shellCmdArgsStr(args []*toolbarparser.Arg) []string {
 	var u []string
-	for _, a := range part.Args {
+	for _, a := range args {
 		s := a.Str()
 		s = parseutil.RemoveEscapesEscapable(s, osutil.EscapeRune, "|")
 		u = append(u, s)
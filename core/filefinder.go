@@ -0,0 +1,454 @@
+package core
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/jmigpin/editor/core/toolbarparser"
+	"github.com/jmigpin/editor/ui"
+	"github.com/jmigpin/editor/util/evreg"
+	"github.com/jmigpin/editor/util/parseutil"
+)
+
+// Fuzzy file finder: lists the files of a directory row that match the query of the "OpenFile <query>" toolbar part. The list is updated while the query is edited.
+type FileFinder struct {
+	erow  *ERow
+	index *fileIndex
+	query string
+	reg   *evreg.Regist
+}
+
+// Max number of files indexed, and max number of results listed.
+var fileFinderMaxFiles = 100000
+var fileFinderMaxResults = 200
+
+//----------
+
+// File rows search the project directory (with ".git") or the file directory, in a new (or existing) row of that directory.
+func OpenFileCmdFromPart(erow *ERow, part *toolbarparser.Part) error {
+	query := fileFinderQuery(part)
+
+	erow2 := erow
+	switch {
+	case erow.Info.IsDir():
+	case erow.Info.IsFileButNotDir():
+		root := fileFinderRoot(erow.Info.Dir())
+		erow2, _ = erow.Ed.ExistingOrNewERow(root)
+		if _, ok := fileFinderPart(erow2); !ok {
+			erow2.ToolbarSetStrAfterNameClearHistory(" | OpenFile " + query)
+		}
+		erow2.Flash()
+	default:
+		return fmt.Errorf("unable to find files for erow: %v", erow.Info.Name())
+	}
+
+	ff := erow2.fileFinder
+	if ff == nil {
+		ff = &FileFinder{erow: erow2}
+		ff.reg = erow2.Row.Toolbar.EvReg.Add(ui.TextAreaSetStrEventId, func(ev interface{}) {
+			ff.onToolbarChange()
+		})
+		erow2.fileFinder = ff
+	}
+
+	// re-index on each explicit run (files could have been added)
+	ff.index = newFileIndex(erow2.ctx, erow2.Info.Name())
+	ff.filter(query, true)
+	return nil
+}
+
+func (ff *FileFinder) Stop() {
+	ff.reg.Unregister()
+	if ff.erow.fileFinder == ff {
+		ff.erow.fileFinder = nil
+	}
+}
+
+//----------
+
+// UI goroutine.
+func (ff *FileFinder) onToolbarChange() {
+	part, ok := fileFinderPart(ff.erow)
+	if !ok {
+		ff.Stop() // cmd was removed from the toolbar
+		return
+	}
+	if q := fileFinderQuery(part); q != ff.query {
+		ff.filter(q, false)
+	}
+}
+
+// Lists the matches in the row. If openSingle is set and there is only one match, the file is opened.
+func (ff *FileFinder) filter(query string, openSingle bool) {
+	ff.query = query
+	index := ff.index
+	erow := ff.erow
+	erow.Exec.Start(func(ctx context.Context, w io.Writer) error {
+		erow.Ed.UI.RunOnUIGoRoutine(func() {
+			erow.Row.TextArea.SetStrClearHistory("")
+			erow.Row.TextArea.ClearPos()
+		})
+
+		files, err := index.wait(ctx)
+		if err != nil {
+			return err
+		}
+		res := fuzzyFindFiles(files, query, fileFinderMaxResults)
+
+		bw := bufio.NewWriter(w)
+		fmt.Fprintf(bw, "# %d of %d files\n", len(res), len(files))
+		for _, f := range res {
+			fmt.Fprintf(bw, "%s\n", parseutil.EscapeFilename(filepath.FromSlash(f)))
+		}
+		if err := bw.Flush(); err != nil {
+			return err
+		}
+
+		if openSingle && query != "" && len(res) == 1 {
+			filename := filepath.Join(erow.Info.Name(), filepath.FromSlash(res[0]))
+			erow.Ed.UI.RunOnUIGoRoutine(func() {
+				conf := &OpenFileERowConfig{
+					FilePos:             &parseutil.FilePos{Filename: filename},
+					RowPos:              erow.Ed.GoodRowPos(),
+					FlashVisibleOffsets: true,
+					NewIfNotExistent:    true,
+					JumpList:            true,
+				}
+				OpenFileERow(erow.Ed, conf)
+			})
+		}
+		return nil
+	})
+}
+
+//----------
+
+func fileFinderPart(erow *ERow) (*toolbarparser.Part, bool) {
+	if len(erow.TbData.Parts) == 0 {
+		return nil, false
+	}
+	for _, part := range erow.TbData.Parts[1:] {
+		if len(part.Args) > 0 && part.Args[0].UnquotedStr() == "OpenFile" {
+			return part, true
+		}
+	}
+	return nil, false
+}
+
+func fileFinderQuery(part *toolbarparser.Part) string {
+	u := []string{}
+	for _, a := range part.Args[1:] {
+		u = append(u, a.UnquotedStr())
+	}
+	return strings.Join(u, " ")
+}
+
+// Closest parent directory with ".git", or the directory itself.
+func fileFinderRoot(dir string) string {
+	for d := dir; ; {
+		if _, err := os.Stat(filepath.Join(d, ".git")); err == nil {
+			return d
+		}
+		d2 := filepath.Dir(d)
+		if d2 == d {
+			return dir
+		}
+		d = d2
+	}
+}
+
+//----------
+
+// Files (relative slash paths) under a directory, indexed in the background. Skips hidden files/directories and ".gitignore" matches.
+type fileIndex struct {
+	done  chan struct{}
+	files []string
+	err   error
+}
+
+func newFileIndex(ctx context.Context, dir string) *fileIndex {
+	fi := &fileIndex{done: make(chan struct{})}
+	go func() {
+		defer close(fi.done)
+		fi.files, fi.err = indexFiles(ctx, dir, fileFinderMaxFiles)
+	}()
+	return fi
+}
+
+func (fi *fileIndex) wait(ctx context.Context) ([]string, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-fi.done:
+		return fi.files, fi.err
+	}
+}
+
+func indexFiles(ctx context.Context, dir string, max int) ([]string, error) {
+	files := []string{}
+	gi := &gitIgnore{}
+	err := filepath.Walk(dir, func(p string, fi os.FileInfo, err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			return nil // skip
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == "." {
+			gi.addFile(dir, "")
+			return nil
+		}
+		if strings.HasPrefix(fi.Name(), ".") || gi.match(rel, fi.IsDir()) {
+			if fi.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if fi.IsDir() {
+			gi.addFile(p, rel)
+			return nil
+		}
+		if len(files) >= max {
+			return io.EOF // stop
+		}
+		files = append(files, rel)
+		return nil
+	})
+	if err == io.EOF {
+		err = nil
+	}
+	return files, err
+}
+
+//----------
+
+// Subset of the ".gitignore" rules: comments, negation ("!"), directory only ("/" suffix), anchored patterns (with "/"), and "**".
+type gitIgnore struct {
+	patterns []*gitIgnorePattern
+}
+
+type gitIgnorePattern struct {
+	dir      string // slash path of the .gitignore directory relative to the root ("" is the root)
+	pattern  string
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+func (gi *gitIgnore) addFile(dir, rel string) {
+	f, err := os.Open(filepath.Join(dir, ".gitignore"))
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	gi.add(rel, f)
+}
+
+func (gi *gitIgnore) add(dir string, rd io.Reader) {
+	sc := bufio.NewScanner(rd)
+	for sc.Scan() {
+		s := strings.TrimRight(sc.Text(), " \t\r")
+		if s == "" || strings.HasPrefix(s, "#") {
+			continue
+		}
+		p := &gitIgnorePattern{dir: dir}
+		if strings.HasPrefix(s, "!") {
+			p.negate = true
+			s = s[1:]
+		}
+		if strings.HasSuffix(s, "/") {
+			p.dirOnly = true
+			s = strings.TrimRight(s, "/")
+		}
+		if strings.Contains(s, "/") {
+			p.anchored = true
+			s = strings.TrimLeft(s, "/")
+		}
+		if s == "" {
+			continue
+		}
+		p.pattern = s
+		gi.patterns = append(gi.patterns, p)
+	}
+}
+
+// The last matching pattern decides. The path is relative to the root.
+func (gi *gitIgnore) match(rel string, isDir bool) bool {
+	ignored := false
+	for _, p := range gi.patterns {
+		if p.dirOnly && !isDir {
+			continue
+		}
+		r := rel
+		if p.dir != "" {
+			if !strings.HasPrefix(rel, p.dir+"/") {
+				continue
+			}
+			r = rel[len(p.dir)+1:]
+		}
+		ok := false
+		if p.anchored {
+			ok = globMatchSegments(p.pattern, r)
+		} else {
+			ok, _ = path.Match(p.pattern, path.Base(r))
+		}
+		if ok {
+			ignored = !p.negate
+		}
+	}
+	return ignored
+}
+
+// Glob with "**" matching zero or more path segments.
+func globMatchSegments(pattern, name string) bool {
+	return globMatchSegments2(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func globMatchSegments2(ps, ns []string) bool {
+	for len(ps) > 0 {
+		if ps[0] == "**" {
+			for k := 0; k <= len(ns); k++ {
+				if globMatchSegments2(ps[1:], ns[k:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(ns) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(ps[0], ns[0]); !ok {
+			return false
+		}
+		ps, ns = ps[1:], ns[1:]
+	}
+	return len(ns) == 0
+}
+
+//----------
+
+// Returns the files that match all the (space separated) query terms, best first.
+func fuzzyFindFiles(files []string, query string, max int) []string {
+	terms := strings.Fields(query)
+	type res struct {
+		file  string
+		score int
+	}
+	u := []res{}
+	for _, f := range files {
+		score, ok := 0, true
+		for _, t := range terms {
+			s, ok2 := fuzzyScore(f, t)
+			if !ok2 {
+				ok = false
+				break
+			}
+			score += s
+		}
+		if !ok {
+			continue
+		}
+		score -= len(f) / 8 // prefer shorter paths
+		u = append(u, res{f, score})
+	}
+	sort.SliceStable(u, func(a, b int) bool {
+		if u[a].score != u[b].score {
+			return u[a].score > u[b].score
+		}
+		return u[a].file < u[b].file
+	})
+	if len(u) > max {
+		u = u[:max]
+	}
+	files2 := make([]string, len(u))
+	for i, r := range u {
+		files2[i] = r.file
+	}
+	return files2
+}
+
+// Score of the best match of the query runes in order (case insensitive). Matches at the start of path segments, words, in the base name and consecutive matches score higher.
+func fuzzyScore(file, query string) (int, bool) {
+	p := []rune(file)
+	q := []rune(strings.ToLower(query))
+	if len(q) == 0 {
+		return 0, true
+	}
+	n := len(p)
+	if n < len(q) {
+		return 0, false
+	}
+	base := utf8.RuneCountInString(file[:strings.LastIndex(file, "/")+1])
+
+	const none = -1 << 30
+	bonus := func(j int) int {
+		b := 1
+		if j >= base {
+			b += 3
+		}
+		if j == 0 {
+			return b + 8
+		}
+		prev := p[j-1]
+		switch {
+		case prev == '/':
+			b += 8
+		case prev == '_' || prev == '-' || prev == '.' || prev == ' ':
+			b += 5
+		case unicode.IsLower(prev) && unicode.IsUpper(p[j]):
+			b += 4
+		}
+		return b
+	}
+	const consecutive = 4
+
+	prev := make([]int, n)
+	cur := make([]int, n)
+	for i := range q {
+		runMax := none // best of prev[0..j-1]
+		for j := 0; j < n; j++ {
+			if i > 0 && j > 0 && prev[j-1] > runMax {
+				runMax = prev[j-1]
+			}
+			cur[j] = none
+			if unicode.ToLower(p[j]) != q[i] {
+				continue
+			}
+			b := bonus(j)
+			if i == 0 {
+				cur[j] = b
+				continue
+			}
+			s := none
+			if runMax != none {
+				s = runMax + b
+			}
+			if j > 0 && prev[j-1] != none && prev[j-1]+b+consecutive > s {
+				s = prev[j-1] + b + consecutive
+			}
+			cur[j] = s
+		}
+		prev, cur = cur, prev
+	}
+	best := none
+	for _, s := range prev {
+		if s > best {
+			best = s
+		}
+	}
+	return best, best != none
+}
@@ -0,0 +1,114 @@
+package core
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFuzzyFindFiles(t *testing.T) {
+	files := []string{
+		"core/editor.go",
+		"core/erow.go",
+		"core/erowinfo.go",
+		"ui/row.go",
+		"util/drawutil/drawer4/drawer.go",
+		"core/contentcmds/openfilename.go",
+	}
+	type test struct {
+		query string
+		first string
+		n     int
+	}
+	tests := []test{
+		{"erow", "core/erow.go", 2},
+		{"edgo", "core/editor.go", 2},
+		{"ofn", "core/contentcmds/openfilename.go", 1},
+		{"ui row", "ui/row.go", 1},
+		{"d4draw", "util/drawutil/drawer4/drawer.go", 1},
+		{"zzz", "", 0},
+	}
+	for _, tt := range tests {
+		res := fuzzyFindFiles(files, tt.query, 10)
+		if len(res) < tt.n || (tt.n == 0 && len(res) != 0) {
+			t.Fatalf("%q: %v", tt.query, res)
+		}
+		if tt.n > 0 && res[0] != tt.first {
+			t.Fatalf("%q: got %v, expecting %v first", tt.query, res, tt.first)
+		}
+	}
+}
+
+func TestFuzzyScoreSegments(t *testing.T) {
+	// match at the start of the base name scores higher
+	s1, ok1 := fuzzyScore("abc/row.go", "row")
+	s2, ok2 := fuzzyScore("arow/xyz.go", "row")
+	if !ok1 || !ok2 || s1 <= s2 {
+		t.Fatal(s1, s2)
+	}
+}
+
+func TestGitIgnore(t *testing.T) {
+	gi := &gitIgnore{}
+	gi.add("", strings.NewReader("# comment\n*.o\nbuild/\n/docs/*.html\n!keep.o\n**/gen/*.go\n"))
+	gi.add("sub", strings.NewReader("local.txt\n"))
+
+	type test struct {
+		rel   string
+		isDir bool
+		ign   bool
+	}
+	tests := []test{
+		{"a.o", false, true},
+		{"x/y/a.o", false, true},
+		{"keep.o", false, false},
+		{"build", true, true},
+		{"build", false, false},
+		{"docs/a.html", false, true},
+		{"x/docs/a.html", false, false},
+		{"x/gen/a.go", false, true},
+		{"gen/a.go", false, true},
+		{"sub/local.txt", false, true},
+		{"local.txt", false, false},
+		{"main.go", false, false},
+	}
+	for _, tt := range tests {
+		if v := gi.match(tt.rel, tt.isDir); v != tt.ign {
+			t.Fatalf("%v: got %v", tt.rel, v)
+		}
+	}
+}
+
+func TestIndexFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "editor_filefinder")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	write := func(name, s string) {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(s), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(".gitignore", "out/\n")
+	write("a.go", "")
+	write("b/c.go", "")
+	write("b/.hidden/d.go", "")
+	write("out/e.go", "")
+
+	files, err := indexFiles(context.Background(), dir, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(files, ",") != "a.go,b/c.go" {
+		t.Fatal(files)
+	}
+}
@@ -0,0 +1,41 @@
+package core
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmigpin/editor/ui"
+	"github.com/jmigpin/editor/util/drawutil/drawer4"
+)
+
+// Toggles the fold using the lsproto folding ranges. Returns false if there is no lsproto server registered for the file (the textarea computes the ranges from the content).
+func ToggleFoldLSProto(erow *ERow, ev *ui.TextAreaFoldEvent) bool {
+	if !erow.Info.IsFileButNotDir() {
+		return false
+	}
+	filename := erow.Info.Name()
+	if _, err := erow.Ed.LSProtoMan.LangManager(filename); err != nil {
+		return false
+	}
+
+	ta := ev.TextArea
+	index := ev.Index
+	go func() {
+		ctx, cancel := context.WithTimeout(erow.ctx, 8*time.Second)
+		defer cancel()
+		rd := ta.TextCursor.RW()
+		u, err := erow.Ed.LSProtoMan.TextDocumentFoldingRange(ctx, filename, rd)
+		if err != nil {
+			erow.Ed.Error(err)
+			// continue: fold from the content
+		}
+		ranges := []*drawer4.Fold{}
+		for _, r := range u {
+			ranges = append(ranges, &drawer4.Fold{Start: r[0], End: r[1]})
+		}
+		erow.Ed.UI.RunOnUIGoRoutine(func() {
+			ta.ToggleFold(index, ranges)
+		})
+	}()
+	return true
+}
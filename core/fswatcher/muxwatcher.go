@@ -0,0 +1,240 @@
+package fswatcher
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+)
+
+// Shares one watcher between several users (each watcher instance counts against the os limits, ex: linux max_user_instances). Added names are reference counted, and capped by maxNames. Events are delivered to the users watching the name or its directory. Each user has its own queue so a slow user doesn't delay the others.
+type MuxWatcher struct {
+	w        Watcher
+	maxNames int
+
+	mu    sync.Mutex
+	refs  map[string]int
+	users map[*MuxUser]bool
+}
+
+func NewMuxWatcher(w Watcher, maxNames int) *MuxWatcher {
+	*w.OpMask() = AllOps
+	mw := &MuxWatcher{
+		w:        w,
+		maxNames: maxNames,
+		refs:     map[string]int{},
+		users:    map[*MuxUser]bool{},
+	}
+	go mw.eventLoop()
+	return mw
+}
+
+func (mw *MuxWatcher) Close() error {
+	return mw.w.Close()
+}
+
+// Closing the user removes its names.
+func (mw *MuxWatcher) NewUser() *MuxUser {
+	u := &MuxUser{
+		mw:     mw,
+		names:  map[string]bool{},
+		events: make(chan interface{}),
+		done:   make(chan struct{}),
+		qc:     make(chan struct{}, 1),
+		opMask: AllOps,
+	}
+	go u.sendLoop()
+	mw.mu.Lock()
+	defer mw.mu.Unlock()
+	mw.users[u] = true
+	return u
+}
+
+//----------
+
+func (mw *MuxWatcher) eventLoop() {
+	for {
+		ev, ok := <-mw.w.Events()
+		if !ok {
+			return
+		}
+		for _, u := range mw.eventUsers(ev) {
+			u.push(ev)
+		}
+	}
+}
+
+func (mw *MuxWatcher) eventUsers(ev interface{}) []*MuxUser {
+	mw.mu.Lock()
+	defer mw.mu.Unlock()
+	users := []*MuxUser{}
+	for u := range mw.users {
+		switch t := ev.(type) {
+		case error:
+			if len(u.names) > 0 {
+				users = append(users, u)
+			}
+		case *Event:
+			if !u.names[t.Name] && !u.names[filepath.Dir(t.Name)] {
+				continue
+			}
+			if t.Op.HasAny(u.opMask) {
+				users = append(users, u)
+			}
+		}
+	}
+	return users
+}
+
+//----------
+
+func (mw *MuxWatcher) add(u *MuxUser, name string) error {
+	name = filepath.Clean(name)
+	mw.mu.Lock()
+	defer mw.mu.Unlock()
+	if u.closed || u.names[name] {
+		return nil
+	}
+	if mw.refs[name] == 0 {
+		if len(mw.refs) >= mw.maxNames {
+			return fmt.Errorf("fswatcher: too many watched names (max=%v): %v", mw.maxNames, name)
+		}
+		if err := mw.w.Add(name); err != nil {
+			return err
+		}
+	}
+	mw.refs[name]++
+	u.names[name] = true
+	return nil
+}
+
+func (mw *MuxWatcher) remove(u *MuxUser, name string) error {
+	name = filepath.Clean(name)
+	mw.mu.Lock()
+	defer mw.mu.Unlock()
+	return mw.remove2(u, name)
+}
+
+func (mw *MuxWatcher) remove2(u *MuxUser, name string) error {
+	if !u.names[name] {
+		return nil
+	}
+	delete(u.names, name)
+	mw.refs[name]--
+	if mw.refs[name] > 0 {
+		return nil
+	}
+	delete(mw.refs, name)
+	return mw.w.Remove(name)
+}
+
+func (mw *MuxWatcher) closeUser(u *MuxUser) {
+	mw.mu.Lock()
+	defer mw.mu.Unlock()
+	if u.closed {
+		return
+	}
+	u.closed = true
+	for name := range u.names {
+		_ = mw.remove2(u, name)
+	}
+	delete(mw.users, u)
+	close(u.done) // unblocks a pending send
+}
+
+//----------
+
+// Watcher interface on a MuxWatcher. The op mask should be set before adding names.
+type MuxUser struct {
+	mw     *MuxWatcher
+	names  map[string]bool // guarded by mw.mu
+	closed bool            // guarded by mw.mu
+	events chan interface{}
+	done   chan struct{}
+	opMask Op
+
+	q struct {
+		sync.Mutex
+		evs []interface{} // coalesced: one event per name, one error
+	}
+	qc chan struct{} // signals queued events
+}
+
+func (u *MuxUser) Add(name string) error {
+	return u.mw.add(u, name)
+}
+func (u *MuxUser) Remove(name string) error {
+	return u.mw.remove(u, name)
+}
+func (u *MuxUser) Events() <-chan interface{} {
+	return u.events
+}
+func (u *MuxUser) OpMask() *Op {
+	return &u.opMask
+}
+func (u *MuxUser) Close() error {
+	u.mw.closeUser(u)
+	return nil
+}
+
+//----------
+
+// Doesn't block: events of a name already queued are merged.
+func (u *MuxUser) push(ev interface{}) {
+	u.q.Lock()
+	defer u.q.Unlock()
+	switch t := ev.(type) {
+	case error:
+		for _, e := range u.q.evs {
+			if _, ok := e.(error); ok {
+				return // keep the first
+			}
+		}
+	case *Event:
+		for _, e := range u.q.evs {
+			if e2, ok := e.(*Event); ok && e2.Name == t.Name {
+				e2.Op.Add(t.Op)
+				return
+			}
+		}
+		ev2 := *t // copy: the op might be merged, and the event is shared with other users
+		ev = &ev2
+	}
+	u.q.evs = append(u.q.evs, ev)
+	select {
+	case u.qc <- struct{}{}:
+	default:
+	}
+}
+
+func (u *MuxUser) pop() (interface{}, bool) {
+	u.q.Lock()
+	defer u.q.Unlock()
+	if len(u.q.evs) == 0 {
+		return nil, false
+	}
+	ev := u.q.evs[0]
+	u.q.evs[0] = nil
+	u.q.evs = u.q.evs[1:]
+	return ev, true
+}
+
+func (u *MuxUser) sendLoop() {
+	for {
+		select {
+		case <-u.qc:
+		case <-u.done:
+			return
+		}
+		for {
+			ev, ok := u.pop()
+			if !ok {
+				break
+			}
+			select {
+			case u.events <- ev:
+			case <-u.done:
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package fswatcher
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMuxWatcher1(t *testing.T) {
+	tmpDir := tmpDir()
+	defer os.RemoveAll(tmpDir)
+
+	mw := NewMuxWatcher(mustNewFsnWatcher(t), 2)
+	defer mw.Close()
+
+	u1 := mw.NewUser()
+	defer u1.Close()
+	u2 := mw.NewUser()
+	defer u2.Close()
+
+	dir := tmpDir
+	dir2 := filepath.Join(dir, "dir2")
+	mustAddWatch(t, u1, dir)
+	mustAddWatch(t, u2, dir)
+
+	// removing from one user keeps the other watching
+	mustRemoveWatch(t, u2, dir)
+	mustMkdirAll(t, dir2)
+	readEvent(t, u1, true, func(ev *Event) bool {
+		return ev.Name == dir2 && ev.Op.HasAny(Create)
+	})
+
+	// max names
+	mustAddWatch(t, u2, dir2)
+	if err := u2.Add(filepath.Join(dir, "dir3")); err == nil {
+		t.Fatal("expecting error")
+	}
+
+	// closed users don't block the other users
+	u2.Close()
+	mustCreateFile(t, filepath.Join(dir2, "f1"))
+	mustCreateFile(t, filepath.Join(dir, "f2"))
+	readEvent(t, u1, true, func(ev *Event) bool {
+		return ev.Name == filepath.Join(dir, "f2") && ev.Op.HasAny(Create)
+	})
+}
+
+func TestMuxWatcher2(t *testing.T) {
+	tmpDir := tmpDir()
+	defer os.RemoveAll(tmpDir)
+
+	mw := NewMuxWatcher(mustNewFsnWatcher(t), 2)
+	defer mw.Close()
+
+	u1 := mw.NewUser()
+	defer u1.Close()
+	u2 := mw.NewUser()
+	defer u2.Close()
+
+	dir := tmpDir
+	mustAddWatch(t, u1, dir)
+	mustAddWatch(t, u2, dir)
+
+	// a user not reading doesn't delay the other users
+	names := []string{}
+	for i := 0; i < 3; i++ {
+		name := filepath.Join(dir, fmt.Sprintf("f%v", i))
+		names = append(names, name)
+		mustCreateFile(t, name)
+		readEvent(t, u2, true, func(ev *Event) bool {
+			return ev.Name == name && ev.Op.HasAny(Create)
+		})
+	}
+
+	// events were queued for the user not reading
+	for _, name := range names {
+		readEvent(t, u1, true, func(ev *Event) bool {
+			return ev.Name == name && ev.Op.HasAny(Create)
+		})
+	}
+}
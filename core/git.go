@@ -0,0 +1,507 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmigpin/editor/ui"
+	"github.com/jmigpin/editor/util/drawutil/drawer4"
+	"github.com/jmigpin/editor/util/iout/iorw"
+	"github.com/jmigpin/editor/util/parseutil"
+	"github.com/jmigpin/editor/util/uiutil/widget"
+)
+
+// Changes of the file rows against the git HEAD (line backgrounds) and blame annotations. The changes are read from the file on disk (updated on save and on disk events). Should be used in the UI goroutine.
+type Git struct {
+	ed    *Editor
+	files map[string]*gitFile
+}
+
+type gitFile struct {
+	hunks   []*GitHunk             // nil if not in a repository (or no changes)
+	changes []*drawer4.ChangeEntry // hunks offsets in the row content, kept in place while editing
+	blame   []string               // annotation per line, nil if off
+	cancel  context.CancelFunc
+	seq     int // current diff run
+}
+
+// Timeout for the git cmds to run.
+var gitCmdTimeout = 10 * time.Second
+
+func NewGit(ed *Editor) *Git {
+	return &Git{ed: ed, files: map[string]*gitFile{}}
+}
+
+//----------
+
+// Reads the changes of the file on disk (async). Previous runs are canceled.
+func (g *Git) UpdateInfo(info *ERowInfo) {
+	if !info.IsFileButNotDir() {
+		return
+	}
+	gf := g.file(info.Name())
+	if gf.cancel != nil {
+		gf.cancel()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), gitCmdTimeout)
+	gf.cancel = cancel
+	gf.seq++
+	seq := gf.seq
+	filename := info.Name()
+	go func() {
+		defer cancel()
+		hunks, err := gitDiffHunks(ctx, filename, 0)
+		if err != nil {
+			hunks = nil // not in a repository, or git not available
+		}
+		g.ed.UI.RunOnUIGoRoutine(func() {
+			if g.files[filename] != gf || gf.seq != seq {
+				return // superseded
+			}
+			gf.hunks = hunks
+			gf.changes = nil
+			if len(info.ERows) > 0 {
+				rd := info.ERows[0].Row.TextArea.TextCursor.RW()
+				gf.changes = gitChangeEntries(rd, hunks)
+			}
+			g.updateInfo(info)
+		})
+	}()
+}
+
+func (g *Git) file(filename string) *gitFile {
+	gf, ok := g.files[filename]
+	if !ok {
+		gf = &gitFile{}
+		g.files[filename] = gf
+	}
+	return gf
+}
+
+//----------
+
+func (g *Git) updateInfo(info *ERowInfo) {
+	for _, erow := range info.ERows {
+		g.updateERow(erow)
+	}
+}
+
+func (g *Git) updateERow(erow *ERow) {
+	if !erow.Info.IsFileButNotDir() {
+		return
+	}
+	gf, ok := g.files[erow.Info.Name()]
+	if !ok {
+		g.UpdateInfo(erow.Info)
+		return
+	}
+	ta := erow.Row.TextArea
+	ta.SetChanges(gf.changes)
+	if gf.blame != nil {
+		g.setBlameAnnotations(erow, true, gitBlameAnnotations(ta.TextCursor.RW(), gf.blame))
+	}
+}
+
+func (g *Git) setBlameAnnotations(erow *ERow, on bool, anns []*drawer4.Annotation) {
+	// godebug annotations have priority
+	if erow.Row.HasState(ui.RowStateAnnotations) {
+		return
+	}
+	ta := erow.Row.TextArea
+	if !g.ed.CanModifyAnnotations(EdAnnReqGitBlame, ta, "") {
+		return
+	}
+	g.ed.SetAnnotations(EdAnnReqGitBlame, ta, on, -1, anns)
+}
+
+// Moves the change markers with the edits (like marks), the hunks are only updated on save.
+func (g *Git) UpdateWriteOp(info *ERowInfo, u *widget.RWWriteOpCb) {
+	gf, ok := g.files[info.Name()]
+	if !ok || len(gf.changes) == 0 {
+		return
+	}
+	changes := make([]*drawer4.ChangeEntry, 0, len(gf.changes))
+	for _, e := range gf.changes {
+		e2 := &drawer4.ChangeEntry{Start: u.UpdateOffset(e.Start), End: u.UpdateOffset(e.End), Kind: e.Kind}
+		changes = append(changes, e2)
+	}
+	gf.changes = changes
+	for _, erow := range info.ERows {
+		erow.Row.TextArea.SetChanges(gf.changes)
+	}
+}
+
+// Should be called when the row is closed.
+func (g *Git) removeERow(erow *ERow) {
+	if len(erow.Info.ERows) > 1 { // row not removed from info yet
+		return
+	}
+	if gf, ok := g.files[erow.Info.Name()]; ok {
+		if gf.cancel != nil {
+			gf.cancel()
+		}
+		delete(g.files, erow.Info.Name())
+	}
+}
+
+//----------
+
+// Hunk at the line of the text cursor. The row must not have unsaved changes since the hunks are from the file on disk.
+func (g *Git) hunkAtCursor(erow *ERow) (*GitHunk, error) {
+	if !erow.Info.IsFileButNotDir() {
+		return nil, fmt.Errorf("not a file")
+	}
+	if erow.Info.HasRowState(ui.RowStateEdited) {
+		return nil, fmt.Errorf("row has unsaved changes")
+	}
+	gf, ok := g.files[erow.Info.Name()]
+	if !ok || len(gf.hunks) == 0 {
+		return nil, fmt.Errorf("no changes")
+	}
+	ta := erow.Row.TextArea
+	line, _, err := parseutil.IndexLineColumn(ta.TextCursor.RW(), ta.TextCursor.Index())
+	if err != nil {
+		return nil, err
+	}
+	for _, h := range gf.hunks {
+		if h.containsLine(line) {
+			return h, nil
+		}
+	}
+	return nil, fmt.Errorf("no changes at line %v", line)
+}
+
+// Replaces the hunk at the cursor with the HEAD content as one undoable edit.
+func (g *Git) RevertHunk(erow *ERow) error {
+	h, err := g.hunkAtCursor(erow)
+	if err != nil {
+		return err
+	}
+	tc := erow.Row.TextArea.TextCursor
+	rd := tc.RW()
+	a, b := h.newRange(rd)
+	old := []byte(strings.Join(h.Old, ""))
+
+	tc.BeginEdit()
+	defer tc.EndEdit()
+	if err := rd.Overwrite(a, b-a, old); err != nil {
+		return err
+	}
+	tc.SetIndex(a)
+	return nil
+}
+
+//----------
+
+// Toggles blame annotations (author/commit per line) of the row content.
+func (g *Git) ToggleBlame(erow *ERow) error {
+	if !erow.Info.IsFileButNotDir() {
+		return fmt.Errorf("not a file")
+	}
+	gf := g.file(erow.Info.Name())
+	if gf.blame != nil {
+		gf.blame = nil
+		for _, e := range erow.Info.ERows {
+			g.setBlameAnnotations(e, false, nil)
+		}
+		return nil
+	}
+
+	b, err := erow.Row.TextArea.Bytes()
+	if err != nil {
+		return err
+	}
+	info := erow.Info
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), gitCmdTimeout)
+		defer cancel()
+		dir, base := filepath.Split(info.Name())
+		args := []string{"git", "blame", "--porcelain", "--contents", "-", "--", base}
+		out, err := ExecCmdStdin(ctx, dir, bytes.NewReader(b), args...)
+		if err != nil {
+			g.ed.Errorf("gitblame: %v", err)
+			return
+		}
+		lines, err := parseGitBlame(bytes.NewReader(out))
+		if err != nil {
+			g.ed.Errorf("gitblame: %v", err)
+			return
+		}
+		g.ed.UI.RunOnUIGoRoutine(func() {
+			if g.files[info.Name()] != gf {
+				return // rows closed meanwhile
+			}
+			gf.blame = lines
+			g.updateInfo(info)
+		})
+	}()
+	return nil
+}
+
+func gitBlameAnnotations(rd iorw.Reader, lines []string) []*drawer4.Annotation {
+	u := []*drawer4.Annotation{}
+	for i, s := range lines {
+		o, err := parseutil.LineColumnIndex(rd, i+1, 1)
+		if err != nil {
+			break
+		}
+		u = append(u, &drawer4.Annotation{Offset: o, Bytes: []byte(s)})
+	}
+	return u
+}
+
+//----------
+
+// Unified diff of the file on disk against HEAD (with context lines), with a "<filename>:<line>" line before each hunk to be able to open the position.
+func GitDiff(ctx context.Context, filename string, w io.Writer, encode func(string) string) error {
+	out, err := gitDiff(ctx, filename, 3)
+	if err != nil {
+		return err
+	}
+	if len(out) == 0 {
+		_, err := fmt.Fprintf(w, "%v: no changes\n", encode(filename))
+		return err
+	}
+	return writeDiffPositions(w, out, encode(filename))
+}
+
+// Writes the unified diff with a "<filename>:<line>" line (new file line) before each hunk header.
+func writeDiffPositions(w io.Writer, diff []byte, filename string) error {
+	bw := bufio.NewWriter(w)
+	sc := bufio.NewScanner(bytes.NewReader(diff))
+	sc.Buffer(nil, 1024*1024)
+	for sc.Scan() {
+		s := sc.Text()
+		if h, ok := parseGitHunkHeader(s); ok {
+			fmt.Fprintf(bw, "%v:%v\n", filename, h.newLineOrFirst())
+		}
+		fmt.Fprintln(bw, s)
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+func gitDiff(ctx context.Context, filename string, unified int) ([]byte, error) {
+	dir, base := filepath.Split(filename)
+	args := []string{"git", "diff", "--no-color", "--no-ext-diff", fmt.Sprintf("-U%d", unified), "HEAD", "--", base}
+	return ExecCmd(ctx, dir, args...)
+}
+
+func gitDiffHunks(ctx context.Context, filename string, unified int) ([]*GitHunk, error) {
+	out, err := gitDiff(ctx, filename, unified)
+	if err != nil {
+		return nil, err
+	}
+	return parseGitDiffHunks(bytes.NewReader(out))
+}
+
+//----------
+
+// Hunk of a diff with zero context lines. Line numbers are one-based.
+type GitHunk struct {
+	OldLine, OldCount int
+	NewLine, NewCount int
+	Old               []string // removed lines (with the newline, if any)
+}
+
+func (h *GitHunk) Kind() drawer4.ChangeKind {
+	switch {
+	case h.NewCount == 0:
+		return drawer4.ChangeDeleted
+	case h.OldCount == 0:
+		return drawer4.ChangeAdded
+	default:
+		return drawer4.ChangeModified
+	}
+}
+
+// Line where the change is shown. Deleted lines are shown on the line before the deletion (or the first line).
+func (h *GitHunk) newLineOrFirst() int {
+	if h.NewLine < 1 {
+		return 1
+	}
+	return h.NewLine
+}
+
+func (h *GitHunk) containsLine(line int) bool {
+	if h.NewCount == 0 {
+		return line == h.newLineOrFirst()
+	}
+	return line >= h.NewLine && line < h.NewLine+h.NewCount
+}
+
+// Offsets of the new lines in the content. Deletions have an empty range after the line of the deletion.
+func (h *GitHunk) newRange(rd iorw.Reader) (int, int) {
+	if h.NewCount == 0 {
+		a := gitLineStart(rd, h.NewLine+1)
+		return a, a
+	}
+	return gitLineStart(rd, h.NewLine), gitLineStart(rd, h.NewLine+h.NewCount)
+}
+
+// Offset of the start of the line, or the end of the content if the line doesn't exist.
+func gitLineStart(rd iorw.Reader, line int) int {
+	if line < 1 {
+		return rd.Min()
+	}
+	o, err := parseutil.LineColumnIndex(rd, line, 1)
+	if err != nil {
+		return rd.Max()
+	}
+	return o
+}
+
+func gitChangeEntries(rd iorw.Reader, hunks []*GitHunk) []*drawer4.ChangeEntry {
+	u := []*drawer4.ChangeEntry{}
+	for _, h := range hunks {
+		var s, e int
+		if h.NewCount == 0 {
+			l := h.newLineOrFirst()
+			s, e = gitLineStart(rd, l), gitLineStart(rd, l+1)
+		} else {
+			s, e = h.newRange(rd)
+		}
+		// exclude the newline
+		if e > s {
+			if b, err := rd.ReadNSliceAt(e-1, 1); err == nil && b[0] == '\n' {
+				e--
+			}
+		}
+		u = append(u, &drawer4.ChangeEntry{Start: s, End: e, Kind: h.Kind()})
+	}
+	return u
+}
+
+//----------
+
+var gitHunkHeaderRe = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+func parseGitHunkHeader(s string) (*GitHunk, bool) {
+	m := gitHunkHeaderRe.FindStringSubmatch(s)
+	if m == nil {
+		return nil, false
+	}
+	atoi := func(s string, def int) int {
+		if s == "" {
+			return def
+		}
+		v, _ := strconv.Atoi(s)
+		return v
+	}
+	h := &GitHunk{
+		OldLine:  atoi(m[1], 0),
+		OldCount: atoi(m[2], 1),
+		NewLine:  atoi(m[3], 0),
+		NewCount: atoi(m[4], 1),
+	}
+	return h, true
+}
+
+// Parses the hunks of a unified diff (single file).
+func parseGitDiffHunks(rd io.Reader) ([]*GitHunk, error) {
+	hunks := []*GitHunk{}
+	var cur *GitHunk
+	prev := byte(0) // first byte of the previous hunk line
+	br := bufio.NewReader(rd)
+	for {
+		s, err := br.ReadString('\n')
+		if s != "" {
+			if h, ok := parseGitHunkHeader(s); ok {
+				cur = h
+				hunks = append(hunks, h)
+				prev = 0
+			} else if cur != nil && len(s) > 0 {
+				switch s[0] {
+				case '-':
+					cur.Old = append(cur.Old, s[1:])
+				case '\\': // "\ No newline at end of file"
+					if prev == '-' {
+						k := len(cur.Old) - 1
+						cur.Old[k] = strings.TrimSuffix(cur.Old[k], "\n")
+					}
+				}
+				prev = s[0]
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return hunks, nil
+}
+
+//----------
+
+// Parses "git blame --porcelain" output into one "<commit> <author> <date>" string per line.
+func parseGitBlame(rd io.Reader) ([]string, error) {
+	type commit struct {
+		author string
+		time   int64
+	}
+	commits := map[string]*commit{}
+	lines := []string{}
+	var cur *commit
+	var curId string
+
+	sc := bufio.NewScanner(rd)
+	sc.Buffer(nil, 1024*1024)
+	for sc.Scan() {
+		s := sc.Text()
+		if strings.HasPrefix(s, "\t") { // content line
+			if cur == nil {
+				return nil, fmt.Errorf("content line without header")
+			}
+			id := curId
+			if len(id) > 8 {
+				id = id[:8]
+			}
+			date := time.Unix(cur.time, 0).Format("2006-01-02")
+			lines = append(lines, fmt.Sprintf("%v %v %v", id, cur.author, date))
+			continue
+		}
+		f := strings.Fields(s)
+		if len(f) == 0 {
+			continue
+		}
+		// header: "<sha> <origline> <finalline> [<numlines>]"
+		if len(f) >= 3 && len(f[0]) == 40 {
+			curId = f[0]
+			c, ok := commits[curId]
+			if !ok {
+				c = &commit{}
+				commits[curId] = c
+			}
+			cur = c
+			continue
+		}
+		if cur == nil {
+			continue
+		}
+		switch f[0] {
+		case "author":
+			cur.author = strings.TrimPrefix(s, "author ")
+		case "author-time":
+			v, err := strconv.ParseInt(f[len(f)-1], 10, 64)
+			if err == nil {
+				cur.time = v
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
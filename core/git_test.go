@@ -0,0 +1,126 @@
+package core
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jmigpin/editor/util/drawutil/drawer4"
+	"github.com/jmigpin/editor/util/iout/iorw"
+)
+
+func TestParseGitDiffHunks(t *testing.T) {
+	s := "diff --git a/a.txt b/a.txt\n" +
+		"--- a/a.txt\n" +
+		"+++ b/a.txt\n" +
+		"@@ -0,0 +1,2 @@\n" +
+		"+n1\n" +
+		"+n2\n" +
+		"@@ -3 +5 @@ func f() {\n" +
+		"-o3\n" +
+		"+n5\n" +
+		"@@ -7,2 +8,0 @@\n" +
+		"-o7\n" +
+		"-o8\n" +
+		"\\ No newline at end of file\n"
+	hunks, err := parseGitDiffHunks(strings.NewReader(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hunks) != 3 {
+		t.Fatal(len(hunks))
+	}
+	h0, h1, h2 := hunks[0], hunks[1], hunks[2]
+	if h0.NewLine != 1 || h0.NewCount != 2 || h0.OldCount != 0 || h0.Kind() != drawer4.ChangeAdded {
+		t.Fatalf("%+v", h0)
+	}
+	if h1.OldLine != 3 || h1.OldCount != 1 || h1.NewLine != 5 || h1.NewCount != 1 || h1.Kind() != drawer4.ChangeModified {
+		t.Fatalf("%+v", h1)
+	}
+	if len(h1.Old) != 1 || h1.Old[0] != "o3\n" {
+		t.Fatalf("%q", h1.Old)
+	}
+	if h2.NewLine != 8 || h2.NewCount != 0 || h2.Kind() != drawer4.ChangeDeleted {
+		t.Fatalf("%+v", h2)
+	}
+	if len(h2.Old) != 2 || h2.Old[1] != "o8" {
+		t.Fatalf("%q", h2.Old)
+	}
+}
+
+func TestGitChangeEntries(t *testing.T) {
+	rd := iorw.NewStringReader("a\nb\nc\nd\n")
+	hunks := []*GitHunk{
+		{OldLine: 0, OldCount: 0, NewLine: 1, NewCount: 2}, // added a,b
+		{OldLine: 2, OldCount: 1, NewLine: 3, NewCount: 1}, // modified c
+		{OldLine: 4, OldCount: 2, NewLine: 4, NewCount: 0}, // deleted after d
+	}
+	u := gitChangeEntries(rd, hunks)
+	if len(u) != 3 {
+		t.Fatal(len(u))
+	}
+	if u[0].Start != 0 || u[0].End != 3 || u[0].Kind != drawer4.ChangeAdded {
+		t.Fatalf("%+v", u[0])
+	}
+	if u[1].Start != 4 || u[1].End != 5 || u[1].Kind != drawer4.ChangeModified {
+		t.Fatalf("%+v", u[1])
+	}
+	if u[2].Start != 6 || u[2].End != 7 || u[2].Kind != drawer4.ChangeDeleted {
+		t.Fatalf("%+v", u[2])
+	}
+}
+
+func TestGitHunkNewRange(t *testing.T) {
+	rw := iorw.NewBytesReadWriter([]byte("a\nX\nY\nd\n"))
+	h := &GitHunk{OldLine: 2, OldCount: 1, NewLine: 2, NewCount: 2, Old: []string{"b\n"}}
+	if !h.containsLine(3) || h.containsLine(4) {
+		t.Fatal()
+	}
+	a, b := h.newRange(rw)
+	if err := rw.Overwrite(a, b-a, []byte(strings.Join(h.Old, ""))); err != nil {
+		t.Fatal(err)
+	}
+	s, _ := iorw.ReadFullSlice(rw)
+	if string(s) != "a\nb\nd\n" {
+		t.Fatalf("%q", s)
+	}
+
+	// deleted lines at the start
+	h = &GitHunk{OldLine: 1, OldCount: 1, NewLine: 0, NewCount: 0, Old: []string{"z\n"}}
+	a, b = h.newRange(rw)
+	if a != 0 || b != 0 || !h.containsLine(1) {
+		t.Fatal(a, b)
+	}
+}
+
+func TestParseGitBlame(t *testing.T) {
+	id1 := strings.Repeat("a", 40)
+	id2 := strings.Repeat("b", 40)
+	s := id1 + " 1 1 2\n" +
+		"author John Doe\n" +
+		"author-mail <jd@example.com>\n" +
+		"author-time 1600000000\n" +
+		"author-tz +0000\n" +
+		"summary first\n" +
+		"filename a.txt\n" +
+		"\tline1\n" +
+		id1 + " 2 2\n" +
+		"\tline2\n" +
+		id2 + " 3 3 1\n" +
+		"author Not Committed Yet\n" +
+		"author-time 1700000000\n" +
+		"filename a.txt\n" +
+		"\tline3\n"
+	lines, err := parseGitBlame(strings.NewReader(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 3 {
+		t.Fatal(lines)
+	}
+	if lines[0] != "aaaaaaaa John Doe 2020-09-13" || lines[1] != lines[0] {
+		t.Fatalf("%q", lines)
+	}
+	if !strings.HasPrefix(lines[2], "bbbbbbbb Not Committed Yet") {
+		t.Fatalf("%q", lines[2])
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"go/parser"
 	"go/printer"
 	"go/token"
+	"strings"
 	"testing"
 
 	"github.com/jmigpin/editor/util/parseutil"
@@ -2089,6 +2090,45 @@ func TestAnnConfigContent(t *testing.T) {
 	t.Logf("%v", src) // TODO: test output
 }
 
+func TestAnnSetTestHooks(t *testing.T) {
+	src := `package pkg1
+		import "testing"
+		func TestA(t *testing.T) { a := 1 }
+		func Test(_ *testing.T) {}
+		func Testing(t *testing.T) {}
+		func TestB(t *testing.T, b int) {}
+		func TestMain(m *testing.M) {}
+		func TestC(t *testing.T) { t.Run("sub", func(t2 *testing.T) { c := 1 }) }
+	`
+	fset := token.NewFileSet()
+	astFile, err := parser.ParseFile(fset, "a_test.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	annset := NewAnnotatorSet()
+	annset.debugPkgName = "Σ"
+	annset.debugVarPrefix = "Σ"
+	annset.insertTestHooks(astFile)
+
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, fset, astFile); err != nil {
+		t.Fatal(err)
+	}
+	res := strings.Replace(buf.String(), "\t{", " {", -1) // printer alignment
+	for _, w := range []string{
+		"func TestA(t *testing.T) { defer Σ.TestEnd(Σ.TestStart(t.Name())); a := 1 }",
+		"func Test(Σt *testing.T) { defer Σ.TestEnd(Σ.TestStart(Σt.Name())) }",
+		"func Testing(t *testing.T) {}",
+		"func TestB(t *testing.T, b int) {}",
+		"func TestMain(m *testing.M) {}",
+		"t.Run(\"sub\", func(t2 *testing.T) { defer Σ.TestEnd(Σ.TestStart(t2.Name())); c := 1 })",
+	} {
+		if !strings.Contains(res, w) {
+			t.Fatalf("missing %q in:\n%v", w, res)
+		}
+	}
+}
+
 //----------
 //----------
 //----------
@@ -5,8 +5,11 @@ import (
 	"go/ast"
 	"go/token"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/jmigpin/editor/core/godebug/debug"
 	"golang.org/x/tools/go/ast/astutil"
@@ -87,6 +90,11 @@ func (annset *AnnotatorSet) AnnotateAstFile(astFile *ast.File, filename string,
 
 		// keep test files package names in case of need to build testmain files
 		annset.keepTestPackage(filename, astFile)
+
+		// mark start/end of tests to tag the msgs with the running test
+		if strings.HasSuffix(filename, "_test.go") {
+			annset.insertTestHooks(astFile)
+		}
 	}
 
 	return nil
@@ -123,6 +131,98 @@ func (annset *AnnotatorSet) insertDebugExitInFunction(astFile *ast.File, name st
 
 //----------
 
+// Inserts "defer debug.TestEnd(debug.TestStart(t.Name()))" in test functions, and in the functions given to t.Run (subtests).
+func (annset *AnnotatorSet) insertTestHooks(astFile *ast.File) {
+	for _, decl := range astFile.Decls {
+		fd, ok := decl.(*ast.FuncDecl)
+		if !ok || fd.Recv != nil || fd.Body == nil {
+			continue
+		}
+		if !isTestFuncName(fd.Name.Name) {
+			continue
+		}
+		annset.insertTestHook(fd.Type, fd.Body)
+	}
+
+	// subtests: "<x>.Run(<name>, func(t *testing.T){...})"
+	ast.Inspect(astFile, func(n ast.Node) bool {
+		ce, ok := n.(*ast.CallExpr)
+		if !ok || len(ce.Args) != 2 {
+			return true
+		}
+		se, ok := ce.Fun.(*ast.SelectorExpr)
+		if !ok || se.Sel.Name != "Run" {
+			return true
+		}
+		if fl, ok := ce.Args[1].(*ast.FuncLit); ok {
+			annset.insertTestHook(fl.Type, fl.Body)
+		}
+		return true
+	})
+}
+
+func (annset *AnnotatorSet) insertTestHook(ft *ast.FuncType, body *ast.BlockStmt) {
+	// single "*testing.T" parameter
+	params := ft.Params.List
+	if len(params) != 1 || len(params[0].Names) > 1 {
+		return
+	}
+	if !isTestingTType(params[0].Type) {
+		return
+	}
+	// parameter name (name it if unnamed)
+	param := params[0]
+	if len(param.Names) == 0 || param.Names[0].Name == "_" {
+		param.Names = []*ast.Ident{ast.NewIdent(annset.debugVarPrefix + "t")}
+	}
+	tname := param.Names[0].Name
+
+	sel := func(name string) *ast.SelectorExpr {
+		return &ast.SelectorExpr{
+			X:   ast.NewIdent(annset.debugPkgName),
+			Sel: ast.NewIdent(name),
+		}
+	}
+	nameCall := &ast.CallExpr{
+		Fun: &ast.SelectorExpr{X: ast.NewIdent(tname), Sel: ast.NewIdent("Name")},
+	}
+	startCall := &ast.CallExpr{Fun: sel("TestStart"), Args: []ast.Expr{nameCall}}
+	stmt := &ast.DeferStmt{
+		Call: &ast.CallExpr{Fun: sel("TestEnd"), Args: []ast.Expr{startCall}},
+	}
+
+	// insert as first stmt
+	body.List = append([]ast.Stmt{stmt}, body.List...)
+}
+
+func isTestFuncName(name string) bool {
+	if !strings.HasPrefix(name, "Test") || name == "TestMain" {
+		return false
+	}
+	// "Test" followed by a non-lowercase letter (same rule as "go test")
+	rest := name[len("Test"):]
+	if rest == "" {
+		return true
+	}
+	ru, _ := utf8.DecodeRuneInString(rest)
+	return !unicode.IsLower(ru)
+}
+
+func isTestingTType(e ast.Expr) bool {
+	star, ok := e.(*ast.StarExpr)
+	if !ok {
+		return false
+	}
+	se, ok := star.X.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	id, ok := se.X.(*ast.Ident)
+	return ok && id.Name == "testing" && se.Sel.Name == "T"
+}
+
+//----------
+
 func (annset *AnnotatorSet) insertImportDebug(astFile *ast.File) {
 	annset.insertImport(astFile, annset.debugPkgName, DebugPkgPath)
 }
@@ -170,6 +270,10 @@ func (annset *AnnotatorSet) ConfigContent(network, addr string) string {
 	if debug.SyncSend {
 		syncSendStr = "true"
 	}
+	goroutinesStr := "false"
+	if debug.Goroutines {
+		goroutinesStr = "true"
+	}
 
 	src := `package godebugconfig
 import "` + DebugPkgPath + `"
@@ -177,6 +281,8 @@ func init(){
 	debug.ServerNetwork = "` + network + `"
 	debug.ServerAddress = "` + addr + `"
 	debug.SyncSend = ` + syncSendStr + `
+	debug.Goroutines = ` + goroutinesStr + `
+	debug.ValueTreeDepth = ` + strconv.Itoa(debug.ValueTreeDepth) + `
 	debug.AnnotatorFilesData = []*debug.AnnotatorFileData{
 		` + entriesStr + `
 	}
@@ -13,6 +13,7 @@ import (
 	"go/ast"
 	"io"
 	"io/ioutil"
+	"net"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -28,6 +29,7 @@ import (
 type Cmd struct {
 	Client *Client
 	Dir    string
+	Stdin  io.Reader // dap mode without address
 	Stdout io.Writer
 	Stderr io.Writer
 
@@ -51,12 +53,18 @@ type Cmd struct {
 		serverCmd *osutil.Cmd // the annotated program
 	}
 
+	dap struct {
+		waitg sync.WaitGroup
+		err   error
+	}
+
 	flags struct {
 		mode struct {
 			run     bool
 			test    bool
 			build   bool
 			connect bool
+			dap     bool
 		}
 		verbose     bool
 		filenames   []string
@@ -68,6 +76,8 @@ type Cmd struct {
 		address     string   // build/connect
 		env         []string // build
 		syncSend    bool
+		goroutines  bool
+		valueTree   int
 		otherArgs   []string
 		testRunArgs []string
 	}
@@ -76,6 +86,7 @@ type Cmd struct {
 func NewCmd() *Cmd {
 	cmd := &Cmd{
 		annset: NewAnnotatorSet(),
+		Stdin:  os.Stdin,
 		Stdout: os.Stdout,
 		Stderr: os.Stderr,
 	}
@@ -106,6 +117,12 @@ func (cmd *Cmd) Start(ctx context.Context, args []string) (done bool, _ error) {
 	if err := cmd.parseArgs(args); err != nil {
 		return true, err
 	}
+	if cmd.flags.mode.dap {
+		if err := cmd.startDap(ctx); err != nil {
+			return true, err
+		}
+		return false, nil
+	}
 	if err := cmd.start2(ctx); err != nil {
 		return true, err
 	}
@@ -141,6 +158,8 @@ func (cmd *Cmd) start2(ctx context.Context) error {
 	m := &cmd.flags.mode
 	if m.run || m.test || m.build {
 		debug.SyncSend = cmd.flags.syncSend
+		debug.Goroutines = cmd.flags.goroutines
+		debug.ValueTreeDepth = cmd.flags.valueTree
 		if err := cmd.initAndAnnotate(ctx); err != nil {
 			return err
 		}
@@ -395,6 +414,10 @@ func (cmd *Cmd) startServerClient2(ctx context.Context) error {
 
 func (cmd *Cmd) Wait() error {
 	defer cmd.start.cancel() // ensure resources are cleared
+	if cmd.flags.mode.dap {
+		cmd.dap.waitg.Wait()
+		return cmd.dap.err
+	}
 	var err error
 	if cmd.start.serverCmd != nil { // might be nil: connect mode
 		err = cmd.start.serverCmd.Wait()
@@ -429,6 +452,69 @@ func (cmd *Cmd) RequestStart() error {
 
 //------------
 
+// Dap mode has no client; the debug adapter protocol clients launch the sessions.
+func (cmd *Cmd) DapMode() bool {
+	return cmd.flags.mode.dap
+}
+
+func (cmd *Cmd) startDap(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	cmd.start.cancel = cancel
+
+	// stdin/stdout
+	if cmd.flags.address == "" {
+		if cmd.Stdin == nil {
+			cancel()
+			return fmt.Errorf("dap: stdin not available, use -addr")
+		}
+		cmd.dap.waitg.Add(1)
+		go func() {
+			defer cmd.dap.waitg.Done()
+			ds := NewDapSession(cmd.Dir, cmd.Stdin, cmd.Stdout)
+			cmd.dap.err = ds.Run(ctx)
+		}()
+		return nil
+	}
+
+	ln, err := net.Listen("tcp", cmd.flags.address)
+	if err != nil {
+		cancel()
+		return err
+	}
+	cmd.Printf("dap: listening on %v\n", ln.Addr())
+
+	// ensure listener close on ctx cancel
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+
+	// serve one client at a time
+	cmd.dap.waitg.Add(1)
+	go func() {
+		defer cmd.dap.waitg.Done()
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				if ctx.Err() == nil {
+					cmd.dap.err = err
+				}
+				return
+			}
+			cmd.Printf("dap: client connected: %v\n", conn.RemoteAddr())
+			ds := NewDapSession(cmd.Dir, conn, conn)
+			if err := ds.Run(ctx); err != nil {
+				cmd.Error(err)
+			}
+			conn.Close()
+			cmd.Printf("dap: client disconnected\n")
+		}
+	}()
+	return nil
+}
+
+//------------
+
 func (cmd *Cmd) tmpDirBasedFilename(filename string) string {
 	// remove volume name
 	v := filepath.VolumeName(filename)
@@ -760,6 +846,9 @@ func (cmd *Cmd) parseArgs(args []string) error {
 		case "connect":
 			cmd.flags.mode.connect = true
 			return cmd.parseConnectArgs(name, args[1:])
+		case "dap":
+			cmd.flags.mode.dap = true
+			return cmd.parseDapArgs(name, args[1:])
 		}
 	}
 	fmt.Fprint(cmd.Stderr, cmdUsage())
@@ -775,6 +864,8 @@ func (cmd *Cmd) parseRunArgs(name string, args []string) error {
 	cmd.verboseFlag(f)
 	cmd.toolExecFlag(f)
 	cmd.syncSendFlag(f)
+	cmd.goroutinesFlag(f)
+	cmd.valueTreeFlag(f)
 	cmd.envFlag(f)
 
 	if err := f.Parse(args); err != nil {
@@ -795,6 +886,8 @@ func (cmd *Cmd) parseTestArgs(name string, args []string) error {
 	cmd.verboseFlag(f)
 	cmd.toolExecFlag(f)
 	cmd.syncSendFlag(f)
+	cmd.goroutinesFlag(f)
+	cmd.valueTreeFlag(f)
 	cmd.envFlag(f)
 	run := f.String("run", "", "run test")
 	verboseTests := f.Bool("v", false, "verbose tests")
@@ -827,6 +920,8 @@ func (cmd *Cmd) parseBuildArgs(name string, args []string) error {
 	cmd.workFlag(f)
 	cmd.verboseFlag(f)
 	cmd.syncSendFlag(f)
+	cmd.goroutinesFlag(f)
+	cmd.valueTreeFlag(f)
 	cmd.envFlag(f)
 	addr := f.String("addr", "", "address to serve from, built into the binary")
 	f.StringVar(&cmd.flags.output, "o", "", "output filename (default: ${filename}_godebug")
@@ -856,6 +951,20 @@ func (cmd *Cmd) parseConnectArgs(name string, args []string) error {
 	return nil
 }
 
+func (cmd *Cmd) parseDapArgs(name string, args []string) error {
+	f := flag.NewFlagSet(name, flag.ContinueOnError)
+	f.SetOutput(cmd.Stderr)
+	addr := f.String("addr", "", "tcp address to serve the debug adapter protocol from (default: stdin/stdout)")
+
+	if err := f.Parse(args); err != nil {
+		return err
+	}
+
+	cmd.flags.address = *addr
+
+	return nil
+}
+
 //------------
 
 func (cmd *Cmd) filenamesAndOtherArgs(fs *flag.FlagSet) {
@@ -888,6 +997,12 @@ func (cmd *Cmd) verboseFlag(fs *flag.FlagSet) {
 func (cmd *Cmd) syncSendFlag(fs *flag.FlagSet) {
 	fs.BoolVar(&cmd.flags.syncSend, "syncsend", false, "Don't send msgs in chunks (slow). Useful to get msgs before a crash.")
 }
+func (cmd *Cmd) goroutinesFlag(fs *flag.FlagSet) {
+	fs.BoolVar(&cmd.flags.goroutines, "goroutines", false, "tag msgs with the goroutine id (slower). Shown in the watch row and used as threads in dap mode.")
+}
+func (cmd *Cmd) valueTreeFlag(fs *flag.FlagSet) {
+	fs.IntVar(&cmd.flags.valueTree, "valuetree", 0, "max depth of the structured value tree sent with the values (0=off). Allows to expand structs, maps and slices in the inspector (ctrl+alt+buttonRight on an annotation).")
+}
 func (cmd *Cmd) toolExecFlag(fs *flag.FlagSet) {
 	fs.StringVar(&cmd.flags.toolExec, "toolexec", "", "execute cmd, useful to run a tool with the output file (ex: wine outputfilename)")
 }
@@ -974,6 +1089,7 @@ The commands are:
 	test		test packages compiled with godebug data
 	build 	build binary with godebug data (allows remote debug)
 	connect	connect to a binary built with godebug data (allows remote debug)
+	dap		serve the debug adapter protocol (the client launches run/test/connect sessions)
 Env variables:
 	GODEBUG_BUILD_FLAGS	comma separated flags for build
 Examples:
@@ -986,7 +1102,9 @@ Examples:
 	GoDebug test -run mytest
 	GoDebug build -addr=:8080 main.go
 	GoDebug connect -addr=:8080
+	GoDebug dap -addr=:9000
 	GoDebug run -env=GODEBUG_BUILD_FLAGS=-tags=xproto main.go
+	GoDebug run -valuetree=3 main.go
 `
 }
 
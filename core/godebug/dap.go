@@ -0,0 +1,173 @@
+package godebug
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Debug Adapter Protocol (DAP) messages and base protocol (header and json content).
+// https://microsoft.github.io/debug-adapter-protocol/specification
+
+type DapRequest struct {
+	Seq       int             `json:"seq"`
+	Type      string          `json:"type"`
+	Command   string          `json:"command"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+}
+
+type DapResponse struct {
+	Seq        int         `json:"seq"`
+	Type       string      `json:"type"`
+	RequestSeq int         `json:"request_seq"`
+	Success    bool        `json:"success"`
+	Command    string      `json:"command"`
+	Message    string      `json:"message,omitempty"`
+	Body       interface{} `json:"body,omitempty"`
+}
+
+type DapEvent struct {
+	Seq   int         `json:"seq"`
+	Type  string      `json:"type"`
+	Event string      `json:"event"`
+	Body  interface{} `json:"body,omitempty"`
+}
+
+//----------
+
+type DapSource struct {
+	Name string `json:"name,omitempty"`
+	Path string `json:"path,omitempty"`
+}
+
+type DapThread struct {
+	Id   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type DapStackFrame struct {
+	Id     int        `json:"id"`
+	Name   string     `json:"name"`
+	Source *DapSource `json:"source,omitempty"`
+	Line   int        `json:"line"`
+	Column int        `json:"column"`
+}
+
+type DapScope struct {
+	Name               string `json:"name"`
+	VariablesReference int    `json:"variablesReference"`
+	Expensive          bool   `json:"expensive"`
+}
+
+type DapVariable struct {
+	Name               string `json:"name"`
+	Value              string `json:"value"`
+	Type               string `json:"type,omitempty"`
+	VariablesReference int    `json:"variablesReference"`
+}
+
+type DapBreakpoint struct {
+	Verified bool `json:"verified"`
+	Line     int  `json:"line,omitempty"`
+}
+
+//----------
+
+// Reads and writes DAP messages. Writes are safe to be called concurrently.
+type DapConn struct {
+	rd  *bufio.Reader
+	w   io.Writer
+	wmu sync.Mutex
+	seq int
+}
+
+func NewDapConn(r io.Reader, w io.Writer) *DapConn {
+	return &DapConn{rd: bufio.NewReader(r), w: w}
+}
+
+// Reads the next message content (json).
+func (dc *DapConn) Read() ([]byte, error) {
+	n := -1
+	for {
+		line, err := dc.rd.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break // end of header
+		}
+		k := strings.Index(line, ":")
+		if k < 0 {
+			return nil, fmt.Errorf("dap: bad header line: %q", line)
+		}
+		name := strings.TrimSpace(line[:k])
+		if strings.EqualFold(name, "Content-Length") {
+			v, err := strconv.Atoi(strings.TrimSpace(line[k+1:]))
+			if err != nil {
+				return nil, fmt.Errorf("dap: content length: %w", err)
+			}
+			n = v
+		}
+	}
+	if n < 0 {
+		return nil, fmt.Errorf("dap: missing content length")
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(dc.rd, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (dc *DapConn) ReadRequest() (*DapRequest, error) {
+	b, err := dc.Read()
+	if err != nil {
+		return nil, err
+	}
+	req := &DapRequest{}
+	if err := json.Unmarshal(b, req); err != nil {
+		return nil, fmt.Errorf("dap: %w", err)
+	}
+	return req, nil
+}
+
+//----------
+
+// Sets the message sequence number (if the message is a response/event/request) and writes it.
+func (dc *DapConn) Write(msg interface{}) error {
+	dc.wmu.Lock()
+	defer dc.wmu.Unlock()
+
+	dc.seq++
+	switch t := msg.(type) {
+	case *DapRequest:
+		t.Seq = dc.seq
+		t.Type = "request"
+	case *DapResponse:
+		t.Seq = dc.seq
+		t.Type = "response"
+	case *DapEvent:
+		t.Seq = dc.seq
+		t.Type = "event"
+	}
+
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	h := fmt.Sprintf("Content-Length: %d\r\n\r\n", len(b))
+	if _, err := io.WriteString(dc.w, h); err != nil {
+		return err
+	}
+	_, err = dc.w.Write(b)
+	return err
+}
+
+func (dc *DapConn) WriteEvent(event string, body interface{}) error {
+	return dc.Write(&DapEvent{Event: event, Body: body})
+}
@@ -0,0 +1,353 @@
+package godebug
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jmigpin/editor/core/godebug/debug"
+)
+
+func TestDapSession1(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "godebug_dap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	filename := filepath.Join(tmpDir, "main.go")
+	src := "a:=1\nb:=2\nc:=3\nd:=4\n"
+	if err := ioutil.WriteFile(filename, []byte(src), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tree := &debug.ValueNode{Type: "[]int", Value: "len=1 cap=1", Children: []*debug.ValueNode{
+		{Name: "[0]", Type: "int", Value: "7"},
+	}}
+	launch := func(ctx context.Context, dir string, args []string, stdout, stderr io.Writer, msgs chan<- interface{}) error {
+		afd := &debug.AnnotatorFileData{FileIndex: 0, DebugLen: 4, Filename: filename}
+		msgs <- &debug.FilesDataMsg{Data: []*debug.AnnotatorFileData{afd}}
+		msgs <- []*debug.LineMsg{
+			{FileIndex: 0, DebugIndex: 0, Offset: 0, GoroutineId: 1, Item: &debug.ItemValue{Str: "1"}},
+			{FileIndex: 0, DebugIndex: 1, Offset: 5, GoroutineId: 2, Item: &debug.ItemValue{Str: "2"}},
+			{FileIndex: 0, DebugIndex: 2, Offset: 10, GoroutineId: 1, Item: &debug.ItemValue{Str: "[7]", Tree: tree}},
+			{FileIndex: 0, DebugIndex: 3, Offset: 15, GoroutineId: 1, Item: &debug.ItemValue{Str: "4"}},
+		}
+		return nil
+	}
+
+	dc := newTestDapClient(t, tmpDir, launch)
+	defer dc.cancel()
+
+	dc.request("initialize", nil)
+	dc.waitEvent("initialized")
+
+	bpArgs := map[string]interface{}{
+		"source":      map[string]string{"path": filename},
+		"breakpoints": []map[string]int{{"line": 3}},
+	}
+	dc.request("setBreakpoints", bpArgs)
+	dc.request("configurationDone", nil)
+
+	dc.request("launch", map[string]interface{}{"args": []string{"run", "main.go"}})
+	dc.waitStopped("entry", 1)
+
+	// threads
+	var threads struct{ Threads []*DapThread }
+	dc.requestBody("threads", nil, &threads)
+	if len(threads.Threads) != 2 || threads.Threads[0].Id != 1 || threads.Threads[1].Id != 2 {
+		t.Fatalf("%+v", threads)
+	}
+
+	dc.mustBeAtLine(1, 1)
+
+	// step forward in thread 1 skips thread 2
+	dc.request("next", map[string]int{"threadId": 1})
+	dc.waitStopped("step", 1)
+	frameId := dc.mustBeAtLine(1, 3)
+
+	// variables: annotation + 1 value with one child
+	var scopes struct{ Scopes []*DapScope }
+	dc.requestBody("scopes", map[string]int{"frameId": frameId}, &scopes)
+	vars := dc.variables(scopes.Scopes[0].VariablesReference)
+	if len(vars) != 2 || vars[0].Name != "annotation" || vars[1].Value != "len=1 cap=1" {
+		t.Fatalf("%+v", vars)
+	}
+	vars2 := dc.variables(vars[1].VariablesReference)
+	if len(vars2) != 1 || vars2[0].Name != "[0]" || vars2[0].Value != "7" {
+		t.Fatalf("%+v", vars2)
+	}
+
+	dc.request("stepBack", map[string]int{"threadId": 1})
+	dc.waitStopped("step", 1)
+	dc.mustBeAtLine(1, 1)
+
+	// continue to the breakpoint
+	dc.request("continue", map[string]int{"threadId": 1})
+	dc.waitStopped("breakpoint", 1)
+	dc.mustBeAtLine(1, 3)
+
+	// no more breakpoints, goes to the last step
+	dc.request("continue", map[string]int{"threadId": 1})
+	dc.waitStopped("pause", 1)
+	dc.mustBeAtLine(1, 4)
+
+	dc.request("disconnect", nil)
+	dc.waitEvent("terminated")
+	dc.waitDone()
+}
+
+func TestDapStackTrace(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "godebug_dap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	filename := filepath.Join(tmpDir, "main.go")
+	src := "f()\nx:=1\ng()\ny:=2\n"
+	if err := ioutil.WriteFile(filename, []byte(src), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// f() calls g()
+	launch := func(ctx context.Context, dir string, args []string, stdout, stderr io.Writer, msgs chan<- interface{}) error {
+		afd := &debug.AnnotatorFileData{FileIndex: 0, DebugLen: 4, Filename: filename}
+		msgs <- &debug.FilesDataMsg{Data: []*debug.AnnotatorFileData{afd}}
+		msgs <- []*debug.LineMsg{
+			{FileIndex: 0, DebugIndex: 0, Offset: 0, GoroutineId: 1, Item: debug.ICe("f")},
+			{FileIndex: 0, DebugIndex: 1, Offset: 4, GoroutineId: 1, Item: debug.IVs("1")},
+			{FileIndex: 0, DebugIndex: 2, Offset: 9, GoroutineId: 1, Item: debug.ICe("g")},
+			{FileIndex: 0, DebugIndex: 3, Offset: 13, GoroutineId: 1, Item: debug.IVs("2")},
+			{FileIndex: 0, DebugIndex: 2, Offset: 9, GoroutineId: 1, Item: debug.IC("g", nil)},
+			{FileIndex: 0, DebugIndex: 0, Offset: 0, GoroutineId: 1, Item: debug.IC("f", nil)},
+		}
+		return nil
+	}
+
+	dc := newTestDapClient(t, tmpDir, launch)
+	defer dc.cancel()
+
+	dc.request("initialize", nil)
+	dc.waitEvent("initialized")
+	dc.request("launch", map[string]interface{}{"args": []string{"run", "main.go"}})
+	dc.waitStopped("entry", 1)
+
+	for i, lines := range [][]int{
+		{1},
+		{2, 1},
+		{3, 1},
+		{4, 3, 1},
+		{3, 1}, // g returned
+		{1},    // f returned
+	} {
+		if i > 0 {
+			dc.request("next", map[string]int{"threadId": 1})
+			dc.waitStopped("step", 1)
+		}
+		dc.mustHaveStackLines(1, lines...)
+	}
+
+	dc.request("disconnect", nil)
+	dc.waitEvent("terminated")
+	dc.waitDone()
+}
+
+func TestDapUnsupported(t *testing.T) {
+	dc := newTestDapClient(t, "", nil)
+	defer dc.cancel()
+	m := dc.request2("evaluate", nil)
+	if m.Success || m.Message == "" {
+		t.Fatalf("%+v", m)
+	}
+	m = dc.request2("launch", map[string]interface{}{"args": []string{"build"}})
+	if m.Success {
+		t.Fatalf("%+v", m)
+	}
+}
+
+//----------
+
+type testDapMsg struct {
+	Seq     int
+	Type    string
+	Command string
+	Event   string
+	Success bool
+	Message string
+	Body    json.RawMessage
+}
+
+type testDapClient struct {
+	t    *testing.T
+	dc   *DapConn
+	msgs chan *testDapMsg
+	done chan error
+
+	cancel context.CancelFunc
+}
+
+func newTestDapClient(t *testing.T, dir string, launch DapLaunchFn) *testDapClient {
+	t.Helper()
+	cr, sw := io.Pipe() // client reads, server writes
+	sr, cw := io.Pipe() // server reads, client writes
+
+	ds := NewDapSession(dir, sr, sw)
+	if launch != nil {
+		ds.Launch = launch
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	tc := &testDapClient{t: t, dc: NewDapConn(cr, cw), cancel: cancel}
+	tc.done = make(chan error, 1)
+	go func() {
+		tc.done <- ds.Run(ctx)
+		sw.Close()
+	}()
+
+	tc.msgs = make(chan *testDapMsg, 100)
+	go func() {
+		defer close(tc.msgs)
+		for {
+			b, err := tc.dc.Read()
+			if err != nil {
+				return
+			}
+			m := &testDapMsg{}
+			if err := json.Unmarshal(b, m); err != nil {
+				t.Error(err)
+				return
+			}
+			tc.msgs <- m
+		}
+	}()
+	return tc
+}
+
+func (tc *testDapClient) next() *testDapMsg {
+	tc.t.Helper()
+	select {
+	case m, ok := <-tc.msgs:
+		if !ok {
+			tc.t.Fatal("connection closed")
+		}
+		return m
+	case <-time.After(5 * time.Second):
+		tc.t.Fatal("timeout")
+	}
+	return nil
+}
+
+func (tc *testDapClient) request2(cmd string, args interface{}) *testDapMsg {
+	tc.t.Helper()
+	req := &DapRequest{Command: cmd}
+	if args != nil {
+		b, err := json.Marshal(args)
+		if err != nil {
+			tc.t.Fatal(err)
+		}
+		req.Arguments = b
+	}
+	if err := tc.dc.Write(req); err != nil {
+		tc.t.Fatal(err)
+	}
+	for {
+		m := tc.next()
+		if m.Type == "response" && m.Command == cmd {
+			return m
+		}
+	}
+}
+
+func (tc *testDapClient) request(cmd string, args interface{}) *testDapMsg {
+	tc.t.Helper()
+	m := tc.request2(cmd, args)
+	if !m.Success {
+		tc.t.Fatalf("%v: %v", cmd, m.Message)
+	}
+	return m
+}
+
+func (tc *testDapClient) requestBody(cmd string, args interface{}, body interface{}) {
+	tc.t.Helper()
+	m := tc.request(cmd, args)
+	if err := json.Unmarshal(m.Body, body); err != nil {
+		tc.t.Fatal(err)
+	}
+}
+
+func (tc *testDapClient) waitEvent(event string) *testDapMsg {
+	tc.t.Helper()
+	for {
+		m := tc.next()
+		if m.Type == "event" && m.Event == event {
+			return m
+		}
+	}
+}
+
+func (tc *testDapClient) waitStopped(reason string, threadId int) {
+	tc.t.Helper()
+	m := tc.waitEvent("stopped")
+	body := struct {
+		Reason   string
+		ThreadId int
+	}{}
+	if err := json.Unmarshal(m.Body, &body); err != nil {
+		tc.t.Fatal(err)
+	}
+	if body.Reason != reason || body.ThreadId != threadId {
+		tc.t.Fatalf("stopped: %+v", body)
+	}
+}
+
+func (tc *testDapClient) mustBeAtLine(threadId, line int) int {
+	tc.t.Helper()
+	frames := tc.stackFrames(threadId)
+	if len(frames) != 1 || frames[0].Line != line {
+		tc.t.Fatalf("expecting line %v: %+v", line, frames)
+	}
+	return frames[0].Id
+}
+
+func (tc *testDapClient) mustHaveStackLines(threadId int, lines ...int) {
+	tc.t.Helper()
+	frames := tc.stackFrames(threadId)
+	lines2 := []int{}
+	for _, f := range frames {
+		lines2 = append(lines2, f.Line)
+	}
+	if fmt.Sprint(lines2) != fmt.Sprint(lines) {
+		tc.t.Fatalf("expecting lines %v: %v", lines, lines2)
+	}
+}
+
+func (tc *testDapClient) stackFrames(threadId int) []*DapStackFrame {
+	tc.t.Helper()
+	var st struct{ StackFrames []*DapStackFrame }
+	tc.requestBody("stackTrace", map[string]int{"threadId": threadId}, &st)
+	return st.StackFrames
+}
+
+func (tc *testDapClient) variables(ref int) []*DapVariable {
+	tc.t.Helper()
+	var vars struct{ Variables []*DapVariable }
+	tc.requestBody("variables", map[string]int{"variablesReference": ref}, &vars)
+	return vars.Variables
+}
+
+func (tc *testDapClient) waitDone() {
+	tc.t.Helper()
+	select {
+	case err := <-tc.done:
+		if err != nil {
+			tc.t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		tc.t.Fatal("timeout")
+	}
+}
@@ -0,0 +1,602 @@
+package godebug
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/jmigpin/editor/core/godebug/debug"
+)
+
+// Launches a godebug session (ex: args="run main.go") and sends the received msgs (*debug.FilesDataMsg, *debug.LineMsg, ...) to the channel. Should not close the channel.
+type DapLaunchFn func(ctx context.Context, dir string, args []string, stdout, stderr io.Writer, msgs chan<- interface{}) error
+
+//----------
+
+// Serves a DAP client with the recorded godebug msgs history. The program runs to the end while the msgs are recorded, and the client can step forward/backward in the history (time travel).
+type DapSession struct {
+	Dir    string      // default launch dir
+	Launch DapLaunchFn // defaults to running a godebug cmd
+
+	dc *DapConn
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu          sync.Mutex
+	afds        []*debug.AnnotatorFileData
+	msgs        []*debug.LineMsg // [arrivalIndex]
+	pos         int              // current step (arrival index), -1 if none
+	goroutines  map[int]bool
+	breakpoints map[string]map[int]bool // [filename][line]
+	lines       map[int][]int           // [fileIndex] lines start offsets
+	varRefs     []interface{}           // [variablesReference-1], valid while stopped
+}
+
+func NewDapSession(dir string, r io.Reader, w io.Writer) *DapSession {
+	ds := &DapSession{Dir: dir, Launch: dapCmdLaunch}
+	ds.dc = NewDapConn(r, w)
+	ds.pos = -1
+	ds.goroutines = map[int]bool{}
+	ds.breakpoints = map[string]map[int]bool{}
+	ds.lines = map[int][]int{}
+	return ds
+}
+
+//----------
+
+// Handles requests until a "disconnect" request or the reader ends.
+func (ds *DapSession) Run(ctx context.Context) error {
+	ds.ctx, ds.cancel = context.WithCancel(ctx)
+	defer ds.cancel()
+	for {
+		req, err := ds.dc.ReadRequest()
+		if err != nil {
+			if err == io.EOF || ds.ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		if err := ds.handleRequest(req); err != nil {
+			return err
+		}
+		if req.Command == "disconnect" {
+			return nil
+		}
+	}
+}
+
+//----------
+
+func (ds *DapSession) handleRequest(req *DapRequest) error {
+	body, stopReason, err := ds.handleRequest2(req)
+	res := &DapResponse{RequestSeq: req.Seq, Command: req.Command}
+	if err != nil {
+		res.Message = err.Error()
+	} else {
+		res.Success = true
+		res.Body = body
+	}
+	if err := ds.dc.Write(res); err != nil {
+		return err
+	}
+
+	// events after the response
+	if err == nil {
+		switch req.Command {
+		case "initialize":
+			return ds.dc.WriteEvent("initialized", nil)
+		case "disconnect", "terminate":
+			return ds.dc.WriteEvent("terminated", nil)
+		}
+		if stopReason != "" {
+			return ds.writeStopped(stopReason)
+		}
+	}
+	return nil
+}
+
+func (ds *DapSession) handleRequest2(req *DapRequest) (body interface{}, stopReason string, _ error) {
+	switch req.Command {
+	case "initialize":
+		caps := map[string]bool{
+			"supportsConfigurationDoneRequest": true,
+			"supportsStepBack":                 true,
+			"supportsTerminateRequest":         true,
+		}
+		return caps, "", nil
+	case "configurationDone":
+		return nil, "", nil
+	case "launch":
+		return nil, "", ds.launch(req)
+	case "disconnect", "terminate":
+		ds.cancel()
+		return nil, "", nil
+	case "setBreakpoints":
+		body, err := ds.setBreakpoints(req)
+		return body, "", err
+	case "threads":
+		return ds.threads(), "", nil
+	case "stackTrace":
+		body, err := ds.stackTrace(req)
+		return body, "", err
+	case "scopes":
+		body, err := ds.scopes(req)
+		return body, "", err
+	case "variables":
+		body, err := ds.variables(req)
+		return body, "", err
+	case "next", "stepIn", "stepOut":
+		return nil, "step", ds.step(req, true)
+	case "stepBack":
+		return nil, "step", ds.step(req, false)
+	case "continue":
+		reason := ds.cont(true)
+		return map[string]bool{"allThreadsContinued": true}, reason, nil
+	case "reverseContinue":
+		return nil, ds.cont(false), nil
+	case "pause":
+		return nil, "pause", nil
+	default:
+		return nil, "", fmt.Errorf("unsupported command: %v", req.Command)
+	}
+}
+
+//----------
+
+func (ds *DapSession) launch(req *DapRequest) error {
+	args := struct {
+		Args []string `json:"args"`
+		Cwd  string   `json:"cwd"`
+	}{}
+	if err := json.Unmarshal(req.Arguments, &args); err != nil {
+		return err
+	}
+	if len(args.Args) == 0 {
+		return fmt.Errorf("missing args")
+	}
+	switch args.Args[0] {
+	case "run", "test", "connect":
+	default:
+		return fmt.Errorf("unsupported launch mode: %v", args.Args[0])
+	}
+	dir := args.Cwd
+	if dir == "" {
+		dir = ds.Dir
+	}
+
+	stdout := &dapOutputWriter{ds.dc, "stdout"}
+	stderr := &dapOutputWriter{ds.dc, "stderr"}
+	msgs := make(chan interface{}, 128)
+	var launchErr error
+	go func() {
+		defer close(msgs)
+		launchErr = ds.Launch(ds.ctx, dir, args.Args, stdout, stderr, msgs)
+	}()
+	go func() {
+		for msg := range msgs {
+			ds.handleMsg(msg)
+		}
+		exitCode := 0
+		if launchErr != nil {
+			exitCode = 1
+			fmt.Fprintf(stderr, "error: %v\n", launchErr)
+		}
+		_ = ds.dc.WriteEvent("exited", map[string]int{"exitCode": exitCode})
+	}()
+	return nil
+}
+
+func (ds *DapSession) handleMsg(msg interface{}) {
+	switch t := msg.(type) {
+	case *debug.FilesDataMsg:
+		ds.mu.Lock()
+		ds.afds = t.Data
+		ds.mu.Unlock()
+	case *debug.LineMsg:
+		ds.addLineMsgs(t)
+	case []*debug.LineMsg:
+		ds.addLineMsgs(t...)
+	case error:
+		_ = ds.writeOutput("stderr", fmt.Sprintf("error: %v\n", t))
+	default:
+		_ = ds.writeOutput("stderr", fmt.Sprintf("unexpected msg: %T\n", msg))
+	}
+}
+
+func (ds *DapSession) addLineMsgs(msgs ...*debug.LineMsg) {
+	ds.mu.Lock()
+	first := ds.pos < 0 && len(msgs) > 0
+	for _, m := range msgs {
+		ds.msgs = append(ds.msgs, m)
+		ds.goroutines[m.GoroutineId] = true
+	}
+	if first {
+		ds.pos = 0
+	}
+	ds.mu.Unlock()
+
+	if first {
+		_ = ds.writeStopped("entry")
+	}
+}
+
+//----------
+
+func (ds *DapSession) setBreakpoints(req *DapRequest) (interface{}, error) {
+	args := struct {
+		Source      DapSource `json:"source"`
+		Breakpoints []struct {
+			Line int `json:"line"`
+		} `json:"breakpoints"`
+	}{}
+	if err := json.Unmarshal(req.Arguments, &args); err != nil {
+		return nil, err
+	}
+
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	m := map[int]bool{}
+	bps := []*DapBreakpoint{}
+	for _, bp := range args.Breakpoints {
+		m[bp.Line] = true
+		bps = append(bps, &DapBreakpoint{Verified: true, Line: bp.Line})
+	}
+	ds.breakpoints[args.Source.Path] = m
+	return map[string]interface{}{"breakpoints": bps}, nil
+}
+
+func (ds *DapSession) threads() interface{} {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	ids := []int{}
+	for id := range ds.goroutines {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	threads := []*DapThread{}
+	for _, id := range ids {
+		t := &DapThread{Id: id, Name: fmt.Sprintf("goroutine %v", id)}
+		threads = append(threads, t)
+	}
+	return map[string]interface{}{"threads": threads}
+}
+
+func (ds *DapSession) stackTrace(req *DapRequest) (interface{}, error) {
+	args := struct {
+		ThreadId int `json:"threadId"`
+	}{}
+	if err := json.Unmarshal(req.Arguments, &args); err != nil {
+		return nil, err
+	}
+
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	frames := []*DapStackFrame{}
+	for _, k := range ds.callChain(args.ThreadId) {
+		m := ds.msgs[k]
+		f := &DapStackFrame{Id: k + 1}
+		f.Name = fmt.Sprintf("#%v %v", k, StringifyItem(m.Item))
+		if afd, ok := ds.afd(m.FileIndex); ok {
+			f.Source = &DapSource{Name: filepath.Base(afd.Filename), Path: afd.Filename}
+			f.Line, f.Column = ds.lineColumn(m)
+		}
+		frames = append(frames, f)
+	}
+	body := map[string]interface{}{
+		"stackFrames": frames,
+		"totalFrames": len(frames),
+	}
+	return body, nil
+}
+
+// Returns the msgs indexes of the thread call chain (innermost first): the last step of the thread up to the current position, followed by the steps entering the calls not yet returned.
+// The steps don't record the calls returning: a call is assumed to have returned when a later step comes from the statement that made it. Recursive calls from the same statement are then shown as one frame.
+func (ds *DapSession) callChain(threadId int) []int {
+	type key struct{ fileIndex, debugIndex int }
+	stack := []int{} // call enter msgs
+	popTo := func(m *debug.LineMsg) {
+		k := key{m.FileIndex, m.DebugIndex}
+		for i := len(stack) - 1; i >= 0; i-- {
+			m2 := ds.msgs[stack[i]]
+			if (key{m2.FileIndex, m2.DebugIndex}) == k {
+				stack = stack[:i]
+				return
+			}
+		}
+	}
+
+	cur := -1
+	for k := 0; k <= ds.pos && k < len(ds.msgs); k++ {
+		m := ds.msgs[k]
+		if m.GoroutineId != threadId {
+			continue
+		}
+		if cur >= 0 {
+			if _, ok := ds.msgs[cur].Item.(*debug.ItemCallEnter); ok {
+				stack = append(stack, cur)
+			}
+		}
+		popTo(m)
+		cur = k
+	}
+	if cur < 0 {
+		return nil
+	}
+	chain := []int{cur}
+	for i := len(stack) - 1; i >= 0; i-- {
+		chain = append(chain, stack[i])
+	}
+	return chain
+}
+
+func (ds *DapSession) scopes(req *DapRequest) (interface{}, error) {
+	args := struct {
+		FrameId int `json:"frameId"`
+	}{}
+	if err := json.Unmarshal(req.Arguments, &args); err != nil {
+		return nil, err
+	}
+
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	k := args.FrameId - 1
+	if k < 0 || k >= len(ds.msgs) {
+		return nil, fmt.Errorf("bad frame id: %v", args.FrameId)
+	}
+	ref := ds.newVarRef(ds.msgs[k])
+	scopes := []*DapScope{{Name: "Values", VariablesReference: ref}}
+	return map[string]interface{}{"scopes": scopes}, nil
+}
+
+func (ds *DapSession) variables(req *DapRequest) (interface{}, error) {
+	args := struct {
+		VariablesReference int `json:"variablesReference"`
+	}{}
+	if err := json.Unmarshal(req.Arguments, &args); err != nil {
+		return nil, err
+	}
+
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	k := args.VariablesReference - 1
+	if k < 0 || k >= len(ds.varRefs) {
+		return nil, fmt.Errorf("bad variables reference: %v", args.VariablesReference)
+	}
+	vars := []*DapVariable{}
+	switch t := ds.varRefs[k].(type) {
+	case *debug.LineMsg:
+		v := &DapVariable{Name: "annotation", Value: StringifyItemFull(t.Item)}
+		vars = append(vars, v)
+		for i, iv := range ItemValues(t.Item) {
+			n := iv.Tree
+			if n == nil {
+				n = &debug.ValueNode{Value: iv.Str}
+			}
+			v := ds.nodeVariable(n)
+			v.Name = fmt.Sprintf("#%v", i)
+			vars = append(vars, v)
+		}
+	case *debug.ValueNode:
+		for _, c := range t.Children {
+			vars = append(vars, ds.nodeVariable(c))
+		}
+	}
+	return map[string]interface{}{"variables": vars}, nil
+}
+
+func (ds *DapSession) nodeVariable(n *debug.ValueNode) *DapVariable {
+	v := &DapVariable{Name: n.Name, Value: n.Value, Type: n.Type}
+	if n.Cycle {
+		v.Value += " (cycle)"
+	}
+	if len(n.Children) > 0 {
+		v.VariablesReference = ds.newVarRef(n)
+	}
+	return v
+}
+
+func (ds *DapSession) newVarRef(v interface{}) int {
+	ds.varRefs = append(ds.varRefs, v)
+	return len(ds.varRefs)
+}
+
+//----------
+
+// Moves to the next/previous step of the same thread (goroutine).
+func (ds *DapSession) step(req *DapRequest, forward bool) error {
+	args := struct {
+		ThreadId int `json:"threadId"`
+	}{}
+	if err := json.Unmarshal(req.Arguments, &args); err != nil {
+		return err
+	}
+
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	if ds.pos < 0 {
+		return fmt.Errorf("no steps recorded yet")
+	}
+	ds.pos = ds.findStep(forward, func(m *debug.LineMsg) bool {
+		return m.GoroutineId == args.ThreadId
+	})
+	return nil
+}
+
+// Moves to the next/previous breakpoint, or to the last/first step if there is no breakpoint.
+func (ds *DapSession) cont(forward bool) (stopReason string) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	if ds.pos < 0 {
+		return "pause"
+	}
+	k := ds.findStep(forward, ds.isBreakpoint)
+	if k != ds.pos {
+		ds.pos = k
+		return "breakpoint"
+	}
+	if forward {
+		ds.pos = len(ds.msgs) - 1
+	} else {
+		ds.pos = 0
+	}
+	return "pause"
+}
+
+// Returns the current position if not found.
+func (ds *DapSession) findStep(forward bool, fn func(*debug.LineMsg) bool) int {
+	if forward {
+		for k := ds.pos + 1; k < len(ds.msgs); k++ {
+			if fn(ds.msgs[k]) {
+				return k
+			}
+		}
+	} else {
+		for k := ds.pos - 1; k >= 0; k-- {
+			if fn(ds.msgs[k]) {
+				return k
+			}
+		}
+	}
+	return ds.pos
+}
+
+func (ds *DapSession) isBreakpoint(m *debug.LineMsg) bool {
+	afd, ok := ds.afd(m.FileIndex)
+	if !ok {
+		return false
+	}
+	bps, ok := ds.breakpoints[afd.Filename]
+	if !ok || len(bps) == 0 {
+		return false
+	}
+	line, _ := ds.lineColumn(m)
+	return bps[line]
+}
+
+//----------
+
+func (ds *DapSession) afd(fileIndex int) (*debug.AnnotatorFileData, bool) {
+	for _, afd := range ds.afds {
+		if afd.FileIndex == fileIndex {
+			return afd, true
+		}
+	}
+	return nil, false
+}
+
+// Returned line/column values are one-based (zero if unknown).
+func (ds *DapSession) lineColumn(m *debug.LineMsg) (int, int) {
+	starts, ok := ds.lines[m.FileIndex]
+	if !ok {
+		afd, ok := ds.afd(m.FileIndex)
+		if !ok {
+			return 0, 0
+		}
+		b, err := ioutil.ReadFile(afd.Filename)
+		if err != nil {
+			return 0, 0
+		}
+		starts = []int{0}
+		for i, c := range b {
+			if c == '\n' {
+				starts = append(starts, i+1)
+			}
+		}
+		ds.lines[m.FileIndex] = starts
+	}
+	// last line start <= offset
+	k := sort.SearchInts(starts, m.Offset+1) - 1
+	if k < 0 {
+		return 0, 0
+	}
+	return k + 1, m.Offset - starts[k] + 1
+}
+
+//----------
+
+func (ds *DapSession) writeStopped(reason string) error {
+	ds.mu.Lock()
+	ds.varRefs = nil // references are only valid while stopped
+	threadId := 0
+	if ds.pos >= 0 {
+		threadId = ds.msgs[ds.pos].GoroutineId
+	}
+	ds.mu.Unlock()
+
+	body := map[string]interface{}{
+		"reason":            reason,
+		"threadId":          threadId,
+		"allThreadsStopped": true,
+	}
+	return ds.dc.WriteEvent("stopped", body)
+}
+
+func (ds *DapSession) writeOutput(category, s string) error {
+	body := map[string]string{"category": category, "output": s}
+	return ds.dc.WriteEvent("output", body)
+}
+
+//----------
+
+type dapOutputWriter struct {
+	dc       *DapConn
+	category string
+}
+
+func (w *dapOutputWriter) Write(p []byte) (int, error) {
+	body := map[string]string{"category": w.category, "output": string(p)}
+	if err := w.dc.WriteEvent("output", body); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+//----------
+
+// Runs a godebug cmd with the given args.
+func dapCmdLaunch(ctx context.Context, dir string, args []string, stdout, stderr io.Writer, msgs chan<- interface{}) error {
+	cmd := NewCmd()
+	defer cmd.Cleanup()
+
+	cmd.Dir = dir
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	// goroutine ids are the dap threads
+	if args[0] == "run" || args[0] == "test" {
+		args = append([]string{args[0], "-goroutines"}, args[1:]...)
+	}
+
+	done, err := cmd.Start(ctx, args)
+	if err != nil {
+		return err
+	}
+	if done {
+		return nil
+	}
+
+	for msg := range cmd.Client.Messages {
+		switch t := msg.(type) {
+		case string:
+			if t == "connected" {
+				if err := cmd.RequestFileSetPositions(); err != nil {
+					return err
+				}
+			}
+		case *debug.FilesDataMsg:
+			msgs <- t
+			if err := cmd.RequestStart(); err != nil {
+				return err
+			}
+		default:
+			msgs <- t
+		}
+	}
+	return cmd.Wait()
+}
@@ -1,14 +1,21 @@
 package debug
 
 import (
+	"bytes"
 	"fmt"
 	"os"
+	"runtime"
+	"strconv"
 	"sync"
+	"sync/atomic"
 )
 
 var server *Server
 var startServerMu sync.Mutex
 
+// Tag the msgs with the goroutine id (populated by the generated config). Test msgs are always tagged while tests are running.
+var Goroutines bool
+
 // Called by the generated config.
 func StartServer() {
 	hotStartServer()
@@ -48,5 +55,73 @@ func ExitServer() {
 func Line(fileIndex, debugIndex, offset int, item Item) {
 	hotStartServer()
 	lmsg := &LineMsg{FileIndex: fileIndex, DebugIndex: debugIndex, Offset: offset, Item: item}
+	// the goroutine id is slow to get (stack trace): only if enabled or needed to know the test
+	if Goroutines || atomic.LoadInt32(&tests.running) > 0 {
+		gid := goroutineId()
+		lmsg.GoroutineId = gid
+		lmsg.TestName = testName(gid)
+	}
 	server.Send(lmsg)
 }
+
+//----------
+
+var tests struct {
+	sync.RWMutex
+	m       map[int][]string // [goroutineId]names (subtests run in new goroutines, but helpers with a *testing.T might not)
+	running int32            // len(m), atomic
+}
+
+// Auto-inserted at the start of test functions and t.Run functions. Returns the name to be used in TestEnd. Not to be used.
+func TestStart(name string) string {
+	tests.Lock()
+	defer tests.Unlock()
+	if tests.m == nil {
+		tests.m = map[int][]string{}
+	}
+	gid := goroutineId()
+	tests.m[gid] = append(tests.m[gid], name)
+	atomic.StoreInt32(&tests.running, int32(len(tests.m)))
+	return name
+}
+
+// Auto-inserted (deferred) at the start of test functions and t.Run functions. Not to be used.
+func TestEnd(name string) {
+	tests.Lock()
+	defer tests.Unlock()
+	gid := goroutineId()
+	if u := tests.m[gid]; len(u) > 1 {
+		tests.m[gid] = u[:len(u)-1]
+	} else {
+		delete(tests.m, gid)
+	}
+	atomic.StoreInt32(&tests.running, int32(len(tests.m)))
+}
+
+// Empty if the goroutine is not running a test (ex: goroutines started by tests are not tagged).
+func testName(goroutineId int) string {
+	tests.RLock()
+	defer tests.RUnlock()
+	if u := tests.m[goroutineId]; len(u) > 0 {
+		return u[len(u)-1]
+	}
+	return ""
+}
+
+//----------
+
+// Parses the goroutine id from the first line of the stack trace: "goroutine <id> [running]:". Returns -1 if not found.
+func goroutineId() int {
+	var buf [64]byte
+	n := runtime.Stack(buf[:], false)
+	b := bytes.TrimPrefix(buf[:n], []byte("goroutine "))
+	i := bytes.IndexByte(b, ' ')
+	if i < 0 {
+		return -1
+	}
+	v, err := strconv.Atoi(string(b[:i]))
+	if err != nil {
+		return -1
+	}
+	return v
+}
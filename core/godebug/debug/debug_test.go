@@ -0,0 +1,52 @@
+package debug
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestTestName(t *testing.T) {
+	if s := testName(goroutineId()); s != "" {
+		t.Fatal(s)
+	}
+	defer TestEnd(TestStart("TestA"))
+	if s := testName(goroutineId()); s != "TestA" {
+		t.Fatal(s)
+	}
+
+	// goroutine started by the test is unknown (not guessed)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if s := testName(goroutineId()); s != "" {
+			t.Error(s)
+		}
+	}()
+	wg.Wait()
+
+	// nested in the same goroutine (ex: helper with a *testing.T)
+	func() {
+		defer TestEnd(TestStart("TestA/sub"))
+		if s := testName(goroutineId()); s != "TestA/sub" {
+			t.Fatal(s)
+		}
+	}()
+	if s := testName(goroutineId()); s != "TestA" {
+		t.Fatal(s)
+	}
+
+	// other test running in parallel
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer TestEnd(TestStart("TestB"))
+		if s := testName(goroutineId()); s != "TestB" {
+			t.Error(s)
+		}
+	}()
+	wg.Wait()
+	if s := testName(goroutineId()); s != "TestA" {
+		t.Fatal(s)
+	}
+}
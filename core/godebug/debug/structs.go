@@ -46,10 +46,12 @@ type ReqStartMsg struct{}
 //----------
 
 type LineMsg struct {
-	FileIndex  int
-	DebugIndex int
-	Offset     int
-	Item       Item
+	FileIndex   int
+	DebugIndex  int
+	Offset      int
+	GoroutineId int
+	TestName    string // running test (empty if not in a test)
+	Item        Item
 }
 
 type FilesDataMsg struct {
@@ -69,7 +71,8 @@ type AnnotatorFileData struct {
 type Item interface {
 }
 type ItemValue struct {
-	Str string
+	Str  string
+	Tree *ValueNode // structured value (optional)
 }
 type ItemList struct { // separated by ","
 	List []Item
@@ -146,7 +149,7 @@ type V interface{}
 
 // ItemValue
 func IV(v V) Item {
-	return &ItemValue{Str: stringifyV(v)}
+	return &ItemValue{Str: stringifyV(v), Tree: valueTree(v)}
 }
 
 // ItemValue: raw string
@@ -0,0 +1,185 @@
+package debug
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Max depth of the value tree sent with the values (zero: no tree).
+var ValueTreeDepth int
+
+// Max children per value tree node.
+const valueTreeMaxEntries = 100
+
+// Max string length of a value tree node value.
+const valueTreeMaxStr = 80
+
+//----------
+
+type ValueNode struct {
+	Name     string // field name, map key, slice index (empty at root)
+	Type     string
+	Value    string // leaf value, or a short summary if it has children
+	Children []*ValueNode
+	Trunc    bool // children not included or incomplete (max depth/entries)
+	Cycle    bool // pointer target already present in the path
+}
+
+//----------
+
+// Returns nil if value trees are disabled or if the value has no structure (ex: int).
+func valueTree(v V) *ValueNode {
+	if ValueTreeDepth <= 0 || v == nil {
+		return nil
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array,
+		reflect.Ptr, reflect.Interface:
+	default:
+		return nil
+	}
+	vtb := newValueTreeBuilder(ValueTreeDepth)
+	return vtb.node("", rv, 0)
+}
+
+//----------
+
+type valueTreeBuilder struct {
+	maxDepth int
+	path     map[uintptr]bool // pointers being visited (cycle detection)
+}
+
+func newValueTreeBuilder(maxDepth int) *valueTreeBuilder {
+	return &valueTreeBuilder{maxDepth: maxDepth, path: map[uintptr]bool{}}
+}
+
+func (vtb *valueTreeBuilder) node(name string, v reflect.Value, depth int) *ValueNode {
+	n := &ValueNode{Name: name}
+	if !v.IsValid() {
+		n.Value = "nil"
+		return n
+	}
+	n.Type = v.Type().String()
+
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.IsNil() {
+			n.Value = "nil"
+			return n
+		}
+		u := vtb.node(name, v.Elem(), depth)
+		u.Type = n.Type + "(" + u.Type + ")"
+		return u
+	case reflect.Ptr:
+		if v.IsNil() {
+			n.Value = "nil"
+			return n
+		}
+		p := v.Pointer()
+		if vtb.path[p] {
+			n.Value = fmt.Sprintf("%#x", p)
+			n.Cycle = true
+			return n
+		}
+		n.Value = vtb.str(v)
+		if vtb.maxedOut(n, depth) {
+			return n
+		}
+		vtb.path[p] = true
+		defer delete(vtb.path, p)
+		n.Children = []*ValueNode{vtb.node("*", v.Elem(), depth+1)}
+	case reflect.Struct:
+		n.Value = vtb.str(v)
+		if vtb.maxedOut(n, depth) {
+			return n
+		}
+		vt := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			if vtb.maxedOutEntries(n, i) {
+				break
+			}
+			c := vtb.node(vt.Field(i).Name, v.Field(i), depth+1)
+			n.Children = append(n.Children, c)
+		}
+	case reflect.Map:
+		if v.IsNil() {
+			n.Value = "nil"
+			return n
+		}
+		n.Value = fmt.Sprintf("len=%v", v.Len())
+		if vtb.maxedOut(n, depth) {
+			return n
+		}
+		// sort keys for a stable output
+		type entry struct {
+			k string
+			v reflect.Value
+		}
+		entries := []*entry{}
+		iter := v.MapRange()
+		for iter.Next() {
+			e := &entry{vtb.str(iter.Key()), iter.Value()}
+			entries = append(entries, e)
+		}
+		sort.Slice(entries, func(a, b int) bool {
+			return entries[a].k < entries[b].k
+		})
+		for i, e := range entries {
+			if vtb.maxedOutEntries(n, i) {
+				break
+			}
+			c := vtb.node(e.k, e.v, depth+1)
+			n.Children = append(n.Children, c)
+		}
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice {
+			if v.IsNil() {
+				n.Value = "nil"
+				return n
+			}
+			n.Value = fmt.Sprintf("len=%v cap=%v", v.Len(), v.Cap())
+		} else {
+			n.Value = fmt.Sprintf("len=%v", v.Len())
+		}
+		if vtb.maxedOut(n, depth) {
+			return n
+		}
+		for i := 0; i < v.Len(); i++ {
+			if vtb.maxedOutEntries(n, i) {
+				break
+			}
+			c := vtb.node(fmt.Sprintf("[%v]", i), v.Index(i), depth+1)
+			n.Children = append(n.Children, c)
+		}
+	default:
+		n.Value = vtb.str(v)
+	}
+	return n
+}
+
+func (vtb *valueTreeBuilder) maxedOut(n *ValueNode, depth int) bool {
+	if depth >= vtb.maxDepth {
+		n.Trunc = true
+		return true
+	}
+	return false
+}
+
+func (vtb *valueTreeBuilder) maxedOutEntries(n *ValueNode, i int) bool {
+	if i >= valueTreeMaxEntries {
+		n.Trunc = true
+		return true
+	}
+	return false
+}
+
+// Works with unexported fields (doesn't call v.Interface()).
+func (vtb *valueTreeBuilder) str(v reflect.Value) string {
+	p := NewPrint(valueTreeMaxStr, 1)
+	ctx := &Ctx{}
+	ctx = ctx.WithInInterface(0)
+	p.doValue(ctx, v, 0)
+	return string(p.Out)
+}
@@ -0,0 +1,100 @@
+package debug
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestValueTreeStruct(t *testing.T) {
+	type St1 struct {
+		a int
+		B []string
+		C map[string]int
+	}
+	v := &St1{1, []string{"x"}, map[string]int{"k": 2}}
+	runValueTreeTest(t, v, 3, `
+		*debug.St1 = &St1{1 ["x"] map["k":2]}
+			* debug.St1 = {1 ["x"] map["k":2]}
+				a int = 1
+				B []string = len=1 cap=1
+					[0] string = "x"
+				C map[string]int = len=1
+					"k" int = 2`)
+}
+
+func TestValueTreeMap(t *testing.T) {
+	v := map[string]int{"k3": 3, "k2": 2, "k1": 1}
+	runValueTreeTest(t, v, 1, `
+		map[string]int = len=3
+			"k1" int = 1
+			"k2" int = 2
+			"k3" int = 3`)
+}
+
+func TestValueTreeDepth(t *testing.T) {
+	v := []interface{}{[]int{1, 2}}
+	runValueTreeTest(t, v, 1, `
+		[]interface {} = len=1 cap=1
+			[0] interface {}([]int) = len=2 cap=2 (trunc)`)
+}
+
+func TestValueTreeCycle(t *testing.T) {
+	type St1 struct {
+		Next *St1
+	}
+	v := &St1{}
+	v.Next = v
+	vt := runValueTree(v, 10)
+	n := vt.Children[0].Children[0] // ptr->struct->field
+	if !n.Cycle || len(n.Children) != 0 {
+		t.Fatalf("expecting cycle: %+v", n)
+	}
+}
+
+func TestValueTreeOff(t *testing.T) {
+	ValueTreeDepth = 0
+	if vt := valueTree([]int{1}); vt != nil {
+		t.Fatal(vt)
+	}
+	ValueTreeDepth = 2
+	defer func() { ValueTreeDepth = 0 }()
+	if vt := valueTree(1); vt != nil {
+		t.Fatal(vt)
+	}
+	if vt := valueTree([]int{1}); vt == nil {
+		t.Fatal(vt)
+	}
+}
+
+//----------
+
+func runValueTree(v interface{}, depth int) *ValueNode {
+	ValueTreeDepth = depth
+	defer func() { ValueTreeDepth = 0 }()
+	return valueTree(v)
+}
+
+func runValueTreeTest(t *testing.T, v interface{}, depth int, out string) {
+	t.Helper()
+	vt := runValueTree(v, depth)
+	sb := &strings.Builder{}
+	sprintValueNode(sb, vt, 0)
+	res := strings.TrimSpace(sb.String())
+	out = strings.TrimSpace(strings.ReplaceAll(out, "\n\t\t", "\n"))
+	if res != out {
+		t.Fatalf("\n%v\nexpecting:\n%v", res, out)
+	}
+}
+
+func sprintValueNode(sb *strings.Builder, n *ValueNode, depth int) {
+	s := strings.TrimSpace(fmt.Sprintf("%v %v", n.Name, n.Type))
+	fmt.Fprintf(sb, "%v%v = %v", strings.Repeat("\t", depth), s, n.Value)
+	if n.Trunc {
+		sb.WriteString(" (trunc)")
+	}
+	sb.WriteString("\n")
+	for _, c := range n.Children {
+		sprintValueNode(sb, c, depth+1)
+	}
+}
@@ -0,0 +1,60 @@
+package godebug
+
+import (
+	"github.com/jmigpin/editor/core/godebug/debug"
+)
+
+// Returns the item values in the same order as they are stringified.
+func ItemValues(item debug.Item) []*debug.ItemValue {
+	u := []*debug.ItemValue{}
+	walkItemValues(item, func(iv *debug.ItemValue) {
+		u = append(u, iv)
+	})
+	return u
+}
+
+func walkItemValues(item debug.Item, fn func(*debug.ItemValue)) {
+	w := func(items ...debug.Item) {
+		for _, item := range items {
+			walkItemValues(item, fn)
+		}
+	}
+	switch t := item.(type) {
+	case *debug.ItemValue:
+		fn(t)
+	case *debug.ItemList:
+		if t != nil {
+			w(t.List...)
+		}
+	case *debug.ItemList2:
+		w(t.List...)
+	case *debug.ItemAssign:
+		w(t.Lhs, t.Rhs)
+	case *debug.ItemSend:
+		w(t.Chan, t.Value)
+	case *debug.ItemCall:
+		w(t.Result, t.Args)
+	case *debug.ItemCallEnter:
+		w(t.Args)
+	case *debug.ItemIndex:
+		w(t.Result, t.Expr, t.Index)
+	case *debug.ItemIndex2:
+		w(t.Result, t.Expr, t.Low, t.High, t.Max)
+	case *debug.ItemKeyValue:
+		w(t.Key, t.Value)
+	case *debug.ItemSelector:
+		w(t.X, t.Sel)
+	case *debug.ItemTypeAssert:
+		w(t.Type, t.X)
+	case *debug.ItemBinary:
+		w(t.Result, t.X, t.Y)
+	case *debug.ItemUnary:
+		w(t.Result, t.X)
+	case *debug.ItemUnaryEnter:
+		w(t.X)
+	case *debug.ItemParen:
+		w(t.X)
+	case *debug.ItemLiteral:
+		w(t.Fields)
+	}
+}
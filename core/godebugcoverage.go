@@ -0,0 +1,177 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/jmigpin/editor/util/drawutil/drawer4"
+	"github.com/jmigpin/editor/util/iout/iorw"
+)
+
+const GoDebugCoverageRowName = "+GoDebugCoverage"
+
+// min heatmap level for lines executed at least once (keep them visible)
+const gdCoverageMinLevel = 0.25
+
+//----------
+
+// Toggles the coverage view: executed lines shading and the coverage report row. Needs to be called in the UI goroutine.
+func (gdi *GoDebugInstance) ToggleCoverage() {
+	gdi.coverage = !gdi.coverage
+	if gdi.coverage {
+		crow, isNew := gdi.ed.ExistingOrNewERow(GoDebugCoverageRowName)
+		if !isNew {
+			crow.Flash()
+		}
+	}
+
+	if !gdi.dataRLock() {
+		if !gdi.coverage {
+			gdi.clearHeatmaps()
+		}
+		return
+	}
+	defer gdi.dataRUnlock()
+	gdi.updateUI2()
+}
+
+//----------
+
+func (gdi *GoDebugInstance) updateCoverageUI() {
+	// only update an existing row (closing the row stops updates)
+	info, ok := gdi.ed.ERowInfo(GoDebugCoverageRowName)
+	if !ok || len(info.ERows) == 0 {
+		return
+	}
+	di := gdi.data.dataIndex
+	if !di.coverage.ok || di.coverage.key != di.lastArrivalIndex {
+		di.coverage.report = di.coverageReport()
+		di.coverage.key = di.lastArrivalIndex
+		di.coverage.ok = true
+	}
+	s := di.coverage.report
+	for _, erow := range info.ERows {
+		ta := erow.Row.TextArea
+		if ta.Str() == s {
+			continue
+		}
+		ta.SetStrClearHistory(s)
+	}
+}
+
+func (gdi *GoDebugInstance) updateHeatmap(erow *ERow, file *GDFileMsgs) {
+	ta := erow.Row.TextArea
+	if !gdi.coverage {
+		ta.SetHeatmap(nil)
+		return
+	}
+	// cached: only changes with new msgs (content is not edited, or there would be no heatmap)
+	key := gdi.data.dataIndex.lastArrivalIndex
+	if !file.heatmap.ok || file.heatmap.key != key {
+		file.heatmap.entries = gdCoverageHeatmap(ta.TextCursor.RW(), file)
+		file.heatmap.key = key
+		file.heatmap.ok = true
+	}
+	ta.SetHeatmap(file.heatmap.entries)
+}
+
+func (gdi *GoDebugInstance) clearHeatmaps() {
+	for _, info := range gdi.ed.ERowInfos() {
+		gdi.clearHeatmap(info)
+	}
+}
+
+func (gdi *GoDebugInstance) clearHeatmap(info *ERowInfo) {
+	for _, erow := range info.ERows {
+		erow.Row.TextArea.SetHeatmap(nil)
+	}
+}
+
+//----------
+
+func (di *GDDataIndex) coverageReport() string {
+	sb := &strings.Builder{}
+	fmt.Fprintf(sb, "coverage: executed/total annotated lines\n")
+	sumExec, sumTotal := 0, 0
+	for findex, file := range di.Files {
+		exec, total := file.coverage()
+		sumExec += exec
+		sumTotal += total
+		fmt.Fprintf(sb, "%v\t%v/%v\t%v\n", di.Afds[findex].Filename, exec, total, gdCoveragePerc(exec, total))
+	}
+	fmt.Fprintf(sb, "total\t%v/%v\t%v\n", sumExec, sumTotal, gdCoveragePerc(sumExec, sumTotal))
+	return sb.String()
+}
+
+// Returns the number of annotated lines that executed at least once, and the total number of annotated lines.
+func (file *GDFileMsgs) coverage() (int, int) {
+	n := 0
+	for _, lm := range file.LinesMsgs {
+		if len(lm.lineMsgs) > 0 {
+			n++
+		}
+	}
+	return n, len(file.LinesMsgs)
+}
+
+func gdCoveragePerc(n, total int) string {
+	if total == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", float64(n)*100/float64(total))
+}
+
+//----------
+
+// Heatmap entries for the lines (of the file content) that have executed annotations. The level is relative to the most executed line (log scale).
+func gdCoverageHeatmap(rd iorw.Reader, file *GDFileMsgs) []*drawer4.HeatmapEntry {
+	// hits by line start
+	type lineHits struct {
+		start, end int
+		hits       int
+	}
+	lines := map[int]*lineHits{}
+	max := 0
+	for _, lm := range file.LinesMsgs {
+		if len(lm.lineMsgs) == 0 {
+			continue
+		}
+		offset := lm.lineMsgs[0].dbgLineMsg.Offset
+		s, e, newline, err := iorw.LinesIndexes(rd, offset, offset)
+		if err != nil {
+			continue
+		}
+		if newline {
+			e--
+		}
+		lh, ok := lines[s]
+		if !ok {
+			lh = &lineHits{start: s, end: e}
+			lines[s] = lh
+		}
+		// several annotations in the same line: keep the most executed
+		if n := len(lm.lineMsgs); n > lh.hits {
+			lh.hits = n
+		}
+		if lh.hits > max {
+			max = lh.hits
+		}
+	}
+
+	u := []*drawer4.HeatmapEntry{}
+	for _, lh := range lines {
+		level := 1.0
+		if max > 1 {
+			level = math.Log1p(float64(lh.hits)) / math.Log1p(float64(max))
+		}
+		level = gdCoverageMinLevel + level*(1-gdCoverageMinLevel)
+		e := &drawer4.HeatmapEntry{Start: lh.start, End: lh.end, Level: level}
+		u = append(u, e)
+	}
+	sort.Slice(u, func(a, b int) bool {
+		return u[a].Start < u[b].Start
+	})
+	return u
+}
@@ -0,0 +1,70 @@
+package core
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jmigpin/editor/core/godebug/debug"
+	"github.com/jmigpin/editor/util/iout/iorw"
+)
+
+func TestGDCoverageHeatmap(t *testing.T) {
+	src := "a := 1\nfor i := 0; i < 3; i++ {\n\tb := i\n}\nc := 2\n"
+	file := NewGDFileMsgs(5)
+	add := func(dindex, offset, n int) {
+		lm := &file.LinesMsgs[dindex].lineMsgs
+		for i := 0; i < n; i++ {
+			dlm := &debug.LineMsg{DebugIndex: dindex, Offset: offset}
+			*lm = append(*lm, &GDLineMsg{dbgLineMsg: dlm})
+		}
+	}
+	add(0, 0, 1)  // line 1
+	add(1, 7, 4)  // line 2
+	add(2, 10, 1) // line 2 (same line, less hits)
+	add(3, 33, 3) // line 3
+	// debug index 4 (line 5) never executed
+
+	rd := iorw.NewStringReader(src)
+	u := gdCoverageHeatmap(rd, file)
+	if len(u) != 3 {
+		t.Fatalf("%v", len(u))
+	}
+	starts := []int{0, 7, 32}
+	ends := []int{6, 31, 39}
+	for i, e := range u {
+		if e.Start != starts[i] || e.End != ends[i] {
+			t.Fatalf("entry %v: %+v", i, e)
+		}
+	}
+	// most executed line has the max level
+	if u[1].Level != 1 {
+		t.Fatalf("%v", u[1].Level)
+	}
+	if !(u[0].Level >= gdCoverageMinLevel && u[0].Level < u[2].Level && u[2].Level < u[1].Level) {
+		t.Fatalf("%v %v %v", u[0].Level, u[1].Level, u[2].Level)
+	}
+
+	exec, total := file.coverage()
+	if exec != 4 || total != 5 {
+		t.Fatalf("%v/%v", exec, total)
+	}
+}
+
+func TestGDCoverageReport(t *testing.T) {
+	di := NewGDDataIndex(nil)
+	di.Afds = []*debug.AnnotatorFileData{{Filename: "/a/a.go"}, {Filename: "/a/b.go"}}
+	di.Files = []*GDFileMsgs{NewGDFileMsgs(4), NewGDFileMsgs(0)}
+	dlm := &debug.LineMsg{}
+	di.Files[0].LinesMsgs[1].lineMsgs = []*GDLineMsg{{dbgLineMsg: dlm}}
+
+	s := di.coverageReport()
+	for _, w := range []string{
+		"/a/a.go\t1/4\t25.0%\n",
+		"/a/b.go\t0/0\t-\n",
+		"total\t1/4\t25.0%\n",
+	} {
+		if !strings.Contains(s, w) {
+			t.Fatalf("missing %q in:\n%v", w, s)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package core
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/jmigpin/editor/core/godebug"
+	"github.com/jmigpin/editor/core/godebug/debug"
+)
+
+const GoDebugInspectRowName = "+GoDebugInspect"
+
+//----------
+
+// Inspect the values of a debug step as an expandable tree.
+type GDInspect struct {
+	header   string
+	roots    []*debug.ValueNode
+	expanded map[*debug.ValueNode]bool
+	lines    []*debug.ValueNode // visible nodes, by line after the header
+}
+
+func NewGDInspect(header string, ivs []*debug.ItemValue) *GDInspect {
+	ins := &GDInspect{header: header, expanded: map[*debug.ValueNode]bool{}}
+	for _, iv := range ivs {
+		n := iv.Tree
+		if n == nil {
+			n = &debug.ValueNode{Value: iv.Str}
+		}
+		ins.roots = append(ins.roots, n)
+		ins.expanded[n] = true
+	}
+	return ins
+}
+
+func (ins *GDInspect) headerLines() int {
+	return strings.Count(ins.header, "\n")
+}
+
+// Line is zero based, from the start of the text (includes the header).
+func (ins *GDInspect) Toggle(line int) error {
+	k := line - ins.headerLines()
+	if k < 0 || k >= len(ins.lines) {
+		return fmt.Errorf("no value at line %v", line+1)
+	}
+	n := ins.lines[k]
+	if len(n.Children) == 0 {
+		return fmt.Errorf("value has no children")
+	}
+	ins.expanded[n] = !ins.expanded[n]
+	return nil
+}
+
+func (ins *GDInspect) String() string {
+	sb := &strings.Builder{}
+	sb.WriteString(ins.header)
+	ins.lines = ins.lines[:0]
+	for _, n := range ins.roots {
+		ins.writeNode(sb, n, 0)
+	}
+	return sb.String()
+}
+
+func (ins *GDInspect) writeNode(sb *strings.Builder, n *debug.ValueNode, depth int) {
+	ins.lines = append(ins.lines, n)
+
+	marker := "   "
+	if len(n.Children) > 0 {
+		marker = "[+]"
+		if ins.expanded[n] {
+			marker = "[-]"
+		}
+	}
+	sb.WriteString(strings.Repeat("\t", depth))
+	sb.WriteString(marker)
+	if n.Name != "" {
+		sb.WriteString(" " + n.Name)
+	}
+	if n.Type != "" {
+		sb.WriteString(" " + n.Type)
+	}
+	sb.WriteString(" = " + n.Value)
+	if n.Cycle {
+		sb.WriteString(" (cycle)")
+	} else if n.Trunc {
+		sb.WriteString(" ...")
+	}
+	sb.WriteString("\n")
+
+	if ins.expanded[n] {
+		for _, c := range n.Children {
+			ins.writeNode(sb, c, depth+1)
+		}
+	}
+}
+
+//----------
+
+func (gdi *GoDebugInstance) inspectIndex(erow *ERow, annIndex int) {
+	file, line, ok := gdi.currentAnnotationFileLine(erow, annIndex)
+	if !ok {
+		return
+	}
+
+	// current msg index at line
+	k := file.AnnEntriesLMIndex[annIndex]
+	if k < 0 { // currently nothing is shown
+		return
+	}
+
+	// msg
+	msg := line.lineMsgs[k]
+	item := msg.dbgLineMsg.Item
+	ivs := godebug.ItemValues(item)
+
+	header := fmt.Sprintf("step #%v: %v\n\t%v\n",
+		msg.arrivalIndex,
+		filepath.Base(erow.Info.Name()),
+		godebug.StringifyItem(item))
+	hasTrees := false
+	for _, iv := range ivs {
+		if iv.Tree != nil {
+			hasTrees = true
+			break
+		}
+	}
+	if !hasTrees {
+		header += "(no value trees: use the -valuetree flag to be able to expand values)\n"
+	}
+
+	gdi.inspect = NewGDInspect(header, ivs)
+
+	irow, isNew := gdi.ed.ExistingOrNewERow(GoDebugInspectRowName)
+	if !isNew {
+		irow.Flash()
+	}
+	gdi.updateInspectUI(false)
+}
+
+// Toggles the expanded state of the value at the line (zero based). Needs to be called in the UI goroutine.
+func (gdi *GoDebugInstance) InspectToggle(line int) error {
+	if gdi.inspect == nil {
+		return fmt.Errorf("nothing to inspect")
+	}
+	if err := gdi.inspect.Toggle(line); err != nil {
+		return err
+	}
+	gdi.updateInspectUI(true)
+	return nil
+}
+
+func (gdi *GoDebugInstance) updateInspectUI(keepPos bool) {
+	info, ok := gdi.ed.ERowInfo(GoDebugInspectRowName)
+	if !ok {
+		return
+	}
+	s := gdi.inspect.String()
+	for _, erow := range info.ERows {
+		ta := erow.Row.TextArea
+		ci, ro := ta.TextCursor.Index(), ta.RuneOffset()
+		ta.SetStrClearHistory(s)
+		if keepPos {
+			if ci <= len(s) {
+				ta.TextCursor.SetIndex(ci)
+			}
+			if ro <= len(s) {
+				ta.SetRuneOffset(ro)
+			}
+		}
+	}
+}
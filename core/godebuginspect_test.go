@@ -0,0 +1,32 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/jmigpin/editor/core/godebug/debug"
+)
+
+func TestGDInspectToggle(t *testing.T) {
+	tree := &debug.ValueNode{Type: "[]int", Value: "len=1 cap=1", Children: []*debug.ValueNode{
+		{Name: "[0]", Type: "int", Value: "7"},
+	}}
+	ivs := []*debug.ItemValue{{Str: "1"}, {Str: "[7]", Tree: tree}}
+	ins := NewGDInspect("header\n", ivs)
+
+	s1 := "header\n    = 1\n[-] []int = len=1 cap=1\n\t    [0] int = 7\n"
+	if s := ins.String(); s != s1 {
+		t.Fatalf("%q", s)
+	}
+
+	if err := ins.Toggle(2); err != nil {
+		t.Fatal(err)
+	}
+	s2 := "header\n    = 1\n[+] []int = len=1 cap=1\n"
+	if s := ins.String(); s != s2 {
+		t.Fatalf("%q", s)
+	}
+
+	if err := ins.Toggle(1); err == nil { // leaf
+		t.Fatal("expecting error")
+	}
+}
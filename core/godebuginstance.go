@@ -30,8 +30,10 @@ type GoDebugInstance struct {
 		mu        sync.RWMutex
 		dataIndex *GDDataIndex
 	}
-	cancel context.CancelFunc
-	ready  sync.Mutex // TODO: start/wait model
+	cancel   context.CancelFunc
+	ready    sync.Mutex // TODO: start/wait model
+	inspect  *GDInspect // used only in the UI goroutine
+	coverage bool       // used only in the UI goroutine
 }
 
 func NewGoDebugInstance(ed *Editor) *GoDebugInstance {
@@ -105,6 +107,9 @@ func (gdi *GoDebugInstance) selectERowAnnotation2(erow *ERow, ev *ui.TextAreaSel
 	case ui.TASelAnnTypePrintAllPrevious:
 		gdi.printIndexAllPrevious(erow, ev.AnnotationIndex, ev.Offset)
 		return false
+	case ui.TASelAnnTypeInspect:
+		gdi.inspectIndex(erow, ev.AnnotationIndex)
+		return false
 	default:
 		log.Printf("todo: %#v", ev)
 	}
@@ -183,7 +188,11 @@ func (gdi *GoDebugInstance) selectAnnCurrent(erow *ERow, annIndex, offset int, t
 
 func (gdi *GoDebugInstance) selectNext() bool {
 	di := gdi.data.dataIndex
-	if di.selected.arrivalIndex < di.lastArrivalIndex {
+	if di.tests.filter != "" {
+		if i, ok := di.tests.step(di.selected.arrivalIndex, 1); ok {
+			di.selected.arrivalIndex = i
+		}
+	} else if di.selected.arrivalIndex < di.lastArrivalIndex {
 		di.selected.arrivalIndex++
 	}
 	gdi.openArrivalIndexERow()
@@ -192,7 +201,11 @@ func (gdi *GoDebugInstance) selectNext() bool {
 
 func (gdi *GoDebugInstance) selectPrev() bool {
 	di := gdi.data.dataIndex
-	if di.selected.arrivalIndex > 0 {
+	if di.tests.filter != "" {
+		if i, ok := di.tests.step(di.selected.arrivalIndex, -1); ok {
+			di.selected.arrivalIndex = i
+		}
+	} else if di.selected.arrivalIndex > 0 {
 		di.selected.arrivalIndex--
 	}
 	gdi.openArrivalIndexERow()
@@ -201,7 +214,11 @@ func (gdi *GoDebugInstance) selectPrev() bool {
 
 func (gdi *GoDebugInstance) selectFirst() bool {
 	di := gdi.data.dataIndex
-	if 0 <= di.lastArrivalIndex {
+	if di.tests.filter != "" {
+		if i, ok := di.tests.first(); ok {
+			di.selected.arrivalIndex = i
+		}
+	} else if 0 <= di.lastArrivalIndex {
 		di.selected.arrivalIndex = 0
 	}
 	gdi.openArrivalIndexERow()
@@ -210,7 +227,13 @@ func (gdi *GoDebugInstance) selectFirst() bool {
 
 func (gdi *GoDebugInstance) selectLast() bool {
 	di := gdi.data.dataIndex
-	di.selected.arrivalIndex = di.lastArrivalIndex
+	if di.tests.filter != "" {
+		if i, ok := di.tests.last(); ok {
+			di.selected.arrivalIndex = i
+		}
+	} else {
+		di.selected.arrivalIndex = di.lastArrivalIndex
+	}
 	gdi.openArrivalIndexERow()
 	return true // show always
 }
@@ -299,6 +322,9 @@ func (gdi *GoDebugInstance) Start(erow *ERow, args []string) error {
 	// only one instance at a time
 	gdi.CancelAndClear() // cancel previous run
 
+	// the output replaces the tree listing
+	DisableDirTree(erow)
+
 	erow.Exec.Start(func(ctx context.Context, w io.Writer) error {
 		// wait for previous run to finish
 		gdi.ready.Lock()
@@ -332,6 +358,7 @@ func (gdi *GoDebugInstance) start2(erow *ERow, args []string, ctx context.Contex
 	defer cmd.Cleanup()
 
 	cmd.Dir = erow.Info.Name()
+	cmd.Stdin = nil // dap mode needs an address
 	cmd.Stdout = w
 	cmd.Stderr = w
 
@@ -342,6 +369,10 @@ func (gdi *GoDebugInstance) start2(erow *ERow, args []string, ctx context.Contex
 	if done {
 		return nil
 	}
+	if cmd.DapMode() {
+		// no client msgs, sessions are driven by the dap clients
+		return cmd.Wait()
+	}
 
 	// handle client msgs loop (blocking)
 	gdi.clientMsgsLoop(ctx, w, cmd)
@@ -507,6 +538,8 @@ func (gdi *GoDebugInstance) updateUI2() {
 	for _, info := range gdi.ed.ERowInfos() {
 		gdi.updateInfoUI(info)
 	}
+	gdi.updateWatchUI()
+	gdi.updateCoverageUI()
 }
 
 func (gdi *GoDebugInstance) updateInfoUI(info *ERowInfo) {
@@ -541,6 +574,7 @@ func (gdi *GoDebugInstance) updateInfoUI(info *ERowInfo) {
 	file := di.Files[findex]
 	for _, erow := range info.ERows {
 		gdi.setAnnotations(erow, true, selLine, file.AnnEntries)
+		gdi.updateHeatmap(erow, file)
 	}
 }
 
@@ -548,6 +582,7 @@ func (gdi *GoDebugInstance) clearDrawerAnn(info *ERowInfo) {
 	for _, erow := range info.ERows {
 		gdi.setAnnotations(erow, false, -1, nil)
 	}
+	gdi.clearHeatmap(info)
 }
 
 func (gdi *GoDebugInstance) setAnnotations(erow *ERow, on bool, selIndex int, entries []*drawer4.Annotation) {
@@ -638,12 +673,23 @@ type GDDataIndex struct {
 
 	Afds  []*debug.AnnotatorFileData // [fileindex]
 	Files []*GDFileMsgs              // [fileindex]
+
+	watch *GDWatch
+	tests *GDTests
+
+	// coverage report cache
+	coverage struct {
+		report string
+		key    int // lastArrivalIndex when computed
+		ok     bool
+	}
 }
 
 func NewGDDataIndex(ed *Editor) *GDDataIndex {
 	di := &GDDataIndex{ed: ed}
 	di.filesIndexM = map[string]int{}
 	di.filesEdited = map[int]bool{}
+	di.tests = NewGDTests()
 	di.clearMsgs()
 	return di
 }
@@ -666,6 +712,11 @@ func (di *GDDataIndex) clearMsgs() {
 		u := NewGDFileMsgs(n)
 		*f = *u
 	}
+	di.tests.clearMsgs()
+	di.coverage.ok = false
+	if di.watch != nil {
+		di.watch.init(di)
+	}
 	di.lastArrivalIndex = -1
 	di.selected.arrivalIndex = di.lastArrivalIndex
 }
@@ -705,6 +756,7 @@ func (di *GDDataIndex) selectedArrivalIndexFilename(arrivalIndex int) (string, b
 
 func (di *GDDataIndex) handleFilesDataMsg(fdm *debug.FilesDataMsg) error {
 	di.Afds = fdm.Data
+	di.coverage.ok = false
 	// index filenames
 	di.filesIndexM = map[string]int{}
 	for _, afd := range di.Afds {
@@ -740,9 +792,24 @@ func (di *GDDataIndex) handleLineMsg(u *debug.LineMsg) error {
 	// index msg
 	w := &di.Files[u.FileIndex].LinesMsgs[u.DebugIndex].lineMsgs
 	*w = append(*w, lm)
+	if di.watch != nil {
+		di.watch.add(lm)
+	}
+
+	// tests
+	prevTestLast, _ := di.tests.last()
+	di.tests.add(u.TestName, di.lastArrivalIndex)
 
 	// auto update selected index if at last position
-	if di.selected.arrivalIndex == di.lastArrivalIndex-1 {
+	if di.tests.filter != "" {
+		// only with msgs of the filtered test
+		if u.TestName == di.tests.filter {
+			n := len(di.tests.arrivals[di.tests.filter])
+			if n == 1 || di.selected.arrivalIndex == prevTestLast {
+				di.selected.arrivalIndex = di.lastArrivalIndex
+			}
+		}
+	} else if di.selected.arrivalIndex == di.lastArrivalIndex-1 {
 		di.selected.arrivalIndex = di.lastArrivalIndex
 	}
 
@@ -761,6 +828,13 @@ type GDFileMsgs struct {
 	AnnEntries        []*drawer4.Annotation
 	AnnEntriesLMIndex []int // line messages index: keep selected k to know the msg entry when coming from a click on an annotation
 
+	// coverage heatmap cache
+	heatmap struct {
+		entries []*drawer4.HeatmapEntry
+		key     int // lastArrivalIndex when computed
+		ok      bool
+	}
+
 	//HasNewData bool // performance
 }
 
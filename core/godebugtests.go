@@ -0,0 +1,128 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Tests that ran in the godebug session (msgs are tagged with the running test name).
+type GDTests struct {
+	names    []string         // ordered by first arrival
+	arrivals map[string][]int // [name]arrival indexes (ordered)
+	filter   string           // restrict navigation to the msgs of this test
+}
+
+func NewGDTests() *GDTests {
+	return &GDTests{arrivals: map[string][]int{}}
+}
+
+func (tests *GDTests) add(name string, arrivalIndex int) {
+	if name == "" {
+		return
+	}
+	u, ok := tests.arrivals[name]
+	if !ok {
+		tests.names = append(tests.names, name)
+	}
+	tests.arrivals[name] = append(u, arrivalIndex)
+}
+
+func (tests *GDTests) clearMsgs() {
+	tests.names = nil
+	tests.arrivals = map[string][]int{}
+}
+
+//----------
+
+// Arrival index of the next (dir>0) or previous (dir<0) step of the filtered test.
+func (tests *GDTests) step(arrivalIndex, dir int) (int, bool) {
+	u := tests.arrivals[tests.filter]
+	if dir > 0 {
+		k := sort.SearchInts(u, arrivalIndex+1)
+		if k < len(u) {
+			return u[k], true
+		}
+		return 0, false
+	}
+	k := sort.SearchInts(u, arrivalIndex) - 1
+	if k >= 0 {
+		return u[k], true
+	}
+	return 0, false
+}
+
+func (tests *GDTests) first() (int, bool) {
+	u := tests.arrivals[tests.filter]
+	if len(u) == 0 {
+		return 0, false
+	}
+	return u[0], true
+}
+
+func (tests *GDTests) last() (int, bool) {
+	u := tests.arrivals[tests.filter]
+	if len(u) == 0 {
+		return 0, false
+	}
+	return u[len(u)-1], true
+}
+
+func (tests *GDTests) String() string {
+	sb := &strings.Builder{}
+	fmt.Fprintf(sb, "godebug tests (%v):\n", len(tests.names))
+	for _, name := range tests.names {
+		mark := " "
+		if name == tests.filter {
+			mark = "*"
+		}
+		fmt.Fprintf(sb, "\t%v %v (steps: %v)\n", mark, name, len(tests.arrivals[name]))
+	}
+	if tests.filter == "" {
+		fmt.Fprintf(sb, "navigation: all steps\n")
+	} else {
+		fmt.Fprintf(sb, "navigation: %v steps only\n", tests.filter)
+	}
+	return sb.String()
+}
+
+//----------
+
+// Restricts the steps navigation to the msgs of the named test (empty name for all steps). With no name and no reset, only lists the tests. Needs to be called in the UI goroutine.
+func (gdi *GoDebugInstance) FilterTest(name string, reset bool) error {
+	show, err := gdi.filterTest2(name, reset)
+	if err != nil {
+		return err
+	}
+	if show {
+		gdi.updateUIShowLine(gdi.ed.GoodRowPos())
+	}
+	return nil
+}
+
+func (gdi *GoDebugInstance) filterTest2(name string, reset bool) (bool, error) {
+	if !gdi.dataLock() {
+		return false, fmt.Errorf("no godebug session")
+	}
+	defer gdi.dataUnlock()
+
+	di := gdi.data.dataIndex
+	show := false
+	if name != "" {
+		if _, ok := di.tests.arrivals[name]; !ok {
+			return false, fmt.Errorf("test not found (or without steps): %v", name)
+		}
+		di.tests.filter = name
+		// select the first step of the test
+		if i, ok := di.tests.first(); ok {
+			di.selected.arrivalIndex = i
+			gdi.openArrivalIndexERow()
+			show = true
+		}
+	} else if reset {
+		di.tests.filter = ""
+	}
+
+	gdi.ed.Messagef("%v", di.tests.String())
+	return show, nil
+}
@@ -0,0 +1,60 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/jmigpin/editor/core/godebug/debug"
+)
+
+func TestGDTestsNavigation(t *testing.T) {
+	di := NewGDDataIndex(nil)
+	di.Files = []*GDFileMsgs{NewGDFileMsgs(1)}
+	for _, name := range []string{"", "TestA", "TestB", "TestA", "TestB", "TestA"} {
+		lm := &debug.LineMsg{TestName: name}
+		if err := di.handleLineMsg(lm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := di.tests
+	if len(tests.names) != 2 || tests.names[0] != "TestA" || tests.names[1] != "TestB" {
+		t.Fatalf("%v", tests.names)
+	}
+
+	tests.filter = "TestB"
+	type tc struct {
+		index, dir int
+		res        int
+		ok         bool
+	}
+	for _, u := range []tc{
+		{0, 1, 2, true},
+		{2, 1, 4, true},
+		{3, 1, 4, true},
+		{4, 1, 0, false},
+		{4, -1, 2, true},
+		{3, -1, 2, true},
+		{2, -1, 0, false},
+	} {
+		i, ok := tests.step(u.index, u.dir)
+		if i != u.res || ok != u.ok {
+			t.Fatalf("%+v: got %v %v", u, i, ok)
+		}
+	}
+	if i, _ := tests.first(); i != 2 {
+		t.Fatal(i)
+	}
+	if i, _ := tests.last(); i != 4 {
+		t.Fatal(i)
+	}
+
+	// auto update selection only with msgs of the filtered test
+	di.selected.arrivalIndex = 4
+	_ = di.handleLineMsg(&debug.LineMsg{TestName: "TestA"})
+	if di.selected.arrivalIndex != 4 {
+		t.Fatal(di.selected.arrivalIndex)
+	}
+	_ = di.handleLineMsg(&debug.LineMsg{TestName: "TestB"})
+	if di.selected.arrivalIndex != 7 {
+		t.Fatal(di.selected.arrivalIndex)
+	}
+}
@@ -0,0 +1,506 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jmigpin/editor/core/godebug"
+	"github.com/jmigpin/editor/core/godebug/debug"
+	"github.com/jmigpin/editor/ui"
+	"github.com/jmigpin/editor/util/iout/iorw"
+)
+
+const GoDebugWatchRowName = "+GoDebugWatch"
+
+// max entries shown in the watch row (last received)
+const gdWatchMaxEntries = 1000
+
+//----------
+
+// Watch an identifier: history of its values in the annotated lines where it is present.
+type GDWatch struct {
+	fileIndex int
+	word      string
+	src       []byte             // file content at the time the watch was set
+	tfile     *token.File        // nil if the src didn't parse
+	astFile   *ast.File          // nil if the src didn't parse
+	lines     map[int]int        // [debugIndex]line (line=0: no match)
+	nodes     map[int][]ast.Node // [debugIndex]nodes annotated at the offset
+	outputKey string             // avoid rewriting the row with the same content
+
+	entries  []*GDWatchEntry // in arrival order
+	complete bool            // entries include all the received msgs
+}
+
+func NewGDWatch(fileIndex int, word string, src []byte) *GDWatch {
+	w := &GDWatch{
+		fileIndex: fileIndex,
+		word:      word,
+		src:       src,
+		lines:     map[int]int{},
+		nodes:     map[int][]ast.Node{},
+	}
+	fset := token.NewFileSet()
+	astFile, err := parser.ParseFile(fset, "", src, 0)
+	if err == nil {
+		w.astFile = astFile
+		w.tfile = fset.File(astFile.Pos())
+	}
+	return w
+}
+
+// Returns the line of the debug index, or zero if the line doesn't contain the watched word.
+func (w *GDWatch) debugIndexLine(debugIndex, offset int) int {
+	line, ok := w.lines[debugIndex]
+	if !ok {
+		line = gdWatchWordLine(w.src, offset, w.word)
+		w.lines[debugIndex] = line
+	}
+	return line
+}
+
+// Returns the value of the watched word in the item annotated at the debug index. If the src didn't parse, the whole item is returned.
+func (w *GDWatch) debugIndexValue(debugIndex, offset int, item debug.Item) (debug.Item, bool) {
+	if w.astFile == nil {
+		return item, true
+	}
+	nodes, ok := w.nodes[debugIndex]
+	if !ok {
+		nodes = gdWatchOffsetNodes(w.tfile, w.astFile, offset)
+		w.nodes[debugIndex] = nodes
+	}
+	for _, n := range nodes {
+		if v, ok := gdWatchNodeItem(n, item, w.word); ok {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+//----------
+
+type GDWatchEntry struct {
+	msg   *GDLineMsg
+	line  int
+	value debug.Item
+}
+
+// Collects the entries of the msgs received before the watch was set. New msgs are added with add().
+func (w *GDWatch) init(di *GDDataIndex) {
+	w.entries = nil
+	w.complete = true
+	if w.fileIndex >= len(di.Files) {
+		return
+	}
+	file := di.Files[w.fileIndex]
+	for dindex, lm := range file.LinesMsgs {
+		if len(lm.lineMsgs) == 0 {
+			continue
+		}
+		line := w.debugIndexLine(dindex, lm.lineMsgs[0].dbgLineMsg.Offset)
+		if line == 0 {
+			continue
+		}
+		for _, msg := range lm.lineMsgs {
+			dlm := msg.dbgLineMsg
+			v, ok := w.debugIndexValue(dindex, dlm.Offset, dlm.Item)
+			if !ok {
+				continue
+			}
+			w.entries = append(w.entries, &GDWatchEntry{msg: msg, line: line, value: v})
+		}
+	}
+	sort.Slice(w.entries, func(a, b int) bool {
+		return w.entries[a].msg.arrivalIndex < w.entries[b].msg.arrivalIndex
+	})
+}
+
+// Msgs arrive in order: appending keeps the entries sorted.
+func (w *GDWatch) add(msg *GDLineMsg) {
+	dlm := msg.dbgLineMsg
+	if !w.complete || dlm.FileIndex != w.fileIndex {
+		return
+	}
+	line := w.debugIndexLine(dlm.DebugIndex, dlm.Offset)
+	if line == 0 {
+		return
+	}
+	v, ok := w.debugIndexValue(dlm.DebugIndex, dlm.Offset, dlm.Item)
+	if !ok {
+		return
+	}
+	w.entries = append(w.entries, &GDWatchEntry{msg: msg, line: line, value: v})
+}
+
+//----------
+
+// Sets a watch on the word at the erow text cursor (or selection), or the given word if not empty. Needs to be called in the UI goroutine.
+func (gdi *GoDebugInstance) Watch(erow *ERow, word string) error {
+	if !gdi.dataLock() {
+		return fmt.Errorf("no godebug session")
+	}
+	defer gdi.dataUnlock()
+
+	di := gdi.data.dataIndex
+	findex, ok := di.FilesIndex(erow.Info.Name())
+	if !ok {
+		return fmt.Errorf("file not in the godebug session: %v", erow.Info.Name())
+	}
+	gdi.updateFileEdited(erow.Info)
+	if di.filesEdited[findex] {
+		return fmt.Errorf("file was edited: %v", erow.Info.Name())
+	}
+
+	ta := erow.Row.TextArea
+	if word == "" {
+		tc := ta.TextCursor
+		if tc.SelectionOn() {
+			b, err := tc.Selection()
+			if err != nil {
+				return err
+			}
+			word = strings.TrimSpace(string(b))
+		} else {
+			b, _, err := iorw.WordAtIndex(tc.RW(), tc.Index())
+			if err != nil {
+				return err
+			}
+			word = string(b)
+		}
+	}
+	if word == "" {
+		return fmt.Errorf("missing word to watch")
+	}
+
+	src, err := ta.Bytes()
+	if err != nil {
+		return err
+	}
+
+	di.watch = NewGDWatch(findex, word, src)
+	di.watch.init(di)
+
+	// show watch row
+	wrow, isNew := gdi.ed.ExistingOrNewERow(GoDebugWatchRowName)
+	if !isNew {
+		wrow.Flash()
+	}
+	gdi.updateWatchUI()
+	return nil
+}
+
+//----------
+
+func (gdi *GoDebugInstance) updateWatchUI() {
+	di := gdi.data.dataIndex
+	w := di.watch
+	if w == nil {
+		return
+	}
+	// only update an existing row (closing the row stops updates)
+	info, ok := gdi.ed.ERowInfo(GoDebugWatchRowName)
+	if !ok || len(info.ERows) == 0 {
+		return
+	}
+
+	// don't rewrite the same content
+	key := fmt.Sprintf("%v_%v_%v", di.lastArrivalIndex, di.selected.arrivalIndex, len(w.entries))
+	if key == w.outputKey {
+		return
+	}
+	w.outputKey = key
+
+	entries := w.entries
+
+	afd := di.Afds[w.fileIndex]
+	sb := &strings.Builder{}
+	fmt.Fprintf(sb, "watch: %q: %v\n", w.word, afd.Filename)
+	fmt.Fprintf(sb, "entries: %v, selected step: #%v\n", len(entries), di.selected.arrivalIndex)
+	if len(entries) > gdWatchMaxEntries {
+		fmt.Fprintf(sb, "(showing last %v entries)\n", gdWatchMaxEntries)
+		entries = entries[len(entries)-gdWatchMaxEntries:]
+	}
+	base := filepath.Base(afd.Filename)
+	for _, e := range entries {
+		dlm := e.msg.dbgLineMsg
+		s := godebug.StringifyItem(e.value)
+		g := "g?" // goroutine ids not enabled
+		if dlm.GoroutineId > 0 {
+			g = fmt.Sprintf("g%v", dlm.GoroutineId)
+		}
+		fmt.Fprintf(sb, "#%v\t%v\t%v:%v\t%v\n", e.msg.arrivalIndex, g, base, e.line, s)
+	}
+
+	for _, erow := range info.ERows {
+		erow.Row.TextArea.SetStrClearHistory(sb.String())
+	}
+}
+
+//----------
+
+// Selects the step by its arrival index, and shows the line.
+func (gdi *GoDebugInstance) SelectArrivalIndex(arrivalIndex int, rowPos *ui.RowPos) error {
+	if err := gdi.selectArrivalIndex2(arrivalIndex); err != nil {
+		return err
+	}
+	gdi.updateUIShowLine(rowPos)
+	return nil
+}
+
+func (gdi *GoDebugInstance) selectArrivalIndex2(arrivalIndex int) error {
+	if !gdi.dataLock() {
+		return fmt.Errorf("no godebug session")
+	}
+	defer gdi.dataUnlock()
+
+	di := gdi.data.dataIndex
+	for findex, file := range di.Files {
+		for line, lm := range file.LinesMsgs {
+			k, eqK, _ := lm.findIndex(arrivalIndex)
+			if eqK {
+				di.selected.arrivalIndex = arrivalIndex
+				di.selected.fileIndex = findex
+				di.selected.lineIndex = line
+				di.selected.lineStepIndex = k
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("step not found: #%v", arrivalIndex)
+}
+
+//----------
+
+// Returns the line number (1-based) of the offset if that line contains the word, or zero otherwise.
+func gdWatchWordLine(src []byte, offset int, word string) int {
+	if offset < 0 || offset > len(src) || word == "" {
+		return 0
+	}
+	ls := bytes.LastIndexByte(src[:offset], '\n') + 1
+	le := len(src)
+	if i := bytes.IndexByte(src[offset:], '\n'); i >= 0 {
+		le = offset + i
+	}
+	rd := iorw.NewBytesReadWriter(src)
+	w := []byte(word)
+	for i := ls; i < le; {
+		k := bytes.Index(src[i:le], w)
+		if k < 0 {
+			break
+		}
+		if iorw.WordIsolated(rd, i+k, len(w)) {
+			return bytes.Count(src[:ls], []byte("\n")) + 1
+		}
+		i += k + 1
+	}
+	return 0
+}
+
+//----------
+
+// Returns the nodes (outer first) that the annotator could have used to build the item at the offset.
+func gdWatchOffsetNodes(tfile *token.File, file *ast.File, offset int) []ast.Node {
+	isOffset := func(p token.Pos) bool {
+		return p.IsValid() && tfile.Offset(p) == offset
+	}
+	nodes := []ast.Node{}
+	ast.Inspect(file, func(n ast.Node) bool {
+		if n == nil {
+			return false
+		}
+		if tfile.Offset(n.Pos()) > offset || tfile.Offset(n.End()) < offset {
+			return false
+		}
+		switch t := n.(type) {
+		case *ast.RangeStmt:
+			if isOffset(t.X.End()) {
+				nodes = append(nodes, t)
+			}
+		case ast.Stmt, ast.Expr, *ast.ValueSpec:
+			if isOffset(t.End()) {
+				nodes = append(nodes, t)
+			}
+		}
+		return true
+	})
+	return nodes
+}
+
+// Walks the node and its annotated item together, returning the item of the watched word.
+func gdWatchNodeItem(node ast.Node, item debug.Item, word string) (debug.Item, bool) {
+	switch t := node.(type) {
+	case *ast.AssignStmt:
+		switch u := item.(type) {
+		case *debug.ItemAssign:
+			if v, ok := gdWatchExprsItem(t.Lhs, gdWatchListItems(u.Lhs), word); ok {
+				return v, true
+			}
+			return gdWatchExprsItem(t.Rhs, gdWatchListItems(u.Rhs), word)
+		case *debug.ItemList: // lhs not annotated
+			return gdWatchExprsItem(t.Rhs, u.List, word)
+		}
+	case *ast.ValueSpec:
+		if u, ok := item.(*debug.ItemAssign); ok {
+			lhs := []ast.Expr{}
+			for _, id := range t.Names {
+				lhs = append(lhs, id)
+			}
+			if v, ok := gdWatchExprsItem(lhs, gdWatchListItems(u.Lhs), word); ok {
+				return v, true
+			}
+			return gdWatchExprsItem(t.Values, gdWatchListItems(u.Rhs), word)
+		}
+	case *ast.RangeStmt:
+		if u, ok := item.(*debug.ItemAssign); ok {
+			lhs := []ast.Expr{}
+			if t.Key != nil {
+				lhs = append(lhs, t.Key)
+			}
+			if t.Value != nil {
+				lhs = append(lhs, t.Value)
+			}
+			return gdWatchExprsItem(lhs, gdWatchListItems(u.Lhs), word)
+		}
+	case *ast.FuncType: // params
+		if u, ok := item.(*debug.ItemList); ok && t.Params != nil {
+			params := []ast.Expr{}
+			for _, f := range t.Params.List {
+				if len(f.Names) == 0 {
+					params = append(params, nil) // named by the annotator
+				}
+				for _, id := range f.Names {
+					params = append(params, id)
+				}
+			}
+			return gdWatchExprsItem(params, u.List, word)
+		}
+	case *ast.IncDecStmt:
+		if u, ok := item.(*debug.ItemAssign); ok {
+			return gdWatchExprsItem([]ast.Expr{t.X}, gdWatchListItems(u.Lhs), word)
+		}
+	case *ast.ReturnStmt:
+		if u, ok := item.(*debug.ItemList); ok {
+			return gdWatchExprsItem(t.Results, u.List, word)
+		}
+	case *ast.SendStmt:
+		if u, ok := item.(*debug.ItemSend); ok {
+			return gdWatchExprsItem([]ast.Expr{t.Chan, t.Value}, []debug.Item{u.Chan, u.Value}, word)
+		}
+	case *ast.ExprStmt:
+		return gdWatchExprItem(t.X, item, word)
+	case ast.Expr: // conditions
+		return gdWatchExprItem(t, item, word)
+	}
+	return nil, false
+}
+
+func gdWatchExprsItem(es []ast.Expr, items []debug.Item, word string) (debug.Item, bool) {
+	if len(es) != len(items) {
+		return nil, false
+	}
+	for i, e := range es {
+		if v, ok := gdWatchExprItem(e, items[i], word); ok {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+func gdWatchExprItem(e ast.Expr, item debug.Item, word string) (debug.Item, bool) {
+	if e == nil || item == nil {
+		return nil, false
+	}
+
+	// the whole expression is the watched word (ex: "a", "a.b", "a[i]")
+	if types.ExprString(e) == word {
+		var v debug.Item
+		switch u := item.(type) {
+		case *debug.ItemValue:
+			v = u
+		case *debug.ItemSelector:
+			v = u.Sel
+		case *debug.ItemIndex:
+			v = u.Result
+		case *debug.ItemIndex2:
+			v = u.Result
+		case *debug.ItemCall:
+			v = u.Result
+		case *debug.ItemBinary:
+			v = u.Result
+		case *debug.ItemUnary:
+			v = u.Result
+		}
+		if v != nil {
+			return v, true
+		}
+	}
+
+	switch t := e.(type) {
+	case *ast.ParenExpr:
+		if u, ok := item.(*debug.ItemParen); ok {
+			return gdWatchExprItem(t.X, u.X, word)
+		}
+	case *ast.BinaryExpr:
+		if u, ok := item.(*debug.ItemBinary); ok {
+			return gdWatchExprsItem([]ast.Expr{t.X, t.Y}, []debug.Item{u.X, u.Y}, word)
+		}
+	case *ast.UnaryExpr:
+		switch u := item.(type) {
+		case *debug.ItemUnary:
+			return gdWatchExprItem(t.X, u.X, word)
+		case *debug.ItemUnaryEnter:
+			return gdWatchExprItem(t.X, u.X, word)
+		}
+	case *ast.StarExpr:
+		if u, ok := item.(*debug.ItemUnary); ok {
+			return gdWatchExprItem(t.X, u.X, word)
+		}
+	case *ast.CallExpr:
+		switch u := item.(type) {
+		case *debug.ItemCall:
+			return gdWatchExprsItem(t.Args, gdWatchListItems(u.Args), word)
+		case *debug.ItemCallEnter:
+			return gdWatchExprsItem(t.Args, gdWatchListItems(u.Args), word)
+		}
+	case *ast.IndexExpr:
+		if u, ok := item.(*debug.ItemIndex); ok {
+			return gdWatchExprsItem([]ast.Expr{t.X, t.Index}, []debug.Item{u.Expr, u.Index}, word)
+		}
+	case *ast.SliceExpr:
+		if u, ok := item.(*debug.ItemIndex2); ok {
+			es := []ast.Expr{t.X, t.Low, t.High, t.Max}
+			return gdWatchExprsItem(es, []debug.Item{u.Expr, u.Low, u.High, u.Max}, word)
+		}
+	case *ast.SelectorExpr:
+		if u, ok := item.(*debug.ItemSelector); ok {
+			return gdWatchExprItem(t.X, u.X, word)
+		}
+	case *ast.KeyValueExpr:
+		if u, ok := item.(*debug.ItemKeyValue); ok {
+			return gdWatchExprsItem([]ast.Expr{t.Key, t.Value}, []debug.Item{u.Key, u.Value}, word)
+		}
+	case *ast.TypeAssertExpr:
+		if u, ok := item.(*debug.ItemTypeAssert); ok {
+			return gdWatchExprItem(t.X, u.X, word)
+		}
+	case *ast.CompositeLit:
+		if u, ok := item.(*debug.ItemLiteral); ok {
+			return gdWatchExprsItem(t.Elts, gdWatchListItems(u.Fields), word)
+		}
+	}
+	return nil, false
+}
+
+func gdWatchListItems(l *debug.ItemList) []debug.Item {
+	if l == nil {
+		return nil
+	}
+	return l.List
+}
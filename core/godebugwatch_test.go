@@ -0,0 +1,88 @@
+package core
+
+import (
+	"bytes"
+	"go/token"
+	"testing"
+
+	"github.com/jmigpin/editor/core/godebug"
+	"github.com/jmigpin/editor/core/godebug/debug"
+)
+
+func TestGDWatchWordLine(t *testing.T) {
+	src := []byte("a := 1\nab := a + 2\nb := ab\n")
+	type tc struct {
+		offset int
+		word   string
+		line   int
+	}
+	for _, u := range []tc{
+		{3, "a", 1},
+		{10, "a", 2},
+		{19, "a", 0}, // only "ab" in line 3
+		{19, "ab", 3},
+		{10, "b", 0},
+	} {
+		line := gdWatchWordLine(src, u.offset, u.word)
+		if line != u.line {
+			t.Fatalf("%+v: got %v", u, line)
+		}
+	}
+}
+
+func TestGDWatchValue(t *testing.T) {
+	src := []byte(`package main
+func f(a int, p *P) int {
+	b := a + 1
+	p.c = b
+	for i, v := range p.s {
+		_ = i
+	}
+	return b
+}
+`)
+	offsetAfter := func(s string) int {
+		return bytes.Index(src, []byte(s)) + len(s)
+	}
+	iv := func(s string) debug.Item { return debug.IVs(s) }
+	params := debug.IL(iv("1"), iv("&P{}"))
+	assign := debug.IA(
+		debug.IL(iv("2")),
+		debug.IL(debug.IB(iv("2"), int(token.ADD), iv("1"), iv("1"))),
+	)
+	assign2 := debug.IA(
+		debug.IL(debug.ISel(iv("&P{}"), iv("2"))),
+		debug.IL(iv("2")),
+	)
+	rang := debug.IA(debug.IL(iv("0"), iv("7")), debug.IL(debug.IVl(1)))
+	ret := debug.IL(iv("2"))
+
+	type tc struct {
+		offset int
+		item   debug.Item
+		word   string
+		out    string // empty: no match
+	}
+	for i, u := range []tc{
+		{offsetAfter("*P) int"), params, "a", "1"},
+		{offsetAfter("*P) int"), params, "p", "&P{}"},
+		{offsetAfter("a + 1"), assign, "b", "2"},
+		{offsetAfter("a + 1"), assign, "a", "1"},
+		{offsetAfter("a + 1"), assign, "f", ""},
+		{offsetAfter("p.c = b"), assign2, "p", "&P{}"},
+		{offsetAfter("p.c = b"), assign2, "p.c", "2"},
+		{offsetAfter("p.c = b"), assign2, "c", ""},
+		{offsetAfter("range p.s"), rang, "v", "7"},
+		{offsetAfter("return b"), ret, "b", "2"},
+	} {
+		w := NewGDWatch(0, u.word, src)
+		v, ok := w.debugIndexValue(i, u.offset, u.item)
+		out := ""
+		if ok {
+			out = godebug.StringifyItem(v)
+		}
+		if out != u.out {
+			t.Fatalf("%v: %+v: got %q", i, u, out)
+		}
+	}
+}
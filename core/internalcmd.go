@@ -163,6 +163,27 @@ func internalCmd(ed *Editor, part *toolbarparser.Part, erow *ERow) {
 		run(detach, node, func() { fn(e) })
 	}
 
+	// editor events
+	preEv := func(e *ERow) {
+		ev := &PreInternalCmdEEvent{ERow: e, Part: part}
+		ed.EEvents.emit(PreInternalCmdEEventId, ev)
+	}
+	runFn := func(cmd *InternalCmd, args *InternalCmdArgs) {
+		err := cmd.Fn(args)
+		if err != nil {
+			ed.Errorf("%v: %v", arg0, err)
+		}
+		// editor events
+		ev := &PostInternalCmdEEvent{ERow: args.ERow, Part: part, Err: err}
+		if cmd.Detach {
+			ed.UI.RunOnUIGoRoutine(func() {
+				ed.EEvents.emit(PostInternalCmdEEventId, ev)
+			})
+		} else {
+			ed.EEvents.emit(PostInternalCmdEEventId, ev)
+		}
+	}
+
 	// internal cmds
 	cmd, ok := InternalCmds[arg0]
 	if ok {
@@ -173,20 +194,20 @@ func internalCmd(ed *Editor, part *toolbarparser.Part, erow *ERow) {
 				ed.Errorf("%s:  root toolbar only command", arg0)
 				return
 			}
+			preEv(nil)
 			run(cmd.Detach, ed.UI.Root, func() {
-				if err := cmd.Fn(args); err != nil {
-					ed.Errorf("%v: %v", arg0, err)
-				}
+				runFn(cmd, args)
 			})
 		} else {
+			if e := currentERow(); e != nil {
+				preEv(e)
+			}
 			rowCmd(cmd.Detach, func(e *ERow) {
 				ctx, cancel := e.newInternalCmdCtx()
 				defer cancel()
 				args.ERow = e
 				args.Ctx = ctx
-				if err := cmd.Fn(args); err != nil {
-					ed.Errorf("%v: %v", arg0, err)
-				}
+				runFn(cmd, args)
 			})
 		}
 		return
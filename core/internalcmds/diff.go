@@ -0,0 +1,25 @@
+package internalcmds
+
+import (
+	"fmt"
+
+	"github.com/jmigpin/editor/core"
+)
+
+func Diff(args0 *core.InternalCmdArgs) error {
+	args := args0.Part.Args[1:]
+	if len(args) != 2 {
+		return fmt.Errorf("expecting 2 arguments")
+	}
+	a, b := args[0].UnquotedStr(), args[1].UnquotedStr()
+	_, err := core.DiffCmd(args0.Ed, args0.ERow, a, b)
+	return err
+}
+
+func NextDiff(args0 *core.InternalCmdArgs) error {
+	return core.DiffViewGotoChange(args0.ERow, true)
+}
+
+func PrevDiff(args0 *core.InternalCmdArgs) error {
+	return core.DiffViewGotoChange(args0.ERow, false)
+}
@@ -0,0 +1,20 @@
+package internalcmds
+
+import (
+	"github.com/jmigpin/editor/core"
+)
+
+func DiffDisk(args0 *core.InternalCmdArgs) error {
+	return core.DiffDisk(args0.ERow)
+}
+
+func MergeDisk(args0 *core.InternalCmdArgs) error {
+	n, err := core.MergeDisk(args0.ERow)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		args0.Ed.Messagef("MergeDisk: %v: %v conflict(s)", args0.ERow.Info.Name(), n)
+	}
+	return nil
+}
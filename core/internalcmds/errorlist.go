@@ -0,0 +1,13 @@
+package internalcmds
+
+import (
+	"github.com/jmigpin/editor/core"
+)
+
+func NextError(args *core.InternalCmdArgs) error {
+	return args.Ed.ErrorList.Next()
+}
+
+func PrevError(args *core.InternalCmdArgs) error {
+	return args.Ed.ErrorList.Prev()
+}
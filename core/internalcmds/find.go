@@ -26,6 +26,8 @@ func Find(args0 *core.InternalCmdArgs) error {
 		str = strings.TrimSpace(s)
 	}
 
+	ci := erow.Row.TextArea.TextCursor.Index()
+
 	found, err := textutil.Find(args0.Ctx, erow.Row.TextArea.TextEdit, str)
 	if err != nil {
 		return err
@@ -37,6 +39,7 @@ func Find(args0 *core.InternalCmdArgs) error {
 	// flash
 	tc := erow.Row.TextArea.TextCursor
 	a, b := tc.SelectionIndexes()
+	erow.Ed.JumpList.Jump(erow, ci, erow.Info.Name(), a)
 	erow.MakeRangeVisibleAndFlash(a, b-a)
 
 	return nil
@@ -0,0 +1,35 @@
+package internalcmds
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/jmigpin/editor/core"
+)
+
+func GitDiff(args0 *core.InternalCmdArgs) error {
+	erow := args0.ERow
+	if !erow.Info.IsFileButNotDir() {
+		return fmt.Errorf("not a file")
+	}
+	ed := args0.Ed
+	filename := erow.Info.Name()
+	erow2, isNew := ed.ExistingOrNewERow("+GitDiff")
+	if !isNew {
+		erow2.Flash()
+	}
+	erow2.Row.TextArea.SetStrClearHistory("")
+	erow2.Exec.Start(func(ctx context.Context, w io.Writer) error {
+		return core.GitDiff(ctx, filename, w, ed.HomeVars.Encode)
+	})
+	return nil
+}
+
+func GitRevertHunk(args0 *core.InternalCmdArgs) error {
+	return args0.Ed.Git.RevertHunk(args0.ERow)
+}
+
+func GitBlame(args0 *core.InternalCmdArgs) error {
+	return args0.Ed.Git.ToggleBlame(args0.ERow)
+}
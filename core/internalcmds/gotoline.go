@@ -31,6 +31,7 @@ func GotoLine(args0 *core.InternalCmdArgs) error {
 
 	// goto index
 	tc := ta.TextCursor
+	erow.Ed.JumpList.Jump(erow, tc.Index(), erow.Info.Name(), index)
 	tc.SetSelectionOff()
 	tc.SetIndex(index)
 
@@ -23,6 +23,8 @@ func init() {
 	ic.Set(&core.InternalCmd{"MaximizeRow", MaximizeRow, false, false})
 
 	ic.Set(&core.InternalCmd{"NewFile", NewFile, false, false})
+	ic.Set(&core.InternalCmd{"RenameFile", RenameFile, false, false})
+	ic.Set(&core.InternalCmd{"DeleteFile", DeleteFile, false, false})
 	ic.Set(&core.InternalCmd{"Save", Save, false, false})
 	ic.Set(&core.InternalCmd{"SaveAllFiles", SaveAllFiles, true, false})
 
@@ -32,11 +34,26 @@ func init() {
 
 	ic.Set(&core.InternalCmd{"Stop", Stop, false, false})
 	ic.Set(&core.InternalCmd{"Clear", Clear, false, false})
+	ic.Set(&core.InternalCmd{"Shell", Shell, false, false})
+	ic.Set(&core.InternalCmd{"Watch", Watch, false, false})
 
 	ic.Set(&core.InternalCmd{"Find", Find, false, false})
 	ic.Set(&core.InternalCmd{"Replace", Replace, false, false})
 	ic.Set(&core.InternalCmd{"GotoLine", GotoLine, false, false})
 
+	ic.Set(&core.InternalCmd{"Pipe", Pipe, false, false})
+	ic.Set(&core.InternalCmd{"PipeInsert", PipeInsert, false, false})
+	ic.Set(&core.InternalCmd{"PipeToRow", PipeToRow, false, false})
+
+	ic.Set(&core.InternalCmd{"GoBack", GoBack, false, false})
+	ic.Set(&core.InternalCmd{"GoForward", GoForward, false, false})
+	ic.Set(&core.InternalCmd{"NextError", NextError, false, false})
+	ic.Set(&core.InternalCmd{"PrevError", PrevError, false, false})
+
+	ic.Set(&core.InternalCmd{"Mark", Mark, false, false})
+	ic.Set(&core.InternalCmd{"GotoMark", GotoMark, false, false})
+	ic.Set(&core.InternalCmd{"ListMarks", ListMarks, false, false})
+
 	ic.Set(&core.InternalCmd{"CopyFilePosition", CopyFilePosition, false, false})
 	ic.Set(&core.InternalCmd{"RuneCodes", RuneCodes, false, false})
 	ic.Set(&core.InternalCmd{"FontRunes", FontRunes, false, false})
@@ -46,9 +63,23 @@ func init() {
 	ic.Set(&core.InternalCmd{"OpenFilemanager", OpenFilemanager, false, false})
 
 	ic.Set(&core.InternalCmd{"ListDir", ListDir, false, false})
+	ic.Set(&core.InternalCmd{"OpenFile", OpenFile, false, false})
+
+	ic.Set(&core.InternalCmd{"Diff", Diff, false, false})
+	ic.Set(&core.InternalCmd{"NextDiff", NextDiff, false, false})
+	ic.Set(&core.InternalCmd{"PrevDiff", PrevDiff, false, false})
+	ic.Set(&core.InternalCmd{"DiffDisk", DiffDisk, false, false})
+	ic.Set(&core.InternalCmd{"MergeDisk", MergeDisk, false, false})
+
+	ic.Set(&core.InternalCmd{"GitDiff", GitDiff, false, false})
+	ic.Set(&core.InternalCmd{"GitRevertHunk", GitRevertHunk, false, false})
+	ic.Set(&core.InternalCmd{"GitBlame", GitBlame, false, false})
 
 	ic.Set(&core.InternalCmd{"GoRename", GoRename, false, false})
 	ic.Set(&core.InternalCmd{"GoDebug", GoDebug, false, false})
+	ic.Set(&core.InternalCmd{"GoDebugWatch", GoDebugWatch, false, false})
+	ic.Set(&core.InternalCmd{"GoDebugTest", GoDebugTest, false, false})
+	ic.Set(&core.InternalCmd{"GoDebugCoverage", GoDebugCoverage, false, false})
 
 	// Deprecated: in favor of "LspCloseAll"
 	ic.Set(&core.InternalCmd{"LSProtoCloseAll", LSProtoCloseAll, false, false})
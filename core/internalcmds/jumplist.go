@@ -0,0 +1,13 @@
+package internalcmds
+
+import (
+	"github.com/jmigpin/editor/core"
+)
+
+func GoBack(args *core.InternalCmdArgs) error {
+	return args.Ed.JumpList.Back(args.ERow)
+}
+
+func GoForward(args *core.InternalCmdArgs) error {
+	return args.Ed.JumpList.Forward(args.ERow)
+}
@@ -14,7 +14,7 @@ func ListDir(args0 *core.InternalCmdArgs) error {
 		return fmt.Errorf("not a directory")
 	}
 
-	tree, hidden := false, false
+	tree, hidden, dirTree := false, false, false
 
 	args := part.Args[1:]
 	for _, a := range args {
@@ -24,9 +24,16 @@ func ListDir(args0 *core.InternalCmdArgs) error {
 			tree = true
 		case "-hidden":
 			hidden = true
+		case "-tree":
+			dirTree = true
 		}
 	}
 
+	if dirTree {
+		return core.EnableDirTree(erow, nil)
+	}
+	core.DisableDirTree(erow)
+
 	core.ListDirERow(erow, erow.Info.Name(), tree, hidden)
 
 	return nil
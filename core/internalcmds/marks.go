@@ -0,0 +1,53 @@
+package internalcmds
+
+import (
+	"fmt"
+
+	"github.com/jmigpin/editor/core"
+	"github.com/jmigpin/editor/util/parseutil"
+)
+
+func Mark(args0 *core.InternalCmdArgs) error {
+	erow := args0.ERow
+	args := args0.Part.Args[1:]
+	if len(args) != 1 {
+		return fmt.Errorf("expecting 1 argument")
+	}
+	if !erow.Info.IsFileButNotDir() {
+		return fmt.Errorf("not a file")
+	}
+	name := args[0].UnquotedStr()
+	ci := erow.Row.TextArea.TextCursor.Index()
+	erow.Ed.Marks.Set(name, erow.Info.Name(), ci)
+	return nil
+}
+
+func GotoMark(args0 *core.InternalCmdArgs) error {
+	ed := args0.Ed
+	args := args0.Part.Args[1:]
+	if len(args) != 1 {
+		return fmt.Errorf("expecting 1 argument")
+	}
+	name := args[0].UnquotedStr()
+	m, ok := ed.Marks.Get(name)
+	if !ok {
+		return fmt.Errorf("mark not found: %v", name)
+	}
+	conf := &core.OpenFileERowConfig{
+		FilePos:             &parseutil.FilePos{Filename: m.Filename, Offset: m.Offset},
+		RowPos:              ed.GoodRowPos(),
+		FlashVisibleOffsets: true,
+		NewIfNotExistent:    true,
+		JumpList:            true,
+	}
+	core.OpenFileERow(ed, conf)
+	return nil
+}
+
+func ListMarks(args0 *core.InternalCmdArgs) error {
+	ed := args0.Ed
+	erow, _ := ed.ExistingOrNewERow("+Marks")
+	erow.Row.TextArea.SetBytesClearPos(ed.Marks.ListBytes())
+	erow.Flash()
+	return nil
+}
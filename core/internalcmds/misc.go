@@ -138,6 +138,37 @@ func GoDebug(args *core.InternalCmdArgs) error {
 	return args.Ed.GoDebug.Start(args.ERow, args2)
 }
 
+func GoDebugWatch(args *core.InternalCmdArgs) error {
+	word := ""
+	if a := args.Part.Args[1:]; len(a) > 0 {
+		if len(a) > 1 {
+			return fmt.Errorf("expecting at most 1 argument")
+		}
+		word = a[0].UnquotedStr()
+	}
+	return args.Ed.GoDebug.Watch(args.ERow, word)
+}
+
+func GoDebugTest(args *core.InternalCmdArgs) error {
+	a := args.Part.Args[1:]
+	if len(a) > 1 {
+		return fmt.Errorf("expecting at most 1 argument")
+	}
+	name, reset := "", false
+	if len(a) == 1 {
+		name = a[0].UnquotedStr()
+		if name == "-all" {
+			name, reset = "", true
+		}
+	}
+	return args.Ed.GoDebug.FilterTest(name, reset)
+}
+
+func GoDebugCoverage(args *core.InternalCmdArgs) error {
+	args.Ed.GoDebug.ToggleCoverage()
+	return nil
+}
+
 //----------
 
 func ColorTheme(args *core.InternalCmdArgs) error {
@@ -178,3 +209,19 @@ func CtxutilCallsState(args *core.InternalCmdArgs) error {
 	args.Ed.Messagef("%s", s)
 	return nil
 }
+
+//----------
+
+func Shell(args *core.InternalCmdArgs) error {
+	return core.ShellCmd(args.ERow, args.Part)
+}
+
+func Watch(args *core.InternalCmdArgs) error {
+	return core.WatchCmdFromPart(args.ERow, args.Part)
+}
+
+//----------
+
+func OpenFile(args *core.InternalCmdArgs) error {
+	return core.OpenFileCmdFromPart(args.ERow, args.Part)
+}
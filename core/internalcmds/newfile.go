@@ -18,9 +18,15 @@ func NewFile(args *core.InternalCmdArgs) error {
 	erow := args.ERow
 
 	// directory
-	dir := erow.Info.Name()
-	if !erow.Info.IsDir() {
-		dir = filepath.Base(dir)
+	dir := erow.Info.Dir()
+	if erow.Info.IsDir() {
+		// directory of the entry under the cursor (tree mode)
+		if u, ok := core.DirRowEntryAtCursor(erow); ok && core.IsDirTree(erow) {
+			dir = u
+			if fi, err := os.Stat(u); err == nil && !fi.IsDir() {
+				dir = filepath.Dir(u)
+			}
+		}
 	}
 
 	filename := filepath.Join(dir, name)
@@ -35,6 +41,10 @@ func NewFile(args *core.InternalCmdArgs) error {
 	}
 	f.Close()
 
+	if erow.Info.IsDir() {
+		core.RefreshDirRow(erow)
+	}
+
 	info := args.Ed.ReadERowInfo(filename)
 
 	rowPos := erow.Row.PosBelow()
@@ -43,3 +53,67 @@ func NewFile(args *core.InternalCmdArgs) error {
 
 	return nil
 }
+
+//----------
+
+// Renames the entry under the cursor of a directory row. The new name is relative to the entry directory.
+func RenameFile(args *core.InternalCmdArgs) error {
+	if len(args.Part.Args) != 2 {
+		return fmt.Errorf("missing new name")
+	}
+	name := args.Part.Args[1].UnquotedStr()
+
+	erow := args.ERow
+	filename, ok := core.DirRowEntryAtCursor(erow)
+	if !ok {
+		return fmt.Errorf("no directory row entry at the cursor")
+	}
+	if !filepath.IsAbs(name) {
+		name = filepath.Join(filepath.Dir(filename), name)
+	}
+	if _, err := os.Stat(name); !os.IsNotExist(err) {
+		return fmt.Errorf("already exists: %v", name)
+	}
+	if err := os.Rename(filename, name); err != nil {
+		return err
+	}
+	core.RefreshDirRow(erow)
+	return nil
+}
+
+// Deletes the entry under the cursor of a directory row. Without "-confirm", only shows what would be deleted.
+func DeleteFile(args *core.InternalCmdArgs) error {
+	erow := args.ERow
+	filename, ok := core.DirRowEntryAtCursor(erow)
+	if !ok {
+		return fmt.Errorf("no directory row entry at the cursor")
+	}
+	fi, err := os.Lstat(filename)
+	if err != nil {
+		return err
+	}
+
+	confirm := false
+	for _, a := range args.Part.Args[1:] {
+		switch s := a.UnquotedStr(); s {
+		case "-confirm":
+			confirm = true
+		default:
+			return fmt.Errorf("unknown flag: %v", s)
+		}
+	}
+	if !confirm {
+		what := "file"
+		if fi.IsDir() {
+			what = "directory (and its contents)"
+		}
+		args.Ed.Messagef("DeleteFile: run with -confirm to delete %v: %v", what, filename)
+		return nil
+	}
+
+	if err := os.RemoveAll(filename); err != nil {
+		return err
+	}
+	core.RefreshDirRow(erow)
+	return nil
+}
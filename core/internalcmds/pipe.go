@@ -0,0 +1,17 @@
+package internalcmds
+
+import (
+	"github.com/jmigpin/editor/core"
+)
+
+func Pipe(args *core.InternalCmdArgs) error {
+	return core.PipeCmd(args.ERow, args.Part, core.PipeCmdReplace)
+}
+
+func PipeInsert(args *core.InternalCmdArgs) error {
+	return core.PipeCmd(args.ERow, args.Part, core.PipeCmdInsert)
+}
+
+func PipeToRow(args *core.InternalCmdArgs) error {
+	return core.PipeCmd(args.ERow, args.Part, core.PipeCmdToRow)
+}
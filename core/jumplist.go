@@ -0,0 +1,144 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/jmigpin/editor/util/iout/iorw"
+	"github.com/jmigpin/editor/util/mathutil"
+	"github.com/jmigpin/editor/util/parseutil"
+)
+
+// Positions visited before jumps (across files, or far within a file) done by content and internal commands. Allows going back/forward.
+type JumpList struct {
+	ed      *Editor
+	back    []*JumpPos
+	forward []*JumpPos
+}
+
+func NewJumpList(ed *Editor) *JumpList {
+	return &JumpList{ed: ed}
+}
+
+// Number of entries kept in each direction.
+var jumpListMax = 100
+
+// Jumps with less lines of distance (same file) are not recorded.
+var jumpListFarLines = 10
+
+//----------
+
+// Records the erow position as a jump origin if the destination is in another file or far within the same file.
+func (jl *JumpList) Jump(from *ERow, fromOffset int, toFilename string, toOffset int) {
+	if from == nil || from.Info.IsSpecial() {
+		return
+	}
+	if from.Info.Name() == toFilename {
+		rd := from.Row.TextArea.TextCursor.RW()
+		if !jumpIsFar(rd, fromOffset, toOffset) {
+			return
+		}
+	}
+	jl.push(&jl.back, &JumpPos{from.Info.Name(), fromOffset})
+	jl.forward = nil
+}
+
+// Records a jump from the active erow cursor. Should be called before the cursor is moved.
+func (jl *JumpList) JumpFromActive(toFilename string, toOffset int) {
+	erow, ok := jl.ed.ActiveERow()
+	if !ok {
+		return
+	}
+	ci := erow.Row.TextArea.TextCursor.Index()
+	jl.Jump(erow, ci, toFilename, toOffset)
+}
+
+//----------
+
+// The current erow (can be nil) position is kept to be able to return.
+func (jl *JumpList) Back(cur *ERow) error {
+	return jl.move(cur, &jl.back, &jl.forward, "back")
+}
+
+func (jl *JumpList) Forward(cur *ERow) error {
+	return jl.move(cur, &jl.forward, &jl.back, "forward")
+}
+
+func (jl *JumpList) move(cur *ERow, src, dst *[]*JumpPos, name string) error {
+	if len(*src) == 0 {
+		return fmt.Errorf("jumplist: no %v positions", name)
+	}
+	// pop
+	k := len(*src) - 1
+	pos := (*src)[k]
+	*src = (*src)[:k]
+
+	// keep the current position to be able to return
+	if cur != nil && !cur.Info.IsSpecial() {
+		ci := cur.Row.TextArea.TextCursor.Index()
+		jl.push(dst, &JumpPos{cur.Info.Name(), ci})
+	}
+
+	return jl.open(pos)
+}
+
+func (jl *JumpList) open(pos *JumpPos) error {
+	info := jl.ed.ReadERowInfo(pos.Filename)
+
+	// use the active erow if it is of this file, or the first in the ui
+	var erow *ERow
+	if e, ok := jl.ed.ActiveERow(); ok && e.Info == info {
+		erow = e
+	} else if len(info.ERows) > 0 {
+		erow = info.ERowsInUIOrder()[0]
+	} else {
+		rowPos := jl.ed.GoodRowPos()
+		e, err := info.NewERow(rowPos)
+		if err != nil {
+			return err
+		}
+		erow = e
+	}
+
+	ta := erow.Row.TextArea
+	offset := mathutil.Smallest(pos.Offset, ta.TextCursor.RW().Max())
+	ta.TextCursor.SetSelectionOff()
+	ta.TextCursor.SetIndex(offset)
+	erow.Info.UpdateActiveRowState(erow)
+	erow.MakeIndexVisibleAndFlash(offset)
+	return nil
+}
+
+//----------
+
+func (jl *JumpList) push(s *[]*JumpPos, pos *JumpPos) {
+	// don't repeat the last position
+	if k := len(*s) - 1; k >= 0 && *(*s)[k] == *pos {
+		return
+	}
+	*s = append(*s, pos)
+	if len(*s) > jumpListMax {
+		*s = (*s)[len(*s)-jumpListMax:]
+	}
+}
+
+//----------
+
+type JumpPos struct {
+	Filename string
+	Offset   int
+}
+
+//----------
+
+func jumpIsFar(rd iorw.Reader, a, b int) bool {
+	la, _, err1 := parseutil.IndexLineColumn(rd, a)
+	lb, _, err2 := parseutil.IndexLineColumn(rd, b)
+	if err1 != nil || err2 != nil {
+		return true
+	}
+	d := la - lb
+	if d < 0 {
+		d = -d
+	}
+	return d >= jumpListFarLines
+}
@@ -0,0 +1,34 @@
+package core
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jmigpin/editor/util/iout/iorw"
+)
+
+func TestJumpListPush(t *testing.T) {
+	jl := NewJumpList(nil)
+	max := jumpListMax
+	defer func() { jumpListMax = max }()
+	jumpListMax = 3
+
+	s := []*JumpPos{}
+	for _, u := range []int{1, 2, 2, 3, 4} {
+		jl.push(&s, &JumpPos{"a", u})
+	}
+	if len(s) != 3 || s[0].Offset != 2 || s[1].Offset != 3 || s[2].Offset != 4 {
+		t.Fatalf("%v %v %v", s[0], s[1], s[2])
+	}
+}
+
+func TestJumpIsFar(t *testing.T) {
+	str := strings.Repeat("line\n", 30)
+	rd := iorw.NewStringReader(str)
+	if jumpIsFar(rd, 0, 5*(jumpListFarLines-1)) {
+		t.Fatal("near")
+	}
+	if !jumpIsFar(rd, 5*jumpListFarLines, 0) {
+		t.Fatal("far")
+	}
+}
@@ -310,3 +310,28 @@ func newTmpFiles(t *testing.T) *osutil.TmpFiles {
 	t.Logf("tf.Dir: %v\n", tf.Dir)
 	return tf
 }
+
+//----------
+
+func TestFoldingRangeOffsets(t *testing.T) {
+	src := "func f() {\n\ta()\n}\n"
+	rd := iorw.NewStringReader(src)
+	c1, c2 := 10, 0
+	fr := &FoldingRange{StartLine: 0, StartCharacter: &c1, EndLine: 2, EndCharacter: &c2}
+	s, e, err := FoldingRangeOffsets(rd, fr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s != 10 || e != 16 {
+		t.Fatal(s, e)
+	}
+	// missing characters are at the end of the lines
+	fr = &FoldingRange{StartLine: 0, EndLine: 1}
+	s, e, err = FoldingRangeOffsets(rd, fr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s != 10 || e != 15 {
+		t.Fatal(s, e)
+	}
+}
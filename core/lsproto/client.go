@@ -517,6 +517,25 @@ func (cli *Client) TextDocumentRename(ctx context.Context, filename string, pos
 
 //----------
 
+func (cli *Client) TextDocumentFoldingRange(ctx context.Context, filename string) ([]*FoldingRange, error) {
+	// https://microsoft.github.io/language-server-protocol/specification#textDocument_foldingRange
+
+	opt := &FoldingRangeParams{}
+	url, err := parseutil.AbsFilenameToUrl(filename)
+	if err != nil {
+		return nil, err
+	}
+	opt.TextDocument.Uri = DocumentUri(url)
+
+	result := []*FoldingRange{}
+	if err := cli.Call(ctx, "textDocument/foldingRange", &opt, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+//----------
+
 func JsonGetPath(v interface{}, path string) (interface{}, error) {
 	args := strings.Split(path, ".")
 	return jsonGetPath2(v, args)
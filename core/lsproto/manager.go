@@ -264,3 +264,37 @@ func (man *Manager) TextDocumentRename(ctx context.Context, filename string, rd
 
 	return cli.TextDocumentRename(ctx, filename, pos, newName)
 }
+
+//----------
+
+// Returns the folding ranges as offsets.
+func (man *Manager) TextDocumentFoldingRange(ctx context.Context, filename string, rd iorw.Reader) ([][2]int, error) {
+	cli, _, err := man.langInstanceClient(ctx, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Dir(filename)
+	if err := cli.UpdateWorkspaceFolder(ctx, dir); err != nil {
+		return nil, err
+	}
+
+	if err := man.didOpenVersion(ctx, cli, filename, rd); err != nil {
+		return nil, err
+	}
+	defer man.didClose(ctx, cli, filename)
+
+	frs, err := cli.TextDocumentFoldingRange(ctx, filename)
+	if err != nil {
+		return nil, err
+	}
+	res := [][2]int{}
+	for _, fr := range frs {
+		s, e, err := FoldingRangeOffsets(rd, fr)
+		if err != nil {
+			continue
+		}
+		res = append(res, [2]int{s, e})
+	}
+	return res, nil
+}
@@ -156,6 +156,17 @@ type TextEdit struct {
 	NewText string `json:"newText"`
 }
 
+type FoldingRangeParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+type FoldingRange struct {
+	StartLine      int    `json:"startLine"`                // zero based
+	StartCharacter *int   `json:"startCharacter,omitempty"` // if absent, end of line
+	EndLine        int    `json:"endLine"`                  // zero based
+	EndCharacter   *int   `json:"endCharacter,omitempty"`   // if absent, end of line
+	Kind           string `json:"kind,omitempty"`
+}
+
 type Position struct {
 	Line      int `json:"line"`      // zero based
 	Character int `json:"character"` // zero based
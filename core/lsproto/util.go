@@ -113,6 +113,41 @@ func OffsetToPosition(rd iorw.Reader, offset int) (Position, error) {
 	return Position{Line: l, Character: c2}, nil
 }
 
+// Missing characters are at the end of the lines.
+func FoldingRangeOffsets(rd iorw.Reader, fr *FoldingRange) (int, int, error) {
+	offset := func(line int, char *int) (int, error) {
+		if char != nil {
+			p := Position{Line: line, Character: *char}
+			o, _, err := RangeToOffsetLen(rd, &Range{Start: p, End: p})
+			return o, err
+		}
+		ls, err := parseutil.LineColumnIndex(rd, line+1, 1)
+		if err != nil {
+			return 0, err
+		}
+		le, newline, err := iorw.LineEndIndex(rd, ls)
+		if err != nil {
+			return 0, err
+		}
+		if newline {
+			le--
+		}
+		return le, nil
+	}
+	s, err := offset(fr.StartLine, fr.StartCharacter)
+	if err != nil {
+		return 0, 0, err
+	}
+	e, err := offset(fr.EndLine, fr.EndCharacter)
+	if err != nil {
+		return 0, 0, err
+	}
+	if s >= e {
+		return 0, 0, fmt.Errorf("empty folding range: %v-%v", s, e)
+	}
+	return s, e, nil
+}
+
 func RangeToOffsetLen(rd iorw.Reader, rang *Range) (int, int, error) {
 	// one-based lines (range is zero based)
 	l1 := rang.Start.Line + 1
@@ -0,0 +1,145 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"sort"
+
+	"github.com/jmigpin/editor/util/iout/iorw"
+	"github.com/jmigpin/editor/util/parseutil"
+	"github.com/jmigpin/editor/util/uiutil/widget"
+)
+
+// Named file positions (bookmarks). The offsets are kept updated with the edits of the file rows.
+type Marks struct {
+	ed *Editor
+	m  map[string]*Mark
+}
+
+func NewMarks(ed *Editor) *Marks {
+	return &Marks{ed: ed, m: map[string]*Mark{}}
+}
+
+//----------
+
+func (ms *Marks) Set(name, filename string, offset int) {
+	old, ok := ms.m[name]
+	ms.m[name] = &Mark{Name: name, Filename: filename, Offset: offset}
+	if ok && old.Filename != filename {
+		ms.updateInfo(old.Filename)
+	}
+	ms.updateInfo(filename)
+}
+
+func (ms *Marks) Get(name string) (*Mark, bool) {
+	m, ok := ms.m[name]
+	return m, ok
+}
+
+// Replaces all marks (ex: session restore).
+func (ms *Marks) SetAll(marks []*Mark) {
+	old := ms.m
+	ms.m = map[string]*Mark{}
+	for _, m := range marks {
+		u := *m
+		ms.m[m.Name] = &u
+	}
+	for _, m := range old {
+		ms.updateInfo(m.Filename)
+	}
+	for _, m := range ms.m {
+		ms.updateInfo(m.Filename)
+	}
+}
+
+// Sorted by name.
+func (ms *Marks) List() []*Mark {
+	u := []*Mark{}
+	for _, m := range ms.m {
+		u = append(u, m)
+	}
+	sort.Slice(u, func(a, b int) bool {
+		return u[a].Name < u[b].Name
+	})
+	return u
+}
+
+//----------
+
+// Should be called once per edit of the file (not for each duplicate row).
+func (ms *Marks) UpdateWriteOp(filename string, u *widget.RWWriteOpCb) {
+	changed := false
+	for _, m := range ms.m {
+		if m.Filename == filename {
+			o := u.UpdateOffset(m.Offset)
+			changed = changed || o != m.Offset
+			m.Offset = o
+		}
+	}
+	if changed {
+		ms.updateInfo(filename)
+	}
+}
+
+//----------
+
+func (ms *Marks) updateInfo(filename string) {
+	info, ok := ms.ed.ERowInfo(filename)
+	if !ok {
+		return
+	}
+	for _, erow := range info.ERows {
+		ms.updateERow(erow)
+	}
+}
+
+func (ms *Marks) updateERow(erow *ERow) {
+	u := []int{}
+	for _, m := range ms.m {
+		if m.Filename == erow.Info.Name() {
+			u = append(u, m.Offset)
+		}
+	}
+	sort.Ints(u)
+	erow.Row.TextArea.SetMarks(u)
+}
+
+//----------
+
+// Content with one "<filename>:<line>:<col>: <name>" line per mark.
+func (ms *Marks) ListBytes() []byte {
+	buf := &bytes.Buffer{}
+	for _, m := range ms.List() {
+		line, col, err := ms.lineColumn(m)
+		if err != nil {
+			fmt.Fprintf(buf, "%v: %v: %v\n", m.Filename, m.Name, err)
+			continue
+		}
+		fmt.Fprintf(buf, "%v:%v:%v: %v\n", m.Filename, line, col, m.Name)
+	}
+	return buf.Bytes()
+}
+
+func (ms *Marks) lineColumn(m *Mark) (int, int, error) {
+	// use the content of an open row, or the file
+	var rd iorw.Reader
+	if info, ok := ms.ed.ERowInfo(m.Filename); ok && len(info.ERows) > 0 {
+		rd = info.ERows[0].Row.TextArea.TextCursor.RW()
+	} else {
+		b, err := ioutil.ReadFile(m.Filename)
+		if err != nil {
+			return 0, 0, err
+		}
+		rd = iorw.NewBytesReadWriter(b)
+	}
+	return parseutil.IndexLineColumn(rd, m.Offset)
+}
+
+//----------
+
+type Mark struct {
+	Name     string
+	Filename string
+	Offset   int
+}
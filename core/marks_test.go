@@ -0,0 +1,37 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/jmigpin/editor/util/iout/iorw"
+	"github.com/jmigpin/editor/util/uiutil/widget"
+)
+
+func TestMarksUpdateWriteOp(t *testing.T) {
+	ed := &Editor{erowInfos: map[string]*ERowInfo{}}
+	ms := NewMarks(ed)
+	ms.Set("a", "f1", 10)
+	ms.Set("b", "f1", 20)
+	ms.Set("c", "f2", 20)
+
+	// insert before "b"
+	ms.UpdateWriteOp("f1", &widget.RWWriteOpCb{Type: iorw.InsertWOp, Index: 15, Length1: 3})
+	// delete containing "a"
+	ms.UpdateWriteOp("f1", &widget.RWWriteOpCb{Type: iorw.DeleteWOp, Index: 5, Length1: 10})
+
+	type tc struct {
+		name   string
+		offset int
+	}
+	for _, u := range []tc{{"a", 5}, {"b", 13}, {"c", 20}} {
+		m, ok := ms.Get(u.name)
+		if !ok || m.Offset != u.offset {
+			t.Fatalf("%v: %v", u.name, m)
+		}
+	}
+
+	l := ms.List()
+	if len(l) != 3 || l[0].Name != "a" || l[2].Name != "c" {
+		t.Fatal(l)
+	}
+}
@@ -18,6 +18,8 @@ type OpenFileERowConfig struct {
 
 	//FlashRowsIfNotFlashed bool
 	FlashVisibleOffsets bool // flashes rows if not visible
+
+	JumpList bool // record the active row position in the editor jumplist
 }
 
 func OpenFileERow(ed *Editor, conf *OpenFileERowConfig) {
@@ -147,6 +149,10 @@ func openFileERow2(ed *Editor, conf *OpenFileERowConfig) (isNew bool, _ error) {
 			erow = info.ERowsInUIOrder()[0]
 		}
 
+		if conf.JumpList {
+			ed.JumpList.JumpFromActive(erow.Info.Name(), offset)
+		}
+
 		// setup chosen erow
 		//erow.Row.EnsureTextAreaMinimumHeight()
 		erow.Row.EnsureOneToolbarLineYVisible()
@@ -0,0 +1,119 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/jmigpin/editor/core/toolbarparser"
+	"github.com/jmigpin/editor/util/osutil"
+)
+
+type PipeCmdMode int
+
+const (
+	PipeCmdReplace PipeCmdMode = iota // replace the input with the output
+	PipeCmdInsert                     // insert the output at the cursor
+	PipeCmdToRow                      // output to a new row
+)
+
+// Runs a shell cmd with the erow selection (or whole text) as input. The text is not changed on errors.
+func PipeCmd(erow *ERow, part *toolbarparser.Part, mode PipeCmdMode) error {
+	args := part.Args[1:]
+	if len(args) == 0 {
+		return fmt.Errorf("missing command")
+	}
+	cargs := osutil.ShellRunArgs(shellCmdArgsStr(args)...)
+
+	// input: selection or whole text
+	tc := erow.Row.TextArea.TextCursor
+	a, b := tc.RW().Min(), tc.RW().Max()
+	if tc.SelectionOn() {
+		a, b = tc.SelectionIndexes()
+	}
+	in0, err := tc.RW().ReadNSliceAt(a, b-a)
+	if err != nil {
+		return err
+	}
+	in := append([]byte{}, in0...) // copy, content might change
+
+	if mode == PipeCmdToRow {
+		if !erow.Info.IsFileButNotDir() && !erow.Info.IsDir() {
+			return fmt.Errorf("unable to create row with the output for erow: %v", erow.Info.Name())
+		}
+		externalCmdNewDirRow(erow, cargs, nil, bytes.NewReader(in), false)
+		return nil
+	}
+
+	env := populateEnvVars(erow, cargs)
+	erow.Ed.RunAsyncBusyCursor(erow.Row, func(done func()) {
+		defer done()
+
+		cmd := osutil.NewCmd(erow.ctx, cargs...)
+		cmd.Dir = erow.Info.Dir()
+		cmd.Env = env
+		out, err := osutil.RunCmdStdoutAndStderrInErr(cmd, bytes.NewReader(in))
+
+		erow.Ed.UI.RunOnUIGoRoutine(func() {
+			if err == nil {
+				switch mode {
+				case PipeCmdReplace:
+					err = pipeCmdReplace(erow, a, in, out)
+				case PipeCmdInsert:
+					err = pipeCmdInsert(erow, out)
+				}
+			}
+			if err != nil {
+				erow.Ed.Errorf("%v: %v", part.Args[0].Str(), err)
+			}
+		})
+	})
+	return nil
+}
+
+// Replaces the input at "a" (needs to be unchanged) as one undoable edit.
+func pipeCmdReplace(erow *ERow, a int, in, out []byte) error {
+	tc := erow.Row.TextArea.TextCursor
+	b := a + len(in)
+	if b > tc.RW().Max() {
+		return fmt.Errorf("text changed while running")
+	}
+	cur, err := tc.RW().ReadNSliceAt(a, b-a)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(cur, in) {
+		return fmt.Errorf("text changed while running")
+	}
+
+	selOn := tc.SelectionOn()
+	ci := tc.Index()
+
+	tc.BeginEdit()
+	defer tc.EndEdit()
+	if err := tc.RW().Overwrite(a, b-a, out); err != nil {
+		return err
+	}
+	if selOn {
+		tc.SetSelection(a, a+len(out))
+	} else {
+		if ci > tc.RW().Max() {
+			ci = tc.RW().Max()
+		}
+		tc.SetIndex(ci)
+	}
+	return nil
+}
+
+// Inserts at the cursor as one undoable edit, and selects the inserted text.
+func pipeCmdInsert(erow *ERow, out []byte) error {
+	tc := erow.Row.TextArea.TextCursor
+	ci := tc.Index()
+
+	tc.BeginEdit()
+	defer tc.EndEdit()
+	if err := tc.RW().Insert(ci, out); err != nil {
+		return err
+	}
+	tc.SetSelection(ci, ci+len(out))
+	return nil
+}
@@ -11,9 +11,10 @@ import (
 )
 
 type Plugins struct {
-	ed    *Editor
-	plugs []*Plug
-	added map[string]bool
+	ed       *Editor
+	plugs    []*Plug
+	rpcPlugs []*RPCPlug
+	added    map[string]bool
 }
 
 func NewPlugins(ed *Editor) *Plugins {
@@ -37,6 +38,27 @@ func (p *Plugins) AddPath(path string) error {
 	return p.runOnLoad(plug)
 }
 
+// Starts a plugin executable that talks json-rpc over stdio.
+func (p *Plugins) AddRPCPath(path string) error {
+	if p.added[path] {
+		return nil
+	}
+	p.added[path] = true
+
+	plug, err := NewRPCPlug(p.ed, path)
+	if err != nil {
+		return err
+	}
+	p.rpcPlugs = append(p.rpcPlugs, plug)
+	return nil
+}
+
+func (p *Plugins) Close() {
+	for _, plug := range p.rpcPlugs {
+		plug.Close()
+	}
+}
+
 //----------
 
 func (p *Plugins) runOnLoad(plug *Plug) error {
@@ -68,6 +90,13 @@ func (p *Plugins) RunAutoComplete(ctx context.Context, cfb *ui.ContextFloatBox)
 		}
 		me.Add(err)
 	}
+	for _, plug := range p.rpcPlugs {
+		err, handled := plug.autoComplete(ctx, cfb)
+		if handled {
+			return err, true
+		}
+		me.Add(err)
+	}
 	return me.Result(), false
 }
 
@@ -100,6 +129,11 @@ func (p *Plugins) RunToolbarCmd(erow *ERow, part *toolbarparser.Part) bool {
 			return true
 		}
 	}
+	for _, plug := range p.rpcPlugs {
+		if plug.toolbarCmd(erow, part) {
+			return true
+		}
+	}
 	return false
 }
 
@@ -0,0 +1,181 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/jmigpin/editor/core/ctlproto"
+	"github.com/jmigpin/editor/core/toolbarparser"
+	"github.com/jmigpin/editor/ui"
+	"github.com/jmigpin/editor/util/osutil"
+)
+
+// Plugin executable that talks json-rpc over stdin/stdout (see ctlproto pkg). Can call the same methods as the control server clients, and implements the hooks declared on load.
+type RPCPlug struct {
+	Path string
+	ed   *Editor
+	cmd  *osutil.Cmd
+	cc   *ctlConn
+
+	mu   sync.RWMutex
+	info *ctlproto.PluginInfo // nil while loading
+}
+
+func NewRPCPlug(ed *Editor, path string) (*RPCPlug, error) {
+	plug := &RPCPlug{Path: path, ed: ed}
+
+	plug.cmd = osutil.NewCmd(context.Background(), path)
+	plug.cmd.Stderr = os.Stderr
+	w, err := plug.cmd.StdinPipe()
+	if err != nil {
+		plug.cmd.Cancel()
+		return nil, err
+	}
+	r, err := plug.cmd.StdoutPipe()
+	if err != nil {
+		plug.cmd.Cancel()
+		return nil, err
+	}
+	if err := plug.cmd.Start(); err != nil {
+		return nil, fmt.Errorf("rpcplugin: %v: %w", path, err)
+	}
+	go func() {
+		if err := plug.cmd.Wait(); err != nil {
+			ed.Errorf("rpcplugin: %v: %v", path, err)
+		}
+	}()
+
+	plug.cc = ed.CtlServer.newConn(&rpcPlugRWC{r, w})
+
+	// load async: the plugin can make requests that need the UI goroutine
+	go func() {
+		info := &ctlproto.PluginInfo{}
+		err := plug.cc.call(context.Background(), ctlproto.MethodPluginLoad, nil, info)
+		if err != nil {
+			ed.Errorf("rpcplugin: %v: %v: %v", path, ctlproto.MethodPluginLoad, err)
+			return
+		}
+		plug.mu.Lock()
+		plug.info = info
+		plug.mu.Unlock()
+	}()
+
+	// added now (not after loading) to avoid changing the content cmds while they run
+	ContentCmds.Prepend(plug.contentCmdName(), plug.contentCmd)
+
+	return plug, nil
+}
+
+func (plug *RPCPlug) Close() {
+	ContentCmds.Remove(plug.contentCmdName())
+	plug.cc.close() // plugin stdin EOF
+	plug.cmd.Cancel()
+}
+
+func (plug *RPCPlug) loaded() (*ctlproto.PluginInfo, bool) {
+	plug.mu.RLock()
+	defer plug.mu.RUnlock()
+	return plug.info, plug.info != nil
+}
+
+//----------
+
+// UI goroutine. Returns true if the plugin declared the cmd, the cmd runs async.
+func (plug *RPCPlug) toolbarCmd(erow *ERow, part *toolbarparser.Part) bool {
+	info, ok := plug.loaded()
+	if !ok {
+		return false
+	}
+	name := part.Args[0].UnquotedStr()
+	handles := false
+	for _, s := range info.ToolbarCmds {
+		if s == name {
+			handles = true
+			break
+		}
+	}
+	if !handles {
+		return false
+	}
+
+	p := &ctlproto.ToolbarCmdParams{Cmd: part.Str()}
+	if erow != nil {
+		p.Row = plug.ed.CtlServer.rowId(erow)
+	}
+	go func() {
+		err := plug.cc.call(context.Background(), ctlproto.MethodPluginToolbarCmd, p, nil)
+		if err != nil {
+			plug.ed.Errorf("%v: %v", name, err)
+		}
+	}()
+	return true
+}
+
+func (plug *RPCPlug) contentCmdName() string {
+	return "rpcplugin:" + plug.Path
+}
+
+// Content cmd (not in the UI goroutine).
+func (plug *RPCPlug) contentCmd(ctx context.Context, erow *ERow, index int) (_ error, handled bool) {
+	info, ok := plug.loaded()
+	if !ok || !info.ContentCmd {
+		return nil, false
+	}
+	p := &ctlproto.ContentCmdParams{
+		Row:   plug.ed.CtlServer.rowId(erow),
+		Index: index,
+	}
+	res := &ctlproto.HandledResult{}
+	err := plug.cc.call(ctx, ctlproto.MethodPluginContentCmd, p, res)
+	if err != nil {
+		return err, true
+	}
+	return nil, res.Handled
+}
+
+// Not in the UI goroutine.
+func (plug *RPCPlug) autoComplete(ctx context.Context, cfb *ui.ContextFloatBox) (_ error, handled bool) {
+	info, ok := plug.loaded()
+	if !ok || !info.AutoComplete {
+		return nil, false
+	}
+	ta, ok := cfb.FindTextAreaUnderPointer()
+	if !ok {
+		return nil, false
+	}
+	erow, ok := plug.ed.NodeERow(ta)
+	if !ok || ta != erow.Row.TextArea {
+		return nil, false
+	}
+
+	p := &ctlproto.AutoCompleteParams{
+		Row:   plug.ed.CtlServer.rowId(erow),
+		Index: ta.TextCursor.Index(),
+	}
+	res := &ctlproto.AutoCompleteResult{}
+	err := plug.cc.call(ctx, ctlproto.MethodPluginAutoComplete, p, res)
+	if err != nil {
+		return err, true
+	}
+	if !res.Handled {
+		return nil, false
+	}
+	plug.ed.UI.RunOnUIGoRoutine(func() {
+		if cfb.Visible() {
+			cfb.SetRefPointToTextAreaCursor(ta)
+			cfb.TextArea.ClearPos()
+			cfb.SetStrClearHistory(res.Text)
+		}
+	})
+	return nil, true
+}
+
+//----------
+
+type rpcPlugRWC struct {
+	io.Reader      // plugin stdout
+	io.WriteCloser // plugin stdin
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/jmigpin/editor/core/toolbarparser"
 	"github.com/jmigpin/editor/ui"
+	"github.com/jmigpin/editor/util/drawutil/drawer4"
 	"github.com/jmigpin/editor/util/osutil"
 )
 
@@ -63,6 +64,7 @@ type Session struct {
 	Name      string
 	RootTbStr string
 	Columns   []*ColumnState
+	Marks     []*Mark
 }
 
 func NewSessionFromEditor(ed *Editor) *Session {
@@ -73,6 +75,7 @@ func NewSessionFromEditor(ed *Editor) *Session {
 		cstate := NewColumnState(ed, c)
 		s.Columns = append(s.Columns, cstate)
 	}
+	s.Marks = ed.Marks.List()
 	return s
 }
 func (s *Session) restore(ed *Editor) {
@@ -128,6 +131,8 @@ func (s *Session) restore(ed *Editor) {
 	for rs, erow := range m {
 		rs.RestorePos(erow)
 	}
+
+	ed.Marks.SetAll(s.Marks)
 }
 
 //----------
@@ -156,7 +161,14 @@ type RowState struct {
 	TbCursorIndex int
 	TaCursorIndex int
 	TaOffsetIndex int
+	TaFolds       [][2]int
 	StartPercent  float64
+	DirTree       *DirTreeState `json:",omitempty"`
+}
+
+// Directory row in tree mode.
+type DirTreeState struct {
+	Expanded []string
 }
 
 func NewRowState(ed *Editor, row *ui.Row) *RowState {
@@ -176,6 +188,15 @@ func NewRowState(ed *Editor, row *ui.Row) *RowState {
 		TaCursorIndex: row.TextArea.TextCursor.Index(),
 		TaOffsetIndex: row.TextArea.RuneOffset(),
 	}
+	for _, f := range row.TextArea.Folds() {
+		rs.TaFolds = append(rs.TaFolds, [2]int{f.Start, f.End})
+	}
+
+	for _, erow := range ed.ERows() {
+		if erow.Row == row && erow.tree != nil {
+			rs.DirTree = &DirTreeState{Expanded: erow.tree.Expanded()}
+		}
+	}
 
 	// check row.col in case the row has been removed from columns (reopenrow?)
 	if row.Col != nil {
@@ -212,6 +233,12 @@ func (state *RowState) OpenERow(ed *Editor, rowPos *ui.RowPos) (*ERow, bool, err
 		return erow, ok, err
 	}
 
+	if state.DirTree != nil && erow.Info.IsDir() {
+		if err := EnableDirTree(erow, state.DirTree.Expanded); err != nil {
+			ed.Error(err)
+		}
+	}
+
 	return erow, true, nil
 }
 
@@ -219,6 +246,12 @@ func (state *RowState) RestorePos(erow *ERow) {
 	erow.Row.Toolbar.TextCursor.SetIndex(state.TbCursorIndex)
 	erow.Row.TextArea.TextCursor.SetIndex(state.TaCursorIndex)
 	erow.Row.TextArea.SetRuneOffset(state.TaOffsetIndex)
+
+	folds := []*drawer4.Fold{}
+	for _, u := range state.TaFolds {
+		folds = append(folds, &drawer4.Fold{Start: u[0], End: u[1]})
+	}
+	erow.Row.TextArea.SetFolds(folds)
 }
 
 //----------
@@ -310,6 +343,10 @@ func OpenSessionFromString(ed *Editor, sessionName string) {
 	for _, s := range ss.Sessions {
 		if s.Name == sessionName {
 			s.restore(ed)
+
+			// editor events
+			ev := &SessionOpenedEEvent{Name: sessionName}
+			ed.EEvents.emit(SessionOpenedEEventId, ev)
 			return
 		}
 	}
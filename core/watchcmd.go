@@ -0,0 +1,230 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jmigpin/editor/core/fswatcher"
+	"github.com/jmigpin/editor/core/toolbarparser"
+	"github.com/jmigpin/editor/ui"
+	"github.com/jmigpin/editor/util/evreg"
+	"github.com/jmigpin/editor/util/osutil"
+)
+
+// Re-runs a cmd in a directory row when files under the directory are saved or changed on disk.
+type WatchCmd struct {
+	erow   *ERow
+	cargs  []string
+	globs  []string
+	delay  time.Duration
+	cancel context.CancelFunc
+
+	trigger chan struct{}
+	reg     *evreg.Regist
+}
+
+// Max number of directories watched for disk changes (per watch).
+var watchCmdMaxDirs = 512
+
+//----------
+
+// Without cmd args, stops the current watch of the row. File rows watch and run the cmd in a new row of the file directory.
+func WatchCmdFromPart(erow *ERow, part *toolbarparser.Part) error {
+	wc := &WatchCmd{delay: 250 * time.Millisecond}
+
+	// flags
+	args := part.Args[1:]
+	for len(args) > 0 {
+		s := args[0].UnquotedStr()
+		if !strings.HasPrefix(s, "-") {
+			break
+		}
+		args = args[1:]
+		switch {
+		case strings.HasPrefix(s, "-glob="):
+			wc.globs = append(wc.globs, s[len("-glob="):])
+		case strings.HasPrefix(s, "-delay="):
+			d, err := time.ParseDuration(s[len("-delay="):])
+			if err != nil {
+				return err
+			}
+			wc.delay = d
+		default:
+			return fmt.Errorf("unknown flag: %v", s)
+		}
+	}
+
+	if len(args) == 0 {
+		if erow.watch == nil {
+			return fmt.Errorf("row is not watching")
+		}
+		erow.watch.Stop()
+		return nil
+	}
+	wc.cargs = osutil.ShellRunArgs(shellCmdArgsStr(args)...)
+
+	switch {
+	case erow.Info.IsDir():
+		wc.erow = erow
+	case erow.Info.IsFileButNotDir():
+		info := erow.Ed.ReadERowInfo(erow.Info.Dir())
+		wc.erow = NewERow(erow.Ed, info, erow.Row.PosBelow())
+	default:
+		return fmt.Errorf("unable to watch for erow: %v", erow.Info.Name())
+	}
+
+	return wc.start()
+}
+
+//----------
+
+func (wc *WatchCmd) start() error {
+	erow := wc.erow
+	if erow.watch != nil {
+		erow.watch.Stop()
+	}
+
+	w := erow.Ed.DirWatcher.NewUser()
+	*w.OpMask() = fswatcher.Create | fswatcher.Modify | fswatcher.Remove | fswatcher.Rename
+	wc.addDirs(w, erow.Info.Name())
+
+	ctx, cancel := context.WithCancel(erow.ctx) // ends on row close
+	wc.cancel = cancel
+	wc.trigger = make(chan struct{}, 1)
+
+	// files saved in the editor
+	wc.reg = erow.Ed.EEvents.Register(PostFileSaveEEventId, func(ev0 interface{}) {
+		ev := ev0.(*PostFileSaveEEvent)
+		if wc.match(ev.Info.Name()) {
+			wc.triggerRun()
+		}
+	})
+
+	erow.watch = wc
+	erow.Row.SetState(ui.RowStateWatching, true)
+
+	go wc.loop(ctx, w)
+
+	wc.run()
+	return nil
+}
+
+// Stops watching, the current run (if any) is not canceled. UI goroutine.
+func (wc *WatchCmd) Stop() {
+	wc.cancel()
+	wc.reg.Unregister()
+	if wc.erow.watch == wc {
+		wc.erow.watch = nil
+		wc.erow.Row.SetState(ui.RowStateWatching, false)
+	}
+}
+
+//----------
+
+func (wc *WatchCmd) run() {
+	env := populateEnvVars(wc.erow, wc.cargs)
+	// cancels the previous run through the row exec context
+	externalCmdDir(wc.erow, wc.cargs, nil, env, nil, false)
+}
+
+func (wc *WatchCmd) triggerRun() {
+	select {
+	case wc.trigger <- struct{}{}:
+	default: // already triggered
+	}
+}
+
+func (wc *WatchCmd) loop(ctx context.Context, w *fswatcher.MuxUser) {
+	defer w.Close()
+
+	// debounce: run after a delay without new triggers
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case ev := <-w.Events():
+			switch t := ev.(type) {
+			case error:
+				wc.erow.Ed.Error(t)
+			case *fswatcher.Event:
+				if t.Op.HasAny(fswatcher.Create) {
+					if fi, err := os.Stat(t.Name); err == nil && fi.IsDir() {
+						wc.addDirs(w, t.Name)
+					}
+				}
+				// ignore changes while running (ex: build outputs)
+				if !wc.erow.Exec.Running() && wc.match(t.Name) {
+					wc.triggerRun()
+				}
+			}
+		case <-wc.trigger:
+			timer.Reset(wc.delay)
+		case <-timer.C:
+			wc.erow.Ed.UI.RunOnUIGoRoutine(func() {
+				if wc.erow.watch == wc {
+					wc.run()
+				}
+			})
+		}
+	}
+}
+
+//----------
+
+// Adds the directory and its sub directories (except hidden).
+func (wc *WatchCmd) addDirs(w *fswatcher.MuxUser, dir string) {
+	n := 0
+	_ = filepath.Walk(dir, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return nil // skip
+		}
+		if !fi.IsDir() {
+			return nil
+		}
+		if path != dir && strings.HasPrefix(fi.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if n >= watchCmdMaxDirs {
+			return filepath.SkipDir
+		}
+		n++
+		if err := w.Add(path); err != nil {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+}
+
+// Matches files under the row directory. Without globs, matches all files except hidden.
+func (wc *WatchCmd) match(name string) bool {
+	return watchCmdMatch(wc.erow.Info.Name(), wc.globs, name)
+}
+
+func watchCmdMatch(dir string, globs []string, name string) bool {
+	rel, err := filepath.Rel(dir, name)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return false
+	}
+	base := filepath.Base(name)
+	if len(globs) == 0 {
+		return !strings.HasPrefix(base, ".")
+	}
+	for _, g := range globs {
+		// match the base name, or the relative path if the glob has a separator
+		s := base
+		if strings.ContainsRune(g, filepath.Separator) {
+			s = rel
+		}
+		if ok, _ := filepath.Match(g, s); ok {
+			return true
+		}
+	}
+	return false
+}
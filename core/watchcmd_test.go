@@ -0,0 +1,28 @@
+package core
+
+import "testing"
+
+func TestWatchCmdMatch(t *testing.T) {
+	type tcase struct {
+		globs []string
+		name  string
+		ok    bool
+	}
+	tcases := []tcase{
+		{nil, "/a/b/c.go", true},
+		{nil, "/a/b/.c.go", false},
+		{nil, "/a", false},
+		{nil, "/x/c.go", false},
+		{[]string{"*.go"}, "/a/b/c.go", true},
+		{[]string{"*.go"}, "/a/b/c.txt", false},
+		{[]string{"*.txt", "*.go"}, "/a/c.go", true},
+		{[]string{"b/*.go"}, "/a/b/c.go", true},
+		{[]string{"b/*.go"}, "/a/d/c.go", false},
+	}
+	for i, tc := range tcases {
+		ok := watchCmdMatch("/a", tc.globs, tc.name)
+		if ok != tc.ok {
+			t.Fatalf("%v: expecting %v, got %v", i, tc.ok, ok)
+		}
+	}
+}
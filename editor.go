@@ -8,6 +8,7 @@ import (
 	"runtime/pprof"
 
 	"github.com/jmigpin/editor/core"
+	"github.com/jmigpin/editor/core/ctlproto"
 	"github.com/jmigpin/editor/core/lsproto"
 
 	// imports that can't be imported from core (cyclic import)
@@ -31,18 +32,40 @@ func main() {
 	flag.IntVar(&opt.ScrollBarWidth, "scrollbarwidth", 0, "Textarea scrollbar width in pixels. A value of 0 takes 3/4 of the font size.")
 	flag.BoolVar(&opt.ScrollBarLeft, "scrollbarleft", true, "set scrollbars on the left side")
 	flag.BoolVar(&opt.Shadows, "shadows", true, "shadow effects on some elements")
+	flag.BoolVar(&opt.LineNumbers, "linenumbers", false, "show line numbers in the rows textarea (can be set per row with the $lineNumbers toolbar var)")
 	flag.StringVar(&opt.SessionName, "sn", "", "open existing session")
 	flag.StringVar(&opt.SessionName, "sessionname", "", "open existing session")
 	flag.BoolVar(&opt.UseMultiKey, "usemultikey", false, "use multi-key to compose characters (Ex: [multi-key, ~, a] = ã)")
 	flag.StringVar(&opt.Plugins, "plugins", "", "comma separated string of plugin filenames")
+	flag.StringVar(&opt.RPCPlugins, "rpcplugins", "", "comma separated string of plugin executables (json-rpc over stdio, see core/ctlproto)")
 	flag.Var(&opt.LSProtos, "lsproto", "Language-server-protocol register options. Can be specified multiple times.\nFormat: language,extensions,network{tcp,tcpclient,stdio},cmd,optional{stderr}\nExamples:\n"+lsproto.RegistrationExamples())
 	cpuProfileFlag := flag.String("cpuprofile", "", "profile cpu filename")
+	remoteFlag := flag.Bool("remote", false, "open the filenames (<filename:line?:col?>) in a running editor of the same user (or the editor that started this process), starts a new editor if none is running")
+	waitFlag := flag.Bool("wait", false, "with -remote, wait until the opened rows are closed (ex: EDITOR=\"editor -remote -wait\")")
 
 	flag.Parse()
 	opt.Filenames = flag.Args()
 
 	log.SetFlags(log.Lshortfile)
 
+	if *remoteFlag {
+		addr, err := ctlproto.DefaultAddr()
+		if err != nil {
+			log.Println(err)
+			os.Exit(1) // ex: usage as $EDITOR, don't proceed
+		}
+		if cli, err := ctlproto.Dial(addr); err == nil {
+			err := cli.OpenFiles(opt.Filenames, *waitFlag)
+			_ = cli.Close()
+			if err != nil {
+				log.Println(err)
+				os.Exit(1) // ex: usage as $EDITOR, don't proceed
+			}
+			return
+		}
+		// no running editor: continue to start a new one
+	}
+
 	if *cpuProfileFlag != "" {
 		f, err := os.Create(*cpuProfileFlag)
 		if err != nil {
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"bytes"
+	"fmt"
 	"path/filepath"
 
 	"github.com/jmigpin/editor/core"
@@ -15,6 +17,14 @@ func OnLoad(ed *core.Editor) {
 	_ = ed.EEvents.Register(core.PostFileSaveEEventId, h.onEvent1)
 	_ = ed.EEvents.Register(core.PreRowCloseEEventId, h.onEvent1)
 	_ = ed.EEvents.Register(core.RowStateChangeEEventId, h.onEvent2)
+	_ = ed.EEvents.Register(core.PreFileSaveEEventId, h.onPreFileSave)
+	_ = ed.EEvents.Register(core.TextChangedEEventId, h.onTextChanged)
+	_ = ed.EEvents.Register(core.CursorMovedEEventId, h.onCursorMoved)
+	_ = ed.EEvents.Register(core.PreInternalCmdEEventId, h.onEvent1)
+	_ = ed.EEvents.Register(core.PostInternalCmdEEventId, h.onEvent1)
+	_ = ed.EEvents.Register(core.ExternalCmdStartEEventId, h.onEvent1)
+	_ = ed.EEvents.Register(core.ExternalCmdEndEEventId, h.onExternalCmdEnd)
+	_ = ed.EEvents.Register(core.SessionOpenedEEventId, h.onEvent1)
 }
 
 //----------
@@ -33,3 +43,46 @@ func (h *Handler) onEvent2(ev interface{}) {
 	name := filepath.Base(e.ERow.Info.Name())
 	h.ed.Messagef("handler2: %T, %p, %v, %v, %v\n", ev, e.ERow, name, e.State, e.Value)
 }
+
+// Transforms the content to be saved (trailing spaces), or cancels the save by setting an error. Only for "*.example" files to not change other user files.
+func (h *Handler) onPreFileSave(ev interface{}) {
+	e := ev.(*core.PreFileSaveEEvent)
+	if filepath.Ext(e.Info.Name()) != ".example" {
+		return
+	}
+	if bytes.Contains(e.Bytes, []byte("DO NOT SAVE")) {
+		e.Err = fmt.Errorf("eevents: save canceled: %v", e.Info.Name())
+		return
+	}
+	lines := bytes.Split(e.Bytes, []byte("\n"))
+	for i, l := range lines {
+		lines[i] = bytes.TrimRight(l, " \t")
+	}
+	e.Bytes = bytes.Join(lines, []byte("\n"))
+}
+
+// Only file rows: writing the message changes the +Messages row (would loop).
+func (h *Handler) onTextChanged(ev interface{}) {
+	e := ev.(*core.TextChangedEEvent)
+	if !e.ERow.Info.IsFileButNotDir() {
+		return
+	}
+	name := filepath.Base(e.ERow.Info.Name())
+	for _, op := range e.WriteOps {
+		h.ed.Messagef("textchanged: %v: %v %v %v %v\n", name, op.Type, op.Index, op.Length1, op.Length2)
+	}
+}
+
+func (h *Handler) onCursorMoved(ev interface{}) {
+	e := ev.(*core.CursorMovedEEvent)
+	if !e.ERow.Info.IsFileButNotDir() {
+		return
+	}
+	name := filepath.Base(e.ERow.Info.Name())
+	h.ed.Messagef("cursormoved: %v: %v\n", name, e.Index)
+}
+
+func (h *Handler) onExternalCmdEnd(ev interface{}) {
+	e := ev.(*core.ExternalCmdEndEEvent)
+	h.ed.Messagef("externalcmdend: %v: exit code %v: %v\n", e.Args, e.ExitCode, e.Err)
+}
@@ -5,3 +5,6 @@ package plugins
 //go:generate go build -buildmode=plugin ./gotodefinition_godef/gotodefinition_godef.go
 //go:generate go build -buildmode=plugin ./rownames/rownames.go
 //go:generate go build -buildmode=plugin ./eevents/eevents.go
+
+// build rpc plugins (usage: "editor --rpcplugins=<p1>,...")
+//go:generate go build -o ./rpc_uppercase/rpc_uppercase ./rpc_uppercase
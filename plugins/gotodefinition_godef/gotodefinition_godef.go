@@ -70,6 +70,7 @@ func goToDefinition(ctx0 context.Context, erow *core.ERow, index int) (err error
 			FilePos:               filePos,
 			RowPos:                rowPos,
 			FlashVisibleOffsets:   true,
+			JumpList:              true,
 			NewIfNotExistent:      true,
 			NewIfOffsetNotVisible: true,
 		}
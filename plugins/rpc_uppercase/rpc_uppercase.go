@@ -0,0 +1,148 @@
+// Example of an out-of-process plugin (json-rpc over stdio).
+// Usage: go build ./rpc_uppercase && editor --rpcplugins=./rpc_uppercase/rpc_uppercase
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/jmigpin/editor/core/ctlproto"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("rpc_uppercase: ")
+	p := newPeer()
+	if err := p.loop(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+//----------
+
+func handle(p *peer, method string, params json.RawMessage) (interface{}, error) {
+	switch method {
+	case ctlproto.MethodPluginLoad:
+		go func() {
+			// events are received as messages with an "event" field
+			sp := &ctlproto.SubscribeParams{Events: []string{ctlproto.EventSave}}
+			if err := p.call(ctlproto.MethodSubscribe, sp, nil); err != nil {
+				log.Print(err)
+			}
+		}()
+		return &ctlproto.PluginInfo{ToolbarCmds: []string{"Uppercase"}}, nil
+	case ctlproto.MethodPluginToolbarCmd:
+		tp := &ctlproto.ToolbarCmdParams{}
+		if err := json.Unmarshal(params, tp); err != nil {
+			return nil, err
+		}
+		return nil, uppercase(p, tp.Row)
+	default:
+		return nil, fmt.Errorf("method not implemented: %v", method)
+	}
+}
+
+func uppercase(p *peer, row int) error {
+	tp := &ctlproto.TextParams{Row: row, Selection: true}
+	tr := &ctlproto.TextResult{}
+	if err := p.call(ctlproto.MethodGetText, tp, tr); err != nil {
+		return err
+	}
+	sp := &ctlproto.SetTextParams{Row: row, Selection: true, Text: strings.ToUpper(tr.Text)}
+	return p.call(ctlproto.MethodSetText, sp, nil)
+}
+
+//----------
+
+// Receives requests from the editor and responses to the plugin calls on the same stream.
+type peer struct {
+	enc struct {
+		sync.Mutex
+		*json.Encoder
+	}
+	calls struct {
+		sync.Mutex
+		lastId int
+		m      map[int]chan *ctlproto.Message
+	}
+}
+
+func newPeer() *peer {
+	p := &peer{}
+	p.enc.Encoder = json.NewEncoder(os.Stdout)
+	p.calls.m = map[int]chan *ctlproto.Message{}
+	return p
+}
+
+func (p *peer) loop() error {
+	dec := json.NewDecoder(bufio.NewReader(os.Stdin))
+	for {
+		msg := &ctlproto.Message{}
+		if err := dec.Decode(msg); err != nil {
+			return nil // editor closed stdin
+		}
+		switch {
+		case msg.Event != nil:
+			log.Printf("event: %v %v", msg.Event.Name, msg.Event.Filename)
+		case msg.Method != "":
+			go func() {
+				res, err := handle(p, msg.Method, msg.Params)
+				resp := &ctlproto.Response{Id: msg.Id}
+				if err != nil {
+					resp.Error = err.Error()
+				} else if res != nil {
+					b, err := json.Marshal(res)
+					if err != nil {
+						resp.Error = err.Error()
+					}
+					resp.Result = b
+				}
+				p.send(resp)
+			}()
+		default:
+			p.calls.Lock()
+			ch, ok := p.calls.m[msg.Id]
+			delete(p.calls.m, msg.Id)
+			p.calls.Unlock()
+			if ok {
+				ch <- msg
+			}
+		}
+	}
+}
+
+func (p *peer) call(method string, params, res interface{}) error {
+	b, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	ch := make(chan *ctlproto.Message, 1)
+	p.calls.Lock()
+	p.calls.lastId++
+	id := p.calls.lastId
+	p.calls.m[id] = ch
+	p.calls.Unlock()
+
+	if err := p.send(&ctlproto.Request{Id: id, Method: method, Params: b}); err != nil {
+		return err
+	}
+	msg := <-ch
+	if msg.Error != "" {
+		return fmt.Errorf("%v: %v", method, msg.Error)
+	}
+	if res != nil && len(msg.Result) > 0 {
+		return json.Unmarshal(msg.Result, res)
+	}
+	return nil
+}
+
+func (p *peer) send(v interface{}) error {
+	p.enc.Lock()
+	defer p.enc.Unlock()
+	return p.enc.Encode(v)
+}
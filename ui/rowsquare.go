@@ -35,6 +35,9 @@ func (sq *RowSquare) Paint() {
 	if sq.state.hasAny(RowStateNotExist) {
 		bg = sq.TreeThemePaletteColor("rs_not_exist")
 	}
+	if sq.state.hasAny(RowStateWatching) {
+		bg = sq.TreeThemePaletteColor("rs_watching")
+	}
 	if sq.state.hasAny(RowStateExecuting) {
 		bg = sq.TreeThemePaletteColor("rs_executing")
 	}
@@ -141,4 +144,5 @@ const (
 	RowStateDuplicateHighlight
 	RowStateAnnotations
 	RowStateAnnotationsEdited
+	RowStateWatching
 )
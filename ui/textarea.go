@@ -27,6 +27,8 @@ func NewTextArea(ui *UI) *TextArea {
 
 	ta.OnSetStr = ta.onSetStr
 	ta.OnWriteOp = ta.onWriteOp
+	ta.OnScroll = ta.onScroll
+	ta.OnCursorIndex = ta.onCursorIndex
 	ta.EvReg = evreg.NewRegister()
 
 	return ta
@@ -40,10 +42,22 @@ func (ta *TextArea) onSetStr() {
 }
 
 func (ta *TextArea) onWriteOp(u *widget.RWWriteOpCb) {
+	ta.UpdateFoldsWriteOp(u)
 	ev := &TextAreaWriteOpEvent{ta, u}
 	ta.EvReg.RunCallbacks(TextAreaWriteOpEventId, ev)
 }
 
+func (ta *TextArea) onScroll() {
+	ev := &TextAreaScrollEvent{ta}
+	ta.EvReg.RunCallbacks(TextAreaScrollEventId, ev)
+}
+
+func (ta *TextArea) onCursorIndex() {
+	ta.unfoldIndex(ta.TextCursor.Index()) // reveal folded content at the cursor
+	ev := &TextAreaCursorIndexEvent{ta}
+	ta.EvReg.RunCallbacks(TextAreaCursorIndexEventId, ev)
+}
+
 //----------
 
 func (ta *TextArea) OnInputEvent(ev0 interface{}, p image.Point) event.Handled {
@@ -69,6 +83,10 @@ func (ta *TextArea) handleInputEvent2(ev0 interface{}, p image.Point) event.Hand
 				if ta.selAnnCurEv(ev.Point, TASelAnnTypePrintAllPrevious) {
 					return event.HTrue
 				}
+			case m.Is(event.ModCtrl | event.ModAlt):
+				if ta.selAnnCurEv(ev.Point, TASelAnnTypeInspect) {
+					return event.HTrue
+				}
 			}
 			if !ta.SupportClickInsideSelection || !ta.PointIndexInsideSelection(ev.Point) {
 				textutil.MoveCursorToPoint(ta.TextEdit, &ev.Point, false)
@@ -89,6 +107,16 @@ func (ta *TextArea) handleInputEvent2(ev0 interface{}, p image.Point) event.Hand
 					return event.HTrue
 				}
 			}
+			if m.Is(event.ModAlt) {
+				ta.toggleFoldEv(ta.GetIndex(ev.Point))
+				return event.HTrue
+			}
+			if ta.unfoldPlaceholder(ev.Point) {
+				return event.HTrue
+			}
+			if ta.selectLineNumber(ev.Point) {
+				return event.HTrue
+			}
 		case event.ButtonWheelUp:
 			m := ev.Mods.ClearLocks()
 			if m.Is(event.ModCtrl) {
@@ -114,7 +142,22 @@ func (ta *TextArea) handleInputEvent2(ev0 interface{}, p image.Point) event.Hand
 		case event.ButtonRight:
 			ta.Cursor = event.NoneCursor
 		}
+	case *event.MouseDoubleClick:
+		if ev.Button == event.ButtonLeft && ta.selectLineNumber(ev.Point) {
+			return event.HTrue
+		}
+	case *event.MouseTripleClick:
+		if ev.Button == event.ButtonLeft && ta.selectLineNumber(ev.Point) {
+			return event.HTrue
+		}
 	case *event.KeyDown:
+		// allow callbacks to handle keys first (ex: terminal input)
+		ev2 := &TextAreaKeyDownEvent{ta, ev, false}
+		ta.EvReg.RunCallbacks(TextAreaKeyDownEventId, ev2)
+		if ev2.Handled {
+			return event.HTrue
+		}
+
 		m := ev.Mods.ClearLocks()
 		switch {
 		case m.Is(event.ModNone):
@@ -122,6 +165,15 @@ func (ta *TextArea) handleInputEvent2(ev0 interface{}, p image.Point) event.Hand
 			case event.KSymTab:
 				return ta.inlineCompleteEv()
 			}
+		case m.Is(event.ModCtrl):
+			switch ev.KeySym {
+			case event.KSymBracketL:
+				ta.toggleFoldEv(ta.TextCursor.Index())
+				return event.HTrue
+			case event.KSymBracketR:
+				ta.UnfoldAll()
+				return event.HTrue
+			}
 		}
 	}
 	return event.HFalse
@@ -172,6 +224,45 @@ func (ta *TextArea) inlineCompleteEv() event.Handled {
 
 //----------
 
+func (ta *TextArea) toggleFoldEv(index int) {
+	ev2 := &TextAreaFoldEvent{ta, index, false}
+	ta.EvReg.RunCallbacks(TextAreaFoldEventId, ev2)
+	if !ev2.Handled {
+		ta.ToggleFold(index, nil)
+	}
+}
+
+// Unfolds if the point is at a fold placeholder.
+func (ta *TextArea) unfoldPlaceholder(p image.Point) bool {
+	if d, ok := ta.Drawer.(*drawer4.Drawer); ok {
+		if ei, ok := d.FoldsIndexOf(p); ok {
+			start := ta.Folds()[ei].Start
+			ta.removeFold(ei)
+			ta.TextCursor.SetSelectionOff()
+			ta.TextCursor.SetIndex(start)
+			return true
+		}
+	}
+	return false
+}
+
+// Unfolds if the index is hidden by a fold.
+func (ta *TextArea) unfoldIndex(index int) {
+	if d, ok := ta.Drawer.(*drawer4.Drawer); ok {
+		if ei, ok := d.FoldHiding(index); ok {
+			ta.removeFold(ei)
+		}
+	}
+}
+
+func (ta *TextArea) removeFold(ei int) {
+	folds := ta.Folds()
+	u := append([]*drawer4.Fold{}, folds[:ei]...)
+	ta.SetFolds(append(u, folds[ei+1:]...))
+}
+
+//----------
+
 func (ta *TextArea) PointIndexInsideSelection(p image.Point) bool {
 	if ta.TextCursor.SelectionOn() {
 		i := ta.GetIndex(p)
@@ -183,6 +274,36 @@ func (ta *TextArea) PointIndexInsideSelection(p image.Point) bool {
 
 //----------
 
+// Selects the line if the point is at the line numbers gutter.
+func (ta *TextArea) selectLineNumber(p image.Point) bool {
+	if p.X >= ta.Bounds.Min.X+ta.LineNumbersWidth() {
+		return false
+	}
+	textutil.MoveCursorToPoint(ta.TextEdit, &p, false)
+	if err := textutil.SelectLine(ta.TextEdit); err != nil {
+		return false
+	}
+	return true
+}
+
+//----------
+
+// Number of columns and rows of text that fit in the textarea (ex: terminal size).
+func (ta *TextArea) TextSize() (cols, rows int) {
+	w := ta.Bounds.Dx() - ta.LineNumbersWidth()
+	if f := ta.Drawer.Face(); f != nil {
+		if adv, ok := f.GlyphAdvance('0'); ok && adv.Ceil() > 0 {
+			cols = w / adv.Ceil()
+		}
+	}
+	if lh := ta.LineHeight(); lh > 0 {
+		rows = ta.Bounds.Dy() / lh
+	}
+	return cols, rows
+}
+
+//----------
+
 func (ta *TextArea) Layout() {
 	ta.TextEditX.Layout()
 	ta.setDrawer4Opts()
@@ -212,6 +333,10 @@ const (
 	TextAreaCmdEventId
 	TextAreaSelectAnnotationEventId
 	TextAreaInlineCompleteEventId
+	TextAreaFoldEventId
+	TextAreaKeyDownEventId
+	TextAreaScrollEventId
+	TextAreaCursorIndexEventId
 )
 
 //----------
@@ -223,6 +348,12 @@ type TextAreaWriteOpEvent struct {
 	TextArea *TextArea
 	WriteOp  *widget.RWWriteOpCb
 }
+type TextAreaScrollEvent struct {
+	TextArea *TextArea
+}
+type TextAreaCursorIndexEvent struct {
+	TextArea *TextArea
+}
 type TextAreaCmdEvent struct {
 	TextArea *TextArea
 	Index    int
@@ -245,6 +376,7 @@ const (
 	TASelAnnTypeCurrentNext
 	TASelAnnTypePrint
 	TASelAnnTypePrintAllPrevious
+	TASelAnnTypeInspect
 )
 
 //----------
@@ -255,3 +387,21 @@ type TextAreaInlineCompleteEvent struct {
 
 	Handled event.Handled // allow callbacks to set value
 }
+
+//----------
+
+type TextAreaFoldEvent struct {
+	TextArea *TextArea
+	Index    int // fold at the line of this index
+
+	Handled bool // allow callbacks to set value (ex: lsproto folding ranges)
+}
+
+//----------
+
+type TextAreaKeyDownEvent struct {
+	TextArea *TextArea
+	KeyDown  *event.KeyDown
+
+	Handled bool // allow callbacks to set value
+}
@@ -107,6 +107,20 @@ func darkThemeColors(node widget.Node) {
 		"text_highlightword_bg":     cint(0x58842d), // green
 		"text_wrapline_fg":          cint(0xffffff),
 		"text_wrapline_bg":          cint(0x595959),
+		"text_heatmap_bg":           cint(0x8c2d2d), // red
+		"text_mark_bg":              cint(0x4a4530), // yellow
+		"text_change_added_bg":      cint(0x2d4a2d), // green
+		"text_change_modified_bg":   cint(0x2d3d5a), // blue
+		"text_change_deleted_bg":    cint(0x5a2d2d), // red
+		"text_diff_word_bg":         cint(0x7a5a2d), // orange
+
+		"text_linenumbers_fg":             cint(0x808080),
+		"text_linenumbers_bg":             imageutil.Tint(cint(0x0), 0.10),
+		"text_linenumbers_cursor_fg":      cint(0xffffff),
+		"text_linenumbers_cursor_bg":      cint(0x595959),
+		"text_linenumbers_cursor_line_bg": cint(0x1c1c1c),
+		"text_fold_fg":                    cint(0xffffff),
+		"text_fold_bg":                    cint(0x595959),
 
 		"toolbar_text_fg":          cint(0xffffff),
 		"toolbar_text_bg":          cint(0x808080),
@@ -197,6 +211,7 @@ func rowSquarePalette() widget.Palette {
 	pal := widget.Palette{
 		"rs_active":              cint(0x0),
 		"rs_executing":           color.RGBA{15, 173, 0, 255},        // dark green
+		"rs_watching":            color.RGBA{0, 150, 136, 255},       // teal
 		"rs_edited":              color.RGBA{0, 0, 255, 255},         // blue
 		"rs_disk_changes":        color.RGBA{255, 0, 0, 255},         // red
 		"rs_not_exist":           color.RGBA{255, 153, 0, 255},       // orange
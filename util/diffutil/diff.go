@@ -0,0 +1,204 @@
+// Line based diffs (myers algorithm), unified diff output, and three-way merges.
+package diffutil
+
+import (
+	"bytes"
+	"math"
+)
+
+// Range a[A1:A2] was replaced by b[B1:B2]. Insertions have A1==A2, deletions have B1==B2.
+type Hunk struct {
+	A1, A2 int
+	B1, B2 int
+}
+
+//----------
+
+// Returns the hunks needed to transform a (length n) into b (length m), ordered and not adjacent. The eq function compares a[i] with b[j].
+func Diff(n, m int, eq func(i, j int) bool) []*Hunk {
+	// common prefix/suffix
+	pre := 0
+	for pre < n && pre < m && eq(pre, pre) {
+		pre++
+	}
+	suf := 0
+	for suf < n-pre && suf < m-pre && eq(n-1-suf, m-1-suf) {
+		suf++
+	}
+	eq2 := func(i, j int) bool { return eq(pre+i, pre+j) }
+	ops := myers(n-pre-suf, m-pre-suf, eq2)
+	return opsHunks(ops, pre, pre)
+}
+
+func DiffLines(a, b []string) []*Hunk {
+	return Diff(len(a), len(b), func(i, j int) bool { return a[i] == b[j] })
+}
+
+//----------
+
+type op byte
+
+const (
+	opEq op = iota
+	opDel
+	opIns
+)
+
+// Shortest edit script, O((n+m)*d) time and O(n+m) memory (divide and conquer on the middle snake). Very divergent inputs give a valid but possibly longer script (cost limit).
+func myers(n, m int, eq func(i, j int) bool) []op {
+	ops := make([]op, 0, n+m)
+	off := n + m + 1
+	maxCost := int(math.Sqrt(float64(n + m)))
+	if maxCost < diffMinMaxCost {
+		maxCost = diffMinMaxCost
+	}
+	vf := make([]int, 2*off+1) // forward furthest x per diagonal
+	vb := make([]int, 2*off+1) // backward furthest x per diagonal (reversed coordinates)
+
+	var rec func(a0, a1, b0, b1 int)
+	rec = func(a0, a1, b0, b1 int) {
+		// common prefix/suffix
+		for a0 < a1 && b0 < b1 && eq(a0, b0) {
+			ops = append(ops, opEq)
+			a0, b0 = a0+1, b0+1
+		}
+		suf := 0
+		for a0 < a1 && b0 < b1 && eq(a1-1, b1-1) {
+			a1, b1 = a1-1, b1-1
+			suf++
+		}
+		switch {
+		case a0 == a1:
+			for ; b0 < b1; b0++ {
+				ops = append(ops, opIns)
+			}
+		case b0 == b1:
+			for ; a0 < a1; a0++ {
+				ops = append(ops, opDel)
+			}
+		default:
+			x, y, u, v := middleSnake(a0, a1, b0, b1, eq, vf, vb, off, maxCost)
+			rec(a0, x, b0, y)
+			for k := x; k < u; k++ {
+				ops = append(ops, opEq)
+			}
+			rec(u, a1, v, b1)
+		}
+		for ; suf > 0; suf-- {
+			ops = append(ops, opEq)
+		}
+	}
+	rec(0, n, 0, m)
+	return ops
+}
+
+// Minimum cost (number of edits) searched before splitting at the furthest forward point.
+var diffMinMaxCost = 256
+
+// Snake (x,y)->(u,v) in the middle of a shortest edit script of a[a0:a1] and b[b0:b1]. The ranges must be non-empty with different first and last elements, which makes both halves of the split smaller. If the cost goes over maxCost, splits at the furthest reaching forward point instead.
+func middleSnake(a0, a1, b0, b1 int, eq func(i, j int) bool, vf, vb []int, off, maxCost int) (int, int, int, int) {
+	n, m := a1-a0, b1-b0
+	delta := n - m
+	odd := delta%2 != 0
+	vf[off+1] = 0
+	vb[off+1] = 0
+	for d := 0; d <= (n+m+1)/2; d++ {
+		// forward
+		for k := -d; k <= d; k += 2 {
+			x := 0
+			if k == -d || (k != d && vf[off+k-1] < vf[off+k+1]) {
+				x = vf[off+k+1] // insertion
+			} else {
+				x = vf[off+k-1] + 1 // deletion
+			}
+			y := x - k
+			x0, y0 := x, y
+			for x < n && y < m && eq(a0+x, b0+y) {
+				x, y = x+1, y+1
+			}
+			vf[off+k] = x
+			if kr := delta - k; odd && kr >= -(d-1) && kr <= d-1 && x+vb[off+kr] >= n {
+				return a0 + x0, b0 + y0, a0 + x, b0 + y
+			}
+		}
+		// backward
+		for k := -d; k <= d; k += 2 {
+			x := 0
+			if k == -d || (k != d && vb[off+k-1] < vb[off+k+1]) {
+				x = vb[off+k+1]
+			} else {
+				x = vb[off+k-1] + 1
+			}
+			y := x - k
+			x0, y0 := x, y
+			for x < n && y < m && eq(a1-1-x, b1-1-y) {
+				x, y = x+1, y+1
+			}
+			vb[off+k] = x
+			if kf := delta - k; !odd && kf >= -d && kf <= d && x+vf[off+kf] >= n {
+				return a1 - x, b1 - y, a1 - x0, b1 - y0
+			}
+		}
+
+		if d >= maxCost {
+			// too expensive: furthest forward point (d>0, so not the start)
+			bx, by := -1, -1
+			for k := -d; k <= d; k += 2 {
+				x := vf[off+k]
+				y := x - k
+				if x > n || y < 0 || y > m {
+					continue
+				}
+				if bx < 0 || x+y > bx+by {
+					bx, by = x, y
+				}
+			}
+			if bx >= 0 && bx+by < n+m {
+				return a0 + bx, b0 + by, a0 + bx, b0 + by
+			}
+		}
+	}
+	panic("diffutil: middle snake not found")
+}
+
+func opsHunks(ops []op, a0, b0 int) []*Hunk {
+	hunks := []*Hunk{}
+	var h *Hunk
+	i, j := a0, b0
+	for _, o := range ops {
+		if o == opEq {
+			h = nil
+			i, j = i+1, j+1
+			continue
+		}
+		if h == nil {
+			h = &Hunk{A1: i, A2: i, B1: j, B2: j}
+			hunks = append(hunks, h)
+		}
+		if o == opDel {
+			i++
+			h.A2 = i
+		} else {
+			j++
+			h.B2 = j
+		}
+	}
+	return hunks
+}
+
+//----------
+
+// Splits the content in lines, keeping the newlines.
+func SplitLines(b []byte) []string {
+	u := []string{}
+	for len(b) > 0 {
+		k := bytes.IndexByte(b, '\n')
+		if k < 0 {
+			u = append(u, string(b))
+			break
+		}
+		u = append(u, string(b[:k+1]))
+		b = b[k+1:]
+	}
+	return u
+}
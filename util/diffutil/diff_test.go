@@ -0,0 +1,235 @@
+package diffutil
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestDiffLines1(t *testing.T) {
+	a := SplitLines([]byte("a\nb\nc\nd\ne\n"))
+	b := SplitLines([]byte("a\nc\nD\ne\nf\n"))
+	hunks := DiffLines(a, b)
+	want := []Hunk{
+		{A1: 1, A2: 2, B1: 1, B2: 1}, // delete b
+		{A1: 3, A2: 4, B1: 2, B2: 3}, // d -> D
+		{A1: 5, A2: 5, B1: 4, B2: 5}, // insert f
+	}
+	if len(hunks) != len(want) {
+		t.Fatalf("%v", hunksStr(hunks))
+	}
+	for i, h := range hunks {
+		if *h != want[i] {
+			t.Fatalf("%v: %+v", i, *h)
+		}
+	}
+}
+
+func TestDiffLines2(t *testing.T) {
+	if h := DiffLines(nil, nil); len(h) != 0 {
+		t.Fatal(h)
+	}
+	h := DiffLines(nil, []string{"a\n"})
+	if len(h) != 1 || *h[0] != (Hunk{0, 0, 0, 1}) {
+		t.Fatal(hunksStr(h))
+	}
+	h = DiffLines([]string{"a\n", "b\n"}, nil)
+	if len(h) != 1 || *h[0] != (Hunk{0, 2, 0, 0}) {
+		t.Fatal(hunksStr(h))
+	}
+}
+
+func TestDiffRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	gen := func() []string {
+		u := make([]string, r.Intn(30))
+		for i := range u {
+			u[i] = string(rune('a' + r.Intn(4)))
+		}
+		return u
+	}
+	for k := 0; k < 500; k++ {
+		a, b := gen(), gen()
+		hunks := DiffLines(a, b)
+
+		// applying the hunks must give b
+		res := []string{}
+		i := 0
+		for _, h := range hunks {
+			res = append(res, a[i:h.A1]...)
+			res = append(res, b[h.B1:h.B2]...)
+			i = h.A2
+		}
+		res = append(res, a[i:]...)
+		if strings.Join(res, "") != strings.Join(b, "") {
+			t.Fatalf("%v %v: %v", a, b, hunksStr(hunks))
+		}
+
+		// shortest: as many edits as the lcs allows
+		edits := 0
+		for _, h := range hunks {
+			edits += h.A2 - h.A1 + h.B2 - h.B1
+		}
+		if w := len(a) + len(b) - 2*lcsLen(a, b); edits != w {
+			t.Fatalf("%v %v: edits %v, want %v", a, b, edits, w)
+		}
+	}
+}
+
+func lcsLen(a, b []string) int {
+	dp := make([][]int, len(a)+1)
+	for i := range dp {
+		dp[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				dp[i][j] = dp[i+1][j+1] + 1
+			} else if dp[i+1][j] > dp[i][j+1] {
+				dp[i][j] = dp[i+1][j]
+			} else {
+				dp[i][j] = dp[i][j+1]
+			}
+		}
+	}
+	return dp[0][0]
+}
+
+func hunksStr(hunks []*Hunk) []Hunk {
+	u := []Hunk{}
+	for _, h := range hunks {
+		u = append(u, *h)
+	}
+	return u
+}
+
+//----------
+
+func TestWriteUnified(t *testing.T) {
+	a := SplitLines([]byte("1\n2\n3\n4\n5\n6\n7\n8\n9\n10"))
+	b := SplitLines([]byte("1\n2\nX\n4\n5\n6\n7\n8\n9\n10\n"))
+	buf := &bytes.Buffer{}
+	if err := WriteUnified(buf, "a", "b", a, b, DiffLines(a, b), 1); err != nil {
+		t.Fatal(err)
+	}
+	s := "--- a\n+++ b\n" +
+		"@@ -2,3 +2,3 @@\n" +
+		" 2\n-3\n+X\n 4\n" +
+		"@@ -9,2 +9,2 @@\n" +
+		" 9\n-10\n\\ No newline at end of file\n+10\n"
+	if buf.String() != s {
+		t.Fatalf("%q", buf.String())
+	}
+}
+
+//----------
+
+func TestMerge3(t *testing.T) {
+	base := SplitLines([]byte("a\nb\nc\nd\ne\n"))
+	a := SplitLines([]byte("A\nb\nc\nd\ne\nf\n"))
+	b := SplitLines([]byte("a\nb\nC\nd\ne\nf\n"))
+	res, n := Merge3(base, a, b, "row", "disk")
+	if n != 0 || strings.Join(res, "") != "A\nb\nC\nd\ne\nf\n" {
+		t.Fatalf("%v %q", n, res)
+	}
+}
+
+func TestMerge3Conflict(t *testing.T) {
+	base := SplitLines([]byte("a\nb\nc"))
+	a := SplitLines([]byte("a\nB1\nc"))
+	b := SplitLines([]byte("a\nB2\nc"))
+	res, n := Merge3(base, a, b, "row", "disk")
+	s := "a\n<<<<<<< row\nB1\n=======\nB2\n>>>>>>> disk\nc"
+	if n != 1 || strings.Join(res, "") != s {
+		t.Fatalf("%v %q", n, strings.Join(res, ""))
+	}
+
+	// conflict at the end without newline
+	a = SplitLines([]byte("a\nb\nc1"))
+	b = SplitLines([]byte("a\nb\nc2"))
+	res, n = Merge3(base, a, b, "row", "disk")
+	s = "a\nb\n<<<<<<< row\nc1\n=======\nc2\n>>>>>>> disk\n"
+	if n != 1 || strings.Join(res, "") != s {
+		t.Fatalf("%v %q", n, strings.Join(res, ""))
+	}
+}
+
+//----------
+
+func TestSplitWords(t *testing.T) {
+	u := SplitWords("a_b1  := f(x)\n")
+	s := strings.Join(u, "|")
+	if s != "a_b1|  |:|=| |f|(|x|)|\n" {
+		t.Fatalf("%q", s)
+	}
+}
+
+func TestWordDiff(t *testing.T) {
+	ra, rb := WordDiff("v := f(a, b)\n", "v := g(a, c)\n")
+	if len(ra) != 2 || *ra[0] != (Range{5, 6}) || *ra[1] != (Range{10, 11}) {
+		t.Fatalf("%+v", ra)
+	}
+	if len(rb) != 2 || *rb[0] != (Range{5, 6}) || *rb[1] != (Range{10, 11}) {
+		t.Fatalf("%+v", rb)
+	}
+
+	// insertion only on one side
+	ra, rb = WordDiff("a b", "a x b")
+	if len(ra) != 0 || len(rb) != 1 || *rb[0] != (Range{2, 4}) {
+		t.Fatalf("%+v %+v", ra, rb)
+	}
+}
+
+func TestDiffLargeDivergent(t *testing.T) {
+	// all lines different: memory must not grow with the number of edits
+	n := 6000
+	a, b := make([]string, n), make([]string, n)
+	for i := 0; i < n; i++ {
+		a[i] = fmt.Sprintf("a%d\n", i)
+		b[i] = fmt.Sprintf("b%d\n", i)
+	}
+	var ms1, ms2 runtime.MemStats
+	runtime.ReadMemStats(&ms1)
+	hunks := DiffLines(a, b)
+	runtime.ReadMemStats(&ms2)
+	if len(hunks) != 1 || *hunks[0] != (Hunk{0, n, 0, n}) {
+		t.Fatal(hunksStr(hunks))
+	}
+	if alloc := ms2.TotalAlloc - ms1.TotalAlloc; alloc > 10*1024*1024 {
+		t.Fatalf("allocated %v bytes", alloc)
+	}
+}
+
+func TestDiffCostLimit(t *testing.T) {
+	defer func(v int) { diffMinMaxCost = v }(diffMinMaxCost)
+	diffMinMaxCost = 2
+
+	r := rand.New(rand.NewSource(1))
+	gen := func() []string {
+		u := make([]string, r.Intn(200))
+		for i := range u {
+			u[i] = string(rune('a' + r.Intn(6)))
+		}
+		return u
+	}
+	for k := 0; k < 200; k++ {
+		a, b := gen(), gen()
+		hunks := DiffLines(a, b)
+
+		// not the shortest, but applying the hunks must give b
+		res := []string{}
+		i := 0
+		for _, h := range hunks {
+			res = append(res, a[i:h.A1]...)
+			res = append(res, b[h.B1:h.B2]...)
+			i = h.A2
+		}
+		res = append(res, a[i:]...)
+		if strings.Join(res, "") != strings.Join(b, "") {
+			t.Fatalf("%v %v: %v", a, b, hunksStr(hunks))
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package diffutil
+
+import "strings"
+
+// Three-way merge of the changes from base to a and from base to b. Non-overlapping changes are applied, overlapping changes that differ are written with conflict markers. Returns the merged lines and the number of conflicts.
+func Merge3(base, a, b []string, aLabel, bLabel string) ([]string, int) {
+	ha := DiffLines(base, a)
+	hb := DiffLines(base, b)
+
+	res := []string{}
+	conflicts := 0
+	pos := 0 // base index
+	i, j := 0, 0
+	for i < len(ha) || j < len(hb) {
+		// group of overlapping hunks from both sides
+		la, lb := []*Hunk{}, []*Hunk{}
+		var gs, ge int
+		if j >= len(hb) || (i < len(ha) && ha[i].A1 <= hb[j].A1) {
+			la = append(la, ha[i])
+			gs, ge = ha[i].A1, ha[i].A2
+			i++
+		} else {
+			lb = append(lb, hb[j])
+			gs, ge = hb[j].A1, hb[j].A2
+			j++
+		}
+		for {
+			if i < len(ha) && overlaps(ha[i], gs, ge) {
+				la = append(la, ha[i])
+				i++
+			} else if j < len(hb) && overlaps(hb[j], gs, ge) {
+				lb = append(lb, hb[j])
+				j++
+			} else {
+				break
+			}
+			if e := lastEnd(la, lb); e > ge {
+				ge = e
+			}
+		}
+
+		res = append(res, base[pos:gs]...)
+		pos = ge
+
+		as, ae := sideRange(la, gs, ge)
+		bs, be := sideRange(lb, gs, ge)
+		switch {
+		case len(lb) == 0:
+			res = append(res, a[as:ae]...)
+		case len(la) == 0:
+			res = append(res, b[bs:be]...)
+		case equalLines(a[as:ae], b[bs:be]):
+			res = append(res, a[as:ae]...)
+		default:
+			conflicts++
+			res = append(res, "<<<<<<< "+aLabel+"\n")
+			res = appendEnsureNewline(res, a[as:ae])
+			res = append(res, "=======\n")
+			res = appendEnsureNewline(res, b[bs:be])
+			res = append(res, ">>>>>>> "+bLabel+"\n")
+		}
+	}
+	res = append(res, base[pos:]...)
+	return res, conflicts
+}
+
+// Touching ranges also overlap (ex: insertions at the same line).
+func overlaps(h *Hunk, s, e int) bool {
+	return h.A1 <= e && s <= h.A2
+}
+
+func lastEnd(la, lb []*Hunk) int {
+	e := 0
+	if len(la) > 0 {
+		e = la[len(la)-1].A2
+	}
+	if len(lb) > 0 && lb[len(lb)-1].A2 > e {
+		e = lb[len(lb)-1].A2
+	}
+	return e
+}
+
+// Range in the side lines that corresponds to the base range [s,e). Outside the hunks, the side is equal to the base.
+func sideRange(hunks []*Hunk, s, e int) (int, int) {
+	if len(hunks) == 0 {
+		return s, e
+	}
+	first, last := hunks[0], hunks[len(hunks)-1]
+	return first.B1 - (first.A1 - s), last.B2 + (e - last.A2)
+}
+
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Conflict markers need to start on a new line.
+func appendEnsureNewline(res, lines []string) []string {
+	res = append(res, lines...)
+	if k := len(res) - 1; len(lines) > 0 && !strings.HasSuffix(res[k], "\n") {
+		res[k] += "\n"
+	}
+	return res
+}
@@ -0,0 +1,76 @@
+package diffutil
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Writes the hunks in the unified format (like "diff -u") with n lines of context. Nothing is written if there are no hunks.
+func WriteUnified(w io.Writer, aName, bName string, a, b []string, hunks []*Hunk, n int) error {
+	if len(hunks) == 0 {
+		return nil
+	}
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "--- %s\n+++ %s\n", aName, bName)
+	for _, g := range groupHunks(hunks, n) {
+		first, last := g[0], g[len(g)-1]
+		s := first.A1 - n
+		if s < 0 {
+			s = 0
+		}
+		e := last.A2 + n
+		if e > len(a) {
+			e = len(a)
+		}
+		bs := first.B1 - (first.A1 - s)
+		be := last.B2 + (e - last.A2)
+		fmt.Fprintf(bw, "@@ -%s +%s @@\n", unifiedRange(s, e-s), unifiedRange(bs, be-bs))
+
+		i := s
+		for _, h := range g {
+			writeUnifiedLines(bw, " ", a[i:h.A1])
+			writeUnifiedLines(bw, "-", a[h.A1:h.A2])
+			writeUnifiedLines(bw, "+", b[h.B1:h.B2])
+			i = h.A2
+		}
+		writeUnifiedLines(bw, " ", a[i:e])
+	}
+	return bw.Flush()
+}
+
+// Hunks closer than 2*n lines share the context lines.
+func groupHunks(hunks []*Hunk, n int) [][]*Hunk {
+	groups := [][]*Hunk{}
+	for i, h := range hunks {
+		if i > 0 && h.A1-hunks[i-1].A2 <= 2*n {
+			k := len(groups) - 1
+			groups[k] = append(groups[k], h)
+			continue
+		}
+		groups = append(groups, []*Hunk{h})
+	}
+	return groups
+}
+
+// Start is zero-based. Empty ranges refer to the line before.
+func unifiedRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	default:
+		return fmt.Sprintf("%d,%d", start+1, count)
+	}
+}
+
+func writeUnifiedLines(w io.Writer, prefix string, lines []string) {
+	for _, l := range lines {
+		fmt.Fprintf(w, "%s%s", prefix, l)
+		if !strings.HasSuffix(l, "\n") {
+			fmt.Fprintf(w, "\n\\ No newline at end of file\n")
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package diffutil
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// Byte range.
+type Range struct {
+	Start, End int
+}
+
+// Splits in words (runs of letters, digits and underscores), runs of spaces, and single runes otherwise.
+func SplitWords(s string) []string {
+	u := []string{}
+	class := func(ru rune) int {
+		switch {
+		case ru == '_' || unicode.IsLetter(ru) || unicode.IsDigit(ru):
+			return 1
+		case unicode.IsSpace(ru):
+			return 2
+		default:
+			return 0
+		}
+	}
+	for len(s) > 0 {
+		ru, size := utf8.DecodeRuneInString(s)
+		c := class(ru)
+		k := size
+		if c != 0 {
+			for k < len(s) {
+				ru2, size2 := utf8.DecodeRuneInString(s[k:])
+				if class(ru2) != c {
+					break
+				}
+				k += size2
+			}
+		}
+		u = append(u, s[:k])
+		s = s[k:]
+	}
+	return u
+}
+
+// Byte ranges of the words that differ between a and b (ex: two versions of a line).
+func WordDiff(a, b string) ([]*Range, []*Range) {
+	wa, wb := SplitWords(a), SplitWords(b)
+	oa, ob := wordOffsets(wa), wordOffsets(wb)
+	ra, rb := []*Range{}, []*Range{}
+	for _, h := range DiffLines(wa, wb) {
+		if h.A2 > h.A1 {
+			ra = append(ra, &Range{oa[h.A1], oa[h.A2]})
+		}
+		if h.B2 > h.B1 {
+			rb = append(rb, &Range{ob[h.B1], ob[h.B2]})
+		}
+	}
+	return ra, rb
+}
+
+// Start offset of each word, plus the end offset.
+func wordOffsets(words []string) []int {
+	u := make([]int, len(words)+1)
+	for i, w := range words {
+		u[i+1] = u[i] + len(w)
+	}
+	return u
+}
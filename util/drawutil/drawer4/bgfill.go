@@ -30,7 +30,7 @@ func (bgf *BgFill) iter2() {
 	if st.lineBg != nil {
 		r := bgf.d.iters.runeR.penBoundsRect()
 		b := bgf.d.bounds
-		r.Min.X = b.Min.X
+		r.Min.X = b.Min.X + bgf.d.LineNumbersWidth() // don't paint the gutter
 		r.Max.X = b.Max.X
 		r = r.Intersect(b)
 		imageutil.FillRectangle(bgf.d.st.drawR.img, &r, st.lineBg)
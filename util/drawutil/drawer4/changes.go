@@ -0,0 +1,62 @@
+package drawer4
+
+import "image/color"
+
+func updateChangesOps(d *Drawer) {
+	if !d.Opt.Changes.On {
+		d.Opt.Changes.Group.Ops = nil
+		return
+	}
+
+	// not cached: entries are set directly in the options
+	d.Opt.Changes.Group.Ops = changesOps(d)
+}
+
+func changesOps(d *Drawer) []*ColorizeOp {
+	opt := &d.Opt.Changes
+	return lineKindOps(opt.Entries, opt.Added, opt.Modified, opt.Deleted)
+}
+
+// Line backgrounds with the color of each entry kind (entries of kinds without color are skipped).
+func lineKindOps(entries []*ChangeEntry, added, modified, deleted color.Color) []*ColorizeOp {
+	var ops []*ColorizeOp
+	for _, e := range entries {
+		var bg color.Color
+		switch e.Kind {
+		case ChangeAdded:
+			bg = added
+		case ChangeModified:
+			bg = modified
+		case ChangeDeleted:
+			bg = deleted
+		}
+		if bg == nil {
+			continue
+		}
+		// need at least len 1 or the colorize op will be canceled
+		end := e.End
+		if end <= e.Start {
+			end = e.Start + 1
+		}
+		op1 := &ColorizeOp{Offset: e.Start, Line: true, Bg: bg}
+		op2 := &ColorizeOp{Offset: end}
+		ops = append(ops, op1, op2)
+	}
+	return ops
+}
+
+//----------
+
+// Background of changed lines (start/end offsets), ex: version control changes.
+type ChangeEntry struct {
+	Start, End int
+	Kind       ChangeKind
+}
+
+type ChangeKind int
+
+const (
+	ChangeAdded ChangeKind = iota
+	ChangeModified
+	ChangeDeleted // lines were removed after this line
+)
@@ -11,7 +11,11 @@ func (c *Colorize) Init() {
 }
 
 func (c *Colorize) Iter() {
-	c.colorize()
+	if c.d.st.folds.placeholder {
+		c.foldPlaceholder()
+	} else {
+		c.colorize()
+	}
 	if !c.d.iterNext() {
 		return
 	}
@@ -63,6 +67,12 @@ func (c *Colorize) applyOp(op *ColorizeOp) {
 	}
 }
 
+func (c *Colorize) foldPlaceholder() {
+	opt := &c.d.Opt.Folds
+	assignColor(&c.d.st.curColors.fg, opt.Fg)
+	assignColor(&c.d.st.curColors.bg, opt.Bg)
+}
+
 //----------
 
 type ColorizeGroup struct {
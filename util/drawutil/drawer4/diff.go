@@ -0,0 +1,43 @@
+package drawer4
+
+func updateDiffOps(d *Drawer) {
+	if !d.Opt.Diff.On {
+		d.Opt.Diff.Group.Ops = nil
+		d.Opt.Diff.WordGroup.Ops = nil
+		return
+	}
+
+	// not cached: entries are set directly in the options
+	d.Opt.Diff.Group.Ops = diffLineOps(d)
+	d.Opt.Diff.WordGroup.Ops = diffWordOps(d)
+}
+
+func diffLineOps(d *Drawer) []*ColorizeOp {
+	opt := &d.Opt.Diff
+	return lineKindOps(opt.Lines, opt.Added, opt.Modified, opt.Removed)
+}
+
+// Separate group: keeps the line background set by the lines group.
+func diffWordOps(d *Drawer) []*ColorizeOp {
+	opt := &d.Opt.Diff
+	if opt.Word == nil {
+		return nil
+	}
+	var ops []*ColorizeOp
+	for _, w := range opt.Words {
+		if w.End <= w.Start {
+			continue
+		}
+		op1 := &ColorizeOp{Offset: w.Start, Bg: opt.Word}
+		op2 := &ColorizeOp{Offset: w.End}
+		ops = append(ops, op1, op2)
+	}
+	return ops
+}
+
+//----------
+
+// Changed words inside the diff lines.
+type DiffWord struct {
+	Start, End int
+}
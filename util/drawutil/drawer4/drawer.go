@@ -46,6 +46,8 @@ type Drawer struct {
 		colorize           Colorize    // init
 		annotations        Annotations // insert
 		annotationsIndexOf AnnotationsIndexOf
+		lineNumbers        LineNumbers
+		foldsIndexOf       FoldsIndexOf
 	}
 
 	st State
@@ -79,6 +81,13 @@ type Drawer struct {
 		syntaxH struct {
 			updated bool
 		}
+		lineNumbers struct {
+			widthUpdated bool
+			width        int
+			cacheOk      bool
+			cacheOffset  int
+			cacheLine    int
+		}
 	}
 
 	// external options
@@ -99,6 +108,47 @@ type Drawer struct {
 		Colorize struct {
 			Groups []*ColorizeGroup
 		}
+		LineNumbers struct {
+			On     bool
+			Fg, Bg color.Color
+			Cursor struct { // cursor line number
+				Fg, Bg color.Color
+				LineBg color.Color // cursor line background (text)
+			}
+			Group ColorizeGroup // cursor line background
+		}
+		Folds struct {
+			On      bool
+			Fg, Bg  color.Color // placeholder colors
+			Entries []*Fold     // must be ordered by offset and not overlapping
+		}
+		Heatmap struct {
+			On       bool
+			Bg0, Bg1 color.Color     // level 0 and 1 colors
+			Entries  []*HeatmapEntry // must be ordered by offset
+			Group    ColorizeGroup
+		}
+		Changes struct {
+			On                       bool
+			Added, Modified, Deleted color.Color    // line backgrounds
+			Entries                  []*ChangeEntry // must be ordered by offset
+			Group                    ColorizeGroup
+		}
+		Diff struct { // side-by-side diff
+			On                       bool
+			Added, Removed, Modified color.Color    // line backgrounds
+			Word                     color.Color    // changed words background
+			Lines                    []*ChangeEntry // ordered by offset, ChangeDeleted are the removed lines
+			Words                    []*DiffWord    // ordered by offset
+			Group                    ColorizeGroup
+			WordGroup                ColorizeGroup
+		}
+		Marks struct {
+			On      bool
+			Bg      color.Color // line background
+			Entries []int       // offsets, must be ordered
+			Group   ColorizeGroup
+		}
 		Annotations struct {
 			On       bool
 			Fg, Bg   color.Color
@@ -196,6 +246,19 @@ type State struct {
 		cei    int // current entries index (to add to q)
 		indexQ []int
 	}
+	folds struct {
+		placeholder bool // drawing a fold placeholder
+		ei          int  // entry index of the placeholder
+	}
+	foldsIndexOf struct {
+		p      mathutil.PointIntf
+		eindex int
+	}
+	lineNumbers struct {
+		line            int // zero based
+		lineStart       bool
+		cursorLineStart int
+	}
 	annotationsIndexOf struct {
 		p      mathutil.PointIntf
 		eindex int
@@ -237,6 +300,8 @@ func New() *Drawer {
 	d.iters.colorize.d = d
 	d.iters.annotations.d = d
 	d.iters.annotationsIndexOf.d = d
+	d.iters.lineNumbers.d = d
+	d.iters.foldsIndexOf.d = d
 	return d
 }
 
@@ -274,6 +339,8 @@ func (d *Drawer) ContentChanged() {
 	d.opt.wordH.updatedWord = false
 	d.opt.wordH.updatedOps = false
 	d.opt.parenthesisH.updated = false
+	d.opt.lineNumbers.widthUpdated = false
+	d.opt.lineNumbers.cacheOk = false
 }
 
 //----------
@@ -289,6 +356,7 @@ func (d *Drawer) SetFace(f font.Face) {
 	d.lineHeight = mathutil.Intf2(lh)
 
 	d.opt.measure.updated = false
+	d.opt.lineNumbers.widthUpdated = false
 }
 
 func (d *Drawer) LineHeight() int {
@@ -399,6 +467,11 @@ func (d *Drawer) Draw(img draw.Image) {
 	updateWordHighlightWord(d)
 	updateWordHighlightOps(d)
 	updateParenthesisHighlight(d)
+	updateHeatmapOps(d)
+	updateChangesOps(d)
+	updateDiffOps(d)
+	updateMarksOps(d)
+	updateCursorLineOps(d)
 
 	d.st = State{}
 	iters := []Iterator{
@@ -411,6 +484,7 @@ func (d *Drawer) Draw(img draw.Image) {
 		&d.iters.earlyExit,   // after iters that change pen.Y
 		&d.iters.annotations, // after iters that change the line
 		&d.iters.bgFill,
+		&d.iters.lineNumbers,
 		&d.iters.drawR,
 		&d.iters.cursor,
 	}
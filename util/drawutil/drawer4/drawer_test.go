@@ -61,6 +61,242 @@ func TestNLinesStartIndex1(t *testing.T) {
 	}
 }
 
+func TestLineNumbers1(t *testing.T) {
+	d := New()
+	d.SetFace(drawutil.GetTestFace())
+	d.SetBounds(image.Rect(0, 0, 100, 100))
+
+	s := "111\n222\n333\n\n555"
+	rw := iorw.NewBytesReadWriter([]byte(s))
+	d.SetReader(rw)
+
+	p0 := d.LocalPointOf(4)
+	d.Opt.LineNumbers.On = true
+	w := d.LineNumbersWidth()
+	if w <= 0 {
+		t.Fatal(w)
+	}
+	// text is shifted by the gutter width
+	p1 := d.LocalPointOf(4)
+	if p1.X != p0.X+w || p1.Y != p0.Y {
+		t.Fatalf("%v %v", p0, p1)
+	}
+
+	// line numbers (cached position moving back and forth)
+	for _, u := range [][2]int{{0, 0}, {5, 1}, {12, 3}, {13, 4}, {3, 0}, {100, 4}, {8, 2}} {
+		if v := d.lineNumberAt(u[0]); v != u[1] {
+			t.Fatalf("offset %v: %v, expecting %v", u[0], v, u[1])
+		}
+	}
+
+	// content changed
+	if err := rw.Insert(0, []byte("\n\n")); err != nil {
+		t.Fatal(err)
+	}
+	d.ContentChanged()
+	if v := d.lineNumberAt(7); v != 3 {
+		t.Fatal(v)
+	}
+}
+
+func TestLineNumbers2(t *testing.T) {
+	d := New()
+	d.SetFace(drawutil.GetTestFace())
+	d.SetBounds(image.Rect(0, 0, 100, 100))
+	d.SetReader(iorw.NewBytesReadWriter([]byte("a\n")))
+	fg := color.RGBA{255, 0, 0, 255}
+	lineBg := color.RGBA{0, 0, 255, 255}
+	d.Opt.LineNumbers.On = true
+	d.Opt.LineNumbers.Fg = fg
+	d.Opt.LineNumbers.Cursor.Fg = fg
+	d.Opt.LineNumbers.Cursor.LineBg = lineBg
+	d.Opt.Colorize.Groups = []*ColorizeGroup{&d.Opt.LineNumbers.Group}
+	d.Opt.Cursor.On = true
+	d.SetCursorOffset(2) // empty last line
+
+	img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	d.Draw(img)
+	lh, w := d.LineHeight(), d.LineNumbersWidth()
+	count := func(line, x0, x1 int, fn func(c color.RGBA) bool) int {
+		n := 0
+		for y := line * lh; y < (line+1)*lh; y++ {
+			for x := x0; x < x1; x++ {
+				if fn(img.RGBAAt(x, y)) {
+					n++
+				}
+			}
+		}
+		return n
+	}
+	isFg := func(c color.RGBA) bool { return c.R > 100 && c.G < 100 && c.B < 100 } // antialiased
+	isLineBg := func(c color.RGBA) bool { return c == lineBg }
+	// numbered lines
+	for line := 0; line < 2; line++ {
+		if count(line, 0, w, isFg) == 0 {
+			t.Fatalf("line %v: no number", line)
+		}
+	}
+	// cursor line background at the text (not at the other line)
+	if count(1, w, 100, isLineBg) == 0 || count(0, w, 100, isLineBg) != 0 {
+		t.Fatal("cursor line bg")
+	}
+}
+
+func TestHeatmapOps(t *testing.T) {
+	d := New()
+	d.Opt.Heatmap.On = true
+	d.Opt.Heatmap.Bg0 = color.RGBA{0, 0, 0, 255}
+	d.Opt.Heatmap.Bg1 = color.RGBA{200, 100, 0, 255}
+	d.Opt.Heatmap.Entries = []*HeatmapEntry{
+		{Start: 0, End: 3, Level: 1},
+		{Start: 4, End: 4, Level: 0.5}, // empty line
+	}
+	updateHeatmapOps(d)
+	ops := d.Opt.Heatmap.Group.Ops
+	if len(ops) != 4 {
+		t.Fatal(len(ops))
+	}
+	if !ops[0].Line || ops[0].Bg != d.Opt.Heatmap.Bg1 || ops[1].Offset != 3 {
+		t.Fatalf("%+v %+v", ops[0], ops[1])
+	}
+	if ops[2].Bg != (color.RGBA{100, 50, 0, 255}) || ops[3].Offset != 5 {
+		t.Fatalf("%+v %+v", ops[2], ops[3])
+	}
+
+	d.Opt.Heatmap.On = false
+	updateHeatmapOps(d)
+	if d.Opt.Heatmap.Group.Ops != nil {
+		t.Fatal()
+	}
+}
+
+func TestChangesOps(t *testing.T) {
+	d := New()
+	d.Opt.Changes.On = true
+	d.Opt.Changes.Added = color.RGBA{0, 200, 0, 255}
+	d.Opt.Changes.Deleted = color.RGBA{200, 0, 0, 255}
+	d.Opt.Changes.Entries = []*ChangeEntry{
+		{Start: 0, End: 3, Kind: ChangeAdded},
+		{Start: 4, End: 6, Kind: ChangeModified}, // no color: skipped
+		{Start: 7, End: 7, Kind: ChangeDeleted},  // empty line
+	}
+	updateChangesOps(d)
+	ops := d.Opt.Changes.Group.Ops
+	if len(ops) != 4 {
+		t.Fatal(len(ops))
+	}
+	if !ops[0].Line || ops[0].Bg != d.Opt.Changes.Added || ops[1].Offset != 3 {
+		t.Fatalf("%+v %+v", ops[0], ops[1])
+	}
+	if ops[2].Bg != d.Opt.Changes.Deleted || ops[2].Offset != 7 || ops[3].Offset != 8 {
+		t.Fatalf("%+v %+v", ops[2], ops[3])
+	}
+
+	d.Opt.Changes.On = false
+	updateChangesOps(d)
+	if d.Opt.Changes.Group.Ops != nil {
+		t.Fatal()
+	}
+}
+
+func TestDiffOps(t *testing.T) {
+	d := New()
+	d.Opt.Diff.On = true
+	d.Opt.Diff.Modified = color.RGBA{0, 0, 200, 255}
+	d.Opt.Diff.Word = color.RGBA{200, 100, 0, 255}
+	d.Opt.Diff.Lines = []*ChangeEntry{{Start: 0, End: 5, Kind: ChangeModified}}
+	d.Opt.Diff.Words = []*DiffWord{{Start: 0, End: 2}, {Start: 3, End: 3}}
+	updateDiffOps(d)
+	ops := d.Opt.Diff.Group.Ops
+	if len(ops) != 2 || !ops[0].Line || ops[0].Bg != d.Opt.Diff.Modified || ops[1].Offset != 5 {
+		t.Fatalf("%v", ops)
+	}
+	// empty word skipped
+	wops := d.Opt.Diff.WordGroup.Ops
+	if len(wops) != 2 || wops[0].Line || wops[0].Bg != d.Opt.Diff.Word || wops[1].Offset != 2 || wops[1].Bg != nil {
+		t.Fatalf("%v", wops)
+	}
+}
+
+func TestFolds1(t *testing.T) {
+	s := "a {\n\tb\n\tc\n}\nif d:\n\te\n\n\tf\ng"
+	rd := iorw.NewStringReader(s)
+	f1, ok := FoldAt(rd, 1)
+	if !ok || f1.Start != 3 || f1.End != 10 {
+		t.Fatalf("%v %+v", ok, f1)
+	}
+	f2, ok := FoldAt(rd, 12)
+	if !ok || f2.Start != 17 || f2.End != 24 {
+		t.Fatalf("%v %+v", ok, f2)
+	}
+	if _, ok := FoldAt(rd, 25); ok { // last line
+		t.Fatal()
+	}
+
+	// folded content draws as the placeholder
+	draw := func(s string, folds []*Fold, offset int) draw.Image {
+		d, img := newTestDrawer()
+		d.SetReader(iorw.NewStringReader(s))
+		d.Opt.Folds.On = len(folds) > 0
+		d.Opt.Folds.Entries = folds
+		d.SetRuneOffset(offset)
+		d.Draw(img)
+		return img
+	}
+	s2 := "a {...}\nif d:...\ng"
+	img1 := draw(s, []*Fold{f1, f2}, 0)
+	img2 := draw(s2, nil, 0)
+	if !imagesEqual(img1, img2) {
+		t.Fatal("folded image differs")
+	}
+	// scrolling to a line after a fold continues the fold start line
+	img1 = draw(s, []*Fold{f1, f2}, 12)
+	img2 = draw(s2, nil, 8)
+	if !imagesEqual(img1, img2) {
+		t.Fatal("scrolled folded image differs")
+	}
+
+	d, _ := newTestDrawer()
+	d.SetReader(rd)
+	d.Opt.Folds.On = true
+	d.Opt.Folds.Entries = []*Fold{f1, f2}
+	p := d.LocalPointOf(f1.End)
+	if i := d.LocalIndexOf(p); i != f1.End {
+		t.Fatal(i)
+	}
+	if ei, ok := d.FoldsIndexOf(d.LocalPointOf(f1.Start)); !ok || ei != 0 {
+		t.Fatal(ei, ok)
+	}
+	if v := d.FoldSkip(5, true); v != f1.End {
+		t.Fatal(v)
+	}
+	if v := d.FoldSkip(5, false); v != f1.Start {
+		t.Fatal(v)
+	}
+	if ei, ok := d.FoldHiding(18); !ok || ei != 1 {
+		t.Fatal(ei, ok)
+	}
+	// the cursor doesn't change the folds (owned by the caller)
+	d.SetCursorOffset(18)
+	if len(d.Opt.Folds.Entries) != 2 {
+		t.Fatal(d.Opt.Folds.Entries)
+	}
+}
+
+func imagesEqual(img1, img2 image.Image) bool {
+	b := img1.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c1 := color.RGBAModel.Convert(img1.At(x, y))
+			c2 := color.RGBAModel.Convert(img2.At(x, y))
+			if c1 != c2 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
 //----------
 
 func TestImg01(t *testing.T) {
@@ -0,0 +1,288 @@
+package drawer4
+
+import (
+	"bytes"
+	"image"
+	"sort"
+	"unicode"
+
+	"github.com/jmigpin/editor/util/iout/iorw"
+	"github.com/jmigpin/editor/util/mathutil"
+)
+
+// Content in [Start,End[ is not drawn, a placeholder is drawn instead.
+type Fold struct {
+	Start, End int
+}
+
+const foldPlaceholder = "..."
+
+// max distance (in runes) to look for the matching parenthesis
+var foldMaxDist = 1024 * 1024
+
+//----------
+
+// Returns false if the iteration was stopped.
+func (rr *RuneReader) skipFolds() bool {
+	st := &rr.d.st.runeR
+	for {
+		ei, ok := rr.d.foldStartingAt(st.ri)
+		if !ok {
+			return true
+		}
+		if !rr.insertFoldPlaceholder(ei) {
+			return false
+		}
+		end := rr.d.Opt.Folds.Entries[ei].End
+		if end <= st.ri {
+			return true // bad entry, don't loop
+		}
+		st.ri = mathutil.Smallest(end, rr.d.reader.Max())
+	}
+}
+
+func (rr *RuneReader) insertFoldPlaceholder(ei int) bool {
+	rr.pushExtra()
+	defer rr.popExtra()
+
+	st := &rr.d.st.folds
+	st.placeholder = true
+	st.ei = ei
+	defer func() { st.placeholder = false }()
+
+	// the placeholder runes don't advance the reader index
+	for _, ru := range foldPlaceholder {
+		if !rr.iter2(ru, 0) {
+			return false
+		}
+	}
+	return true
+}
+
+//----------
+
+func (d *Drawer) foldStartingAt(offset int) (int, bool) {
+	entries := d.Opt.Folds.Entries
+	k := sort.Search(len(entries), func(i int) bool {
+		return entries[i].Start >= offset
+	})
+	if k < len(entries) && entries[k].Start == offset {
+		return k, true
+	}
+	return 0, false
+}
+
+// Returns the entry index of the fold that hides the offset.
+func (d *Drawer) FoldHiding(offset int) (int, bool) {
+	entries := d.Opt.Folds.Entries
+	k := sort.Search(len(entries), func(i int) bool {
+		return entries[i].End > offset
+	})
+	if k < len(entries) && entries[k].Start < offset {
+		return k, true
+	}
+	return 0, false
+}
+
+// Line start of the line containing the offset, considering that lines hidden by a fold belong to the line where the fold starts.
+func (d *Drawer) foldedLineStart(rd iorw.Reader, offset int) int {
+	if !d.Opt.Folds.On {
+		return offset
+	}
+	entries := d.Opt.Folds.Entries
+	for {
+		// a line start at the fold end is also hidden (continues the fold start line)
+		ei := sort.Search(len(entries), func(i int) bool {
+			return entries[i].End >= offset
+		})
+		if !(ei < len(entries) && entries[ei].Start < offset) {
+			return offset
+		}
+		k, err := iorw.LineStartIndex(rd, entries[ei].Start)
+		if err != nil || k >= offset {
+			return offset
+		}
+		offset = k
+	}
+}
+
+//----------
+
+// Returns an offset outside of folded content: the fold end if moving forward, or the fold start otherwise.
+func (d *Drawer) FoldSkip(offset int, forward bool) int {
+	if ei, ok := d.FoldHiding(offset); ok {
+		f := d.Opt.Folds.Entries[ei]
+		if forward {
+			return f.End
+		}
+		return f.Start
+	}
+	return offset
+}
+
+//----------
+
+// Returns the entry index of the fold placeholder at the point.
+func (d *Drawer) FoldsIndexOf(p image.Point) (int, bool) {
+	if !d.ready() || !d.Opt.Folds.On {
+		return 0, false
+	}
+	d.st = State{}
+	d.st.foldsIndexOf.p = mathutil.PIntf2(p)
+	iters := []Iterator{
+		&d.iters.runeR,
+		&d.iters.line,
+		&d.iters.lineWrap,
+		&d.iters.indent,
+		&d.iters.earlyExit,
+		&d.iters.foldsIndexOf,
+	}
+	d.loopInit(iters)
+	d.header0()
+	d.loop()
+
+	st := &d.st.foldsIndexOf
+	if st.eindex < 0 {
+		return 0, false
+	}
+	return st.eindex, true
+}
+
+//----------
+
+type FoldsIndexOf struct {
+	d *Drawer
+}
+
+func (fio *FoldsIndexOf) Init() {
+	fio.d.st.foldsIndexOf.eindex = -1
+}
+
+func (fio *FoldsIndexOf) Iter() {
+	if fio.d.st.folds.placeholder {
+		fio.iter2()
+	}
+	_ = fio.d.iterNext()
+}
+
+func (fio *FoldsIndexOf) End() {}
+
+func (fio *FoldsIndexOf) iter2() {
+	p := &fio.d.st.foldsIndexOf.p
+	pb := fio.d.iters.runeR.penBounds()
+
+	// before the y start
+	if p.Y < pb.Min.Y {
+		fio.d.iterStop()
+		return
+	}
+	// in the line
+	if p.Y < pb.Max.Y {
+		// before the x start
+		if p.X < pb.Min.X {
+			fio.d.iterStop()
+			return
+		}
+		// inside
+		if p.X < pb.Max.X {
+			fio.d.st.foldsIndexOf.eindex = fio.d.st.folds.ei
+			fio.d.iterStop()
+			return
+		}
+	}
+}
+
+//----------
+
+// Fold of the block that starts at the line of the offset: from an opening parenthesis at the end of the line to its matching pair, or otherwise the following lines with a bigger indentation.
+func FoldAt(rd iorw.Reader, offset int) (*Fold, bool) {
+	ls, le, newline, err := iorw.LinesIndexes(rd, offset, offset)
+	if err != nil {
+		return nil, false
+	}
+	if newline {
+		le--
+	}
+	line, err := rd.ReadNSliceAt(ls, le-ls)
+	if err != nil {
+		return nil, false
+	}
+	if f, ok := foldParenthesis(rd, ls, le, line); ok {
+		return f, true
+	}
+	if !newline {
+		return nil, false
+	}
+	return foldIndentation(rd, le, line)
+}
+
+func foldParenthesis(rd iorw.Reader, ls, le int, line []byte) (*Fold, bool) {
+	t := bytes.TrimRightFunc(line, unicode.IsSpace)
+	if len(t) == 0 {
+		return nil, false
+	}
+	pairs := map[byte]rune{'{': '}', '(': ')', '[': ']'}
+	open := t[len(t)-1]
+	close, ok := pairs[open]
+	if !ok {
+		return nil, false
+	}
+	start := ls + len(t)
+	ri, ok := parenthesisMatch(rd, rune(open), close, start, true, foldMaxDist)
+	if !ok || ri <= le { // must close in another line
+		return nil, false
+	}
+	return &Fold{Start: start, End: ri}, true
+}
+
+// The fold starts at the newline of the given line (le).
+func foldIndentation(rd iorw.Reader, le int, line []byte) (*Fold, bool) {
+	if len(bytes.TrimSpace(line)) == 0 {
+		return nil, false
+	}
+	indent := foldIndentWidth(line)
+	end := -1
+	for i := le; i < rd.Max(); { // i is at a newline
+		s := i + 1
+		e, newline, err := iorw.LineEndIndex(rd, s)
+		if err != nil {
+			break
+		}
+		if newline {
+			e--
+		}
+		b, err := rd.ReadNSliceAt(s, e-s)
+		if err != nil {
+			break
+		}
+		if len(bytes.TrimSpace(b)) != 0 { // empty lines don't end the block
+			if foldIndentWidth(b) <= indent {
+				break
+			}
+			end = e
+		}
+		if !newline {
+			break
+		}
+		i = e
+	}
+	if end < 0 {
+		return nil, false
+	}
+	return &Fold{Start: le, End: end}, true
+}
+
+func foldIndentWidth(line []byte) int {
+	w := 0
+	for _, b := range line {
+		switch b {
+		case ' ':
+			w++
+		case '\t':
+			w += 8 - w%8
+		default:
+			return w
+		}
+	}
+	return w
+}
@@ -0,0 +1,41 @@
+package drawer4
+
+import "github.com/jmigpin/editor/util/imageutil"
+
+func updateHeatmapOps(d *Drawer) {
+	if !d.Opt.Heatmap.On {
+		d.Opt.Heatmap.Group.Ops = nil
+		return
+	}
+
+	// not cached: entries and colors are set directly in the options
+	d.Opt.Heatmap.Group.Ops = heatmapOps(d)
+}
+
+func heatmapOps(d *Drawer) []*ColorizeOp {
+	opt := &d.Opt.Heatmap
+	if opt.Bg0 == nil || opt.Bg1 == nil {
+		return nil
+	}
+	var ops []*ColorizeOp
+	for _, e := range opt.Entries {
+		// need at least len 1 or the colorize op will be canceled
+		end := e.End
+		if end <= e.Start {
+			end = e.Start + 1
+		}
+		bg := imageutil.Mix(opt.Bg0, opt.Bg1, e.Level)
+		op1 := &ColorizeOp{Offset: e.Start, Line: true, Bg: bg}
+		op2 := &ColorizeOp{Offset: end}
+		ops = append(ops, op1, op2)
+	}
+	return ops
+}
+
+//----------
+
+// Background of a line (start/end offsets) colored by level (0.0, 1.0).
+type HeatmapEntry struct {
+	Start, End int
+	Level      float64
+}
@@ -0,0 +1,230 @@
+package drawer4
+
+import (
+	"bytes"
+	"image"
+	"strconv"
+
+	"github.com/jmigpin/editor/util/imageutil"
+	"github.com/jmigpin/editor/util/iout/iorw"
+	"github.com/jmigpin/editor/util/mathutil"
+)
+
+// Draws the line numbers in a gutter at the left of the text. Only logical lines are numbered (wrapped lines continue without a number).
+type LineNumbers struct {
+	d *Drawer
+}
+
+func (ln *LineNumbers) Init() {
+	st := &ln.d.st.lineNumbers
+	st.line = -1
+	st.cursorLineStart = -1
+	if ln.d.Opt.Cursor.On {
+		ci := ln.d.opt.cursor.offset
+		rd := ln.d.limitedReaderPad(ci)
+		if k, err := iorw.LineStartIndex(rd, ci); err == nil {
+			st.cursorLineStart = k
+		}
+	}
+}
+
+func (ln *LineNumbers) Iter() {
+	if ln.d.Opt.LineNumbers.On && ln.d.iters.runeR.isNormal() {
+		ln.iter2()
+	}
+	if !ln.d.iterNext() {
+		return
+	}
+}
+
+func (ln *LineNumbers) End() {}
+
+//----------
+
+// Background of the cursor line text (the gutter is filled when drawing the number).
+func updateCursorLineOps(d *Drawer) {
+	opt := &d.Opt.LineNumbers
+	if !opt.On || !d.Opt.Cursor.On || opt.Cursor.LineBg == nil {
+		opt.Group.Ops = nil
+		return
+	}
+	ci := d.opt.cursor.offset
+	ls, le, newline, err := iorw.LinesIndexes(d.reader, ci, ci)
+	if err != nil {
+		opt.Group.Ops = nil
+		return
+	}
+	if newline {
+		le--
+	}
+	// need at least len 1 or the colorize op will be canceled (ex: empty last line)
+	if le <= ls {
+		le = ls + 1
+	}
+	opt.Group.Ops = []*ColorizeOp{
+		{Offset: ls, Line: true, Bg: opt.Cursor.LineBg},
+		{Offset: le},
+	}
+}
+
+//----------
+
+func (ln *LineNumbers) iter2() {
+	st := &ln.d.st.lineNumbers
+	ri := ln.d.st.runeR.ri
+
+	// first rune: find line number (might not be at a line start if starting at a wrapped line)
+	if st.line < 0 {
+		ln.fillGutterBg()
+		st.line = ln.d.lineNumberAt(ri)
+		st.lineStart = ri == ln.d.reader.Min()
+		if !st.lineStart {
+			ru, _, err := ln.d.reader.ReadLastRuneAt(ri)
+			st.lineStart = err == nil && ru == '\n'
+		}
+	}
+
+	if st.lineStart {
+		st.lineStart = false
+		// folded lines were skipped
+		if ln.d.Opt.Folds.On {
+			st.line = ln.d.lineNumberAt(ri)
+		}
+		ln.drawNumber(st.line+1, ri == st.cursorLineStart)
+	}
+
+	if ln.d.st.runeR.ru == '\n' {
+		st.line++
+		st.lineStart = true
+	}
+}
+
+func (ln *LineNumbers) fillGutterBg() {
+	bg := ln.d.Opt.LineNumbers.Bg
+	if bg == nil {
+		return
+	}
+	r := ln.gutterRect()
+	imageutil.FillRectangle(ln.d.st.drawR.img, &r, bg)
+}
+
+func (ln *LineNumbers) drawNumber(num int, cursorLine bool) {
+	opt := &ln.d.Opt.LineNumbers
+	pr := ln.d.iters.runeR.penBoundsRect()
+
+	fg := opt.Fg
+	if fg == nil {
+		fg = ln.d.fg
+	}
+	if cursorLine {
+		// fill row
+		r := ln.gutterRect()
+		r.Min.Y = pr.Min.Y
+		r.Max.Y = pr.Min.Y + ln.d.LineHeight()
+		r = r.Intersect(ln.d.bounds)
+		if opt.Cursor.Bg != nil {
+			imageutil.FillRectangle(ln.d.st.drawR.img, &r, opt.Cursor.Bg)
+		}
+		if opt.Cursor.Fg != nil {
+			fg = opt.Cursor.Fg
+		}
+	}
+
+	// right aligned, with one digit of padding at the right
+	s := strconv.Itoa(num)
+	adv := ln.d.iters.runeR.glyphAdvance('0')
+	x := mathutil.Intf1(ln.gutterRect().Max.X) - adv*mathutil.Intf(len(s)+1)
+	for _, ru := range s {
+		pen := image.Point{x.Floor(), pr.Min.Y}
+		ln.d.iters.drawR.draw2(pen, ru, fg)
+		x += ln.d.iters.runeR.glyphAdvance(ru)
+	}
+}
+
+func (ln *LineNumbers) gutterRect() image.Rectangle {
+	r := ln.d.bounds
+	r.Max.X = r.Min.X + ln.d.LineNumbersWidth()
+	return r.Intersect(ln.d.bounds)
+}
+
+//----------
+
+// Width of the line numbers gutter (zero if not on).
+func (d *Drawer) LineNumbersWidth() int {
+	if !d.Opt.LineNumbers.On || !d.ready() {
+		return 0
+	}
+	c := &d.opt.lineNumbers
+	if !c.widthUpdated {
+		c.widthUpdated = true
+		c.width = d.lineNumbersWidth2()
+	}
+	return c.width
+}
+
+func (d *Drawer) lineNumbersWidth2() int {
+	// number of digits of the last line (min 3 to avoid width changes in small files)
+	n := d.lineNumberAt(d.reader.Max()) + 1
+	digits := len(strconv.Itoa(n))
+	if digits < 3 {
+		digits = 3
+	}
+	// one digit of padding at each side
+	adv := d.iters.runeR.glyphAdvance('0')
+	w := adv * mathutil.Intf(digits+2)
+	return w.Ceil()
+}
+
+//----------
+
+// Zero based line number of the offset. Uses the reader line index if available, otherwise the last computed position to count only the newlines in between (fast when scrolling).
+func (d *Drawer) lineNumberAt(offset int) int {
+	if li := iorw.ReaderLineIndex(d.reader); li != nil {
+		if l, _, ok, err := li.Line(offset); err == nil && ok {
+			return l
+		}
+	}
+	c := &d.opt.lineNumbers
+	if !c.cacheOk {
+		c.cacheOk = true
+		c.cacheOffset = d.reader.Min()
+		c.cacheLine = 0
+	}
+	if offset > d.reader.Max() {
+		offset = d.reader.Max()
+	}
+	if offset >= c.cacheOffset {
+		n, err := countNewlines(d.reader, c.cacheOffset, offset)
+		if err != nil {
+			c.cacheOk = false
+			return 0
+		}
+		c.cacheLine += n
+	} else {
+		n, err := countNewlines(d.reader, offset, c.cacheOffset)
+		if err != nil {
+			c.cacheOk = false
+			return 0
+		}
+		c.cacheLine -= n
+	}
+	c.cacheOffset = offset
+	return c.cacheLine
+}
+
+func countNewlines(rd iorw.Reader, a, b int) (int, error) {
+	n := 0
+	const chunk = 32 * 1024
+	for i := a; i < b; i += chunk {
+		l := chunk
+		if i+l > b {
+			l = b - i
+		}
+		p, err := rd.ReadNSliceAt(i, l)
+		if err != nil {
+			return 0, err
+		}
+		n += bytes.Count(p, []byte("\n"))
+	}
+	return n, nil
+}
@@ -85,6 +85,7 @@ func (ls *LineStart) linesStartIndexes(offset, nLinesUp int) []int {
 			}
 			break
 		}
+		k = ls.d.foldedLineStart(rd, k)
 		w = append(w, k)
 		offset = k - 1
 	}
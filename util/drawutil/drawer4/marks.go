@@ -0,0 +1,45 @@
+package drawer4
+
+import "github.com/jmigpin/editor/util/iout/iorw"
+
+func updateMarksOps(d *Drawer) {
+	if !d.Opt.Marks.On {
+		d.Opt.Marks.Group.Ops = nil
+		return
+	}
+
+	// not cached: entries are set directly in the options
+	d.Opt.Marks.Group.Ops = marksOps(d)
+}
+
+func marksOps(d *Drawer) []*ColorizeOp {
+	opt := &d.Opt.Marks
+	if opt.Bg == nil {
+		return nil
+	}
+	var ops []*ColorizeOp
+	prevLs := -1
+	for _, o := range opt.Entries {
+		ls, le, newline, err := iorw.LinesIndexes(d.reader, o, o)
+		if err != nil {
+			continue
+		}
+		// several marks in the same line
+		if ls == prevLs {
+			continue
+		}
+		prevLs = ls
+
+		if newline {
+			le--
+		}
+		// need at least len 1 or the colorize op will be canceled
+		if le <= ls {
+			le = ls + 1
+		}
+		op1 := &ColorizeOp{Offset: ls, Line: true, Bg: opt.Bg}
+		op2 := &ColorizeOp{Offset: le}
+		ops = append(ops, op1, op2)
+	}
+	return ops
+}
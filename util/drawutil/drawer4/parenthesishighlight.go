@@ -1,5 +1,7 @@
 package drawer4
 
+import "github.com/jmigpin/editor/util/iout/iorw"
+
 func updateParenthesisHighlight(d *Drawer) {
 	if !d.Opt.ParenthesisHighlight.On {
 		d.Opt.ParenthesisHighlight.Group.Ops = nil
@@ -39,12 +41,51 @@ func parenthesisHOps(d *Drawer, maxDist int) []*ColorizeOp {
 	// assign open/close parenthesis
 	var open, close rune
 	isOpen := pi%2 == 0
-	var nextRune func() (rune, int, error)
+	ri := ci
 	if isOpen {
 		open, close = pairs[pi], pairs[pi+1]
-		ri := ci + len(string(open))
+		ri += len(string(open))
+	} else {
+		open, close = pairs[pi], pairs[pi-1]
+	}
+
+	// colorize open
+	op1 := &ColorizeOp{
+		Offset: ci,
+		Fg:     d.Opt.ParenthesisHighlight.Fg,
+		Bg:     d.Opt.ParenthesisHighlight.Bg,
+	}
+	op2 := &ColorizeOp{Offset: ci + len(string(open))}
+	var ops []*ColorizeOp
+	ops = append(ops, op1, op2)
+
+	// find parenthesis
+	if ri2, ok := parenthesisMatch(d.reader, open, close, ri, isOpen, maxDist); ok {
+		// colorize close
+		op1 := &ColorizeOp{
+			Offset: ri2,
+			Fg:     d.Opt.ParenthesisHighlight.Fg,
+			Bg:     d.Opt.ParenthesisHighlight.Bg,
+		}
+		op2 := &ColorizeOp{Offset: ri2 + len(string(close))}
+		ops = append(ops, op1, op2)
+		if !isOpen {
+			// invert order
+			l := len(ops)
+			ops[l-4], ops[l-2] = ops[l-2], ops[l-4]
+			ops[l-3], ops[l-1] = ops[l-1], ops[l-3]
+		}
+	}
+
+	return ops
+}
+
+// Returns the index of the matching parenthesis (close rune if reading forward from ri, open rune if reading backward).
+func parenthesisMatch(rd iorw.Reader, open, close rune, ri int, forward bool, maxDist int) (int, bool) {
+	var nextRune func() (rune, int, error)
+	if forward {
 		nextRune = func() (rune, int, error) {
-			ru, size, err := d.reader.ReadRuneAt(ri)
+			ru, size, err := rd.ReadRuneAt(ri)
 			if err != nil {
 				return 0, 0, err
 			}
@@ -53,10 +94,8 @@ func parenthesisHOps(d *Drawer, maxDist int) []*ColorizeOp {
 			return ru, ri2, nil
 		}
 	} else {
-		open, close = pairs[pi], pairs[pi-1]
-		ri := ci
 		nextRune = func() (rune, int, error) {
-			ru, size, err := d.reader.ReadLastRuneAt(ri)
+			ru, size, err := rd.ReadLastRuneAt(ri)
 			if err != nil {
 				return 0, 0, err
 			}
@@ -65,17 +104,6 @@ func parenthesisHOps(d *Drawer, maxDist int) []*ColorizeOp {
 		}
 	}
 
-	// colorize open
-	op1 := &ColorizeOp{
-		Offset: ci,
-		Fg:     d.Opt.ParenthesisHighlight.Fg,
-		Bg:     d.Opt.ParenthesisHighlight.Bg,
-	}
-	op2 := &ColorizeOp{Offset: ci + len(string(open))}
-	var ops []*ColorizeOp
-	ops = append(ops, op1, op2)
-
-	// find parenthesis
 	match := 0
 	for i := 0; i < maxDist; i++ {
 		ru, ri, err := nextRune()
@@ -88,26 +116,11 @@ func parenthesisHOps(d *Drawer, maxDist int) []*ColorizeOp {
 			if match > 0 {
 				match--
 			} else {
-				// colorize close
-				op1 := &ColorizeOp{
-					Offset: ri,
-					Fg:     d.Opt.ParenthesisHighlight.Fg,
-					Bg:     d.Opt.ParenthesisHighlight.Bg,
-				}
-				op2 := &ColorizeOp{Offset: ri + len(string(close))}
-				ops = append(ops, op1, op2)
-				if !isOpen {
-					// invert order
-					l := len(ops)
-					ops[l-4], ops[l-2] = ops[l-2], ops[l-4]
-					ops[l-3], ops[l-1] = ops[l-1], ops[l-3]
-				}
-				break
+				return ri, true
 			}
 		}
 	}
-
-	return ops
+	return 0, false
 }
 
 func parenthesisFindPair(d *Drawer, pairs []rune, ci int) (int, bool) {
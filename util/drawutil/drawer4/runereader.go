@@ -22,6 +22,13 @@ func (rr *RuneReader) Iter() {
 		rr.d.st.runeR.startRi = rr.d.st.runeR.ri
 	}
 
+	// folded content is skipped (a placeholder is drawn instead)
+	if rr.d.Opt.Folds.On {
+		if !rr.skipFolds() {
+			return
+		}
+	}
+
 	ru, size, err := rr.d.reader.ReadRuneAt(rr.d.st.runeR.ri)
 	if err != nil {
 		// run last advanced position (draw/delayeddraw/selecting)
@@ -140,6 +147,7 @@ func (rr *RuneReader) penBoundsRect() image.Rectangle {
 func (rr *RuneReader) startingPen() mathutil.PointIntf {
 	p := rr.d.bounds.Min
 	p.X += rr.d.Opt.RuneReader.StartOffsetX
+	p.X += rr.d.LineNumbersWidth()
 	if rr.d.st.runeR.ri == 0 {
 		p.X += rr.d.firstLineOffsetX
 	}
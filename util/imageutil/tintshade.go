@@ -54,6 +54,19 @@ func shade(c color.RGBA, v float64) color.Color {
 	return c
 }
 
+// Mix colors c0 and c1 by v percent (0.0=c0, 1.0=c1).
+func Mix(c0, c1 color.Color, v float64) color.Color {
+	if v < 0 || v > 1 {
+		panic("!")
+	}
+	a := color.RGBAModel.Convert(c0).(color.RGBA)
+	b := color.RGBAModel.Convert(c1).(color.RGBA)
+	mix := func(u0, u1 uint8) uint8 {
+		return uint8(float64(u0) + v*(float64(u1)-float64(u0)))
+	}
+	return color.RGBA{mix(a.R, b.R), mix(a.G, b.G), mix(a.B, b.B), mix(a.A, b.A)}
+}
+
 //----------
 
 func Valorize(c color.Color, v float64, auto bool) color.Color {
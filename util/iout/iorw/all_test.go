@@ -3,8 +3,11 @@ package iorw
 import (
 	"bytes"
 	"context"
+	"math/rand"
 	"testing"
 	"unicode"
+
+	"github.com/jmigpin/editor/util/mathutil"
 )
 
 func TestRW1(t *testing.T) {
@@ -158,3 +161,99 @@ func TestWordAtIndex(t *testing.T) {
 		t.Fatalf("%v %v %v", w, i, err)
 	}
 }
+
+//----------
+
+func TestLineIndexRW1(t *testing.T) {
+	rw := NewLineIndexRW(NewBytesReadWriter([]byte("ab\ncd\n\nef")))
+	li := rw.LineIndex()
+	testLineIndexRW(t, rw) // builds the index before the edits
+
+	rnd := rand.New(rand.NewSource(1))
+	pieces := []string{"a", "\n", "b\nc", "\n\n", "αβ\n", ""}
+	for k := 0; k < 500; k++ {
+		max := rw.Max()
+		i := rnd.Intn(max + 1)
+		n := rnd.Intn(max - i + 1)
+		p := []byte(pieces[rnd.Intn(len(pieces))])
+		var err error
+		switch rnd.Intn(3) {
+		case 0:
+			err = rw.Insert(i, p)
+		case 1:
+			err = rw.Delete(i, n)
+		case 2:
+			err = rw.Overwrite(i, n, p)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		testLineIndexRW(t, rw)
+	}
+
+	b, _ := ReadFullSlice(rw)
+	nl, err := li.NLines()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nl != bytes.Count(b, []byte("\n"))+1 {
+		t.Fatal(nl)
+	}
+}
+
+// compare with the scanning implementation (the inner rw has no index)
+func testLineIndexRW(t *testing.T, rw *LineIndexRW) {
+	t.Helper()
+	for i := rw.Min(); i <= rw.Max(); i++ {
+		v1, err1 := LineStartIndex(rw, i)
+		v2, err2 := LineStartIndex(rw.ReadWriter, i)
+		if v1 != v2 || err1 != err2 {
+			b, _ := ReadFullSlice(rw)
+			t.Fatalf("%q: i=%v: %v %v, %v %v", b, i, v1, err1, v2, err2)
+		}
+	}
+}
+
+func TestLineIndexRW2(t *testing.T) {
+	// many lines: the tree stays balanced with the edits
+	b := bytes.Repeat([]byte("abc\n"), 100000)
+	rw := NewLineIndexRW(NewBytesReadWriter(b))
+	li := rw.LineIndex()
+	if _, err := li.NLines(); err != nil {
+		t.Fatal(err)
+	}
+	rnd := rand.New(rand.NewSource(1))
+	for k := 0; k < 20000; k++ {
+		i := rnd.Intn(rw.Max() + 1)
+		var err error
+		if k%2 == 0 {
+			err = rw.Insert(i, []byte("d\ne\n"))
+		} else {
+			err = rw.Delete(i, mathutil.Smallest(6, rw.Max()-i))
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if h := li.root.height(); h > 100 {
+		t.Fatal(h)
+	}
+
+	b2, _ := ReadFullSlice(rw)
+	nl, err := li.NLines()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nl != bytes.Count(b2, []byte("\n"))+1 {
+		t.Fatal(nl)
+	}
+	for _, i := range []int{0, 1, rw.Max() / 2, rw.Max() - 1, rw.Max()} {
+		k, s, ok, err := li.Line(i)
+		if err != nil || !ok {
+			t.Fatal(err, ok)
+		}
+		if k != bytes.Count(b2[:i], []byte("\n")) || s != bytes.LastIndexByte(b2[:i], '\n')+1 {
+			t.Fatal(i, k, s)
+		}
+	}
+}
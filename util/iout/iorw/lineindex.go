@@ -0,0 +1,339 @@
+package iorw
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/jmigpin/editor/util/mathutil"
+)
+
+// Line lengths of a reader in a balanced tree: queries and updates are O(log n). Built on the first query and kept updated by the write operations of the LineIndexRW.
+type LineIndex struct {
+	rd Reader
+
+	mu    sync.Mutex
+	built bool
+	root  *liNode // lines in order; the last line has no newline
+	seed  uint32  // tree merge randomness
+}
+
+// Number of lines (a trailing newline starts an empty last line).
+func (li *LineIndex) NLines() (int, error) {
+	li.mu.Lock()
+	defer li.mu.Unlock()
+	if err := li.build(); err != nil {
+		return 0, err
+	}
+	return li.root.lines(), nil
+}
+
+// Start offset of the zero-based line. Returns false if the line doesn't exist.
+func (li *LineIndex) LineStart(line int) (int, bool, error) {
+	li.mu.Lock()
+	defer li.mu.Unlock()
+	if err := li.build(); err != nil {
+		return 0, false, err
+	}
+	if line < 0 || line >= li.root.lines() {
+		return 0, false, nil
+	}
+	return li.rd.Min() + li.root.lineStart(line), true, nil
+}
+
+// Zero-based line and line start offset of the offset. Returns false if the offset is out of bounds.
+func (li *LineIndex) Line(offset int) (int, int, bool, error) {
+	li.mu.Lock()
+	defer li.mu.Unlock()
+	if err := li.build(); err != nil {
+		return 0, 0, false, err
+	}
+	min := li.rd.Min()
+	if offset < min || offset > li.rd.Max() {
+		return 0, 0, false, nil
+	}
+	k, s := li.root.lineOf(offset - min)
+	return k, min + s, true, nil
+}
+
+//----------
+
+func (li *LineIndex) build() error {
+	if li.built {
+		return nil
+	}
+	b, err := ReadFullSlice(li.rd)
+	if err != nil {
+		return err
+	}
+	lens := []int{}
+	for i := 0; ; {
+		k := bytes.IndexByte(b[i:], '\n')
+		if k < 0 {
+			lens = append(lens, len(b)-i)
+			break
+		}
+		lens = append(lens, k+1)
+		i += k + 1
+	}
+	li.root = liBuild(lens)
+	li.built = true
+	return nil
+}
+
+func (li *LineIndex) reset() {
+	li.mu.Lock()
+	defer li.mu.Unlock()
+	li.built = false
+	li.root = nil
+}
+
+//----------
+
+func (li *LineIndex) insert(i int, p []byte) {
+	li.mu.Lock()
+	defer li.mu.Unlock()
+	if !li.built {
+		return
+	}
+	i -= li.rd.Min()
+	k, s := li.root.lineOf(i)
+	a, m, c := li.split3(k, k+1)
+	head, tail := i-s, m.len-(i-s)
+
+	// line k is split by the newlines of p
+	lens := []int{}
+	for j := 0; ; {
+		h := bytes.IndexByte(p[j:], '\n')
+		if h < 0 {
+			lens = append(lens, len(p)-j)
+			break
+		}
+		lens = append(lens, h+1)
+		j += h + 1
+	}
+	lens[0] += head
+	lens[len(lens)-1] += tail
+	li.root = li.merge(li.merge(a, liBuild(lens)), c)
+}
+
+func (li *LineIndex) delete(i, n int) {
+	li.mu.Lock()
+	defer li.mu.Unlock()
+	if !li.built {
+		return
+	}
+	i -= li.rd.Min()
+	ka, _ := li.root.lineOf(i)
+	kb, _ := li.root.lineOf(i + n)
+	// lines ka..kb become one line
+	a, m, c := li.split3(ka, kb+1)
+	li.root = li.merge(li.merge(a, liBuild([]int{m.sum - n})), c)
+}
+
+// Splits the lines in [0,k1), [k1,k2), [k2,n).
+func (li *LineIndex) split3(k1, k2 int) (*liNode, *liNode, *liNode) {
+	a, bc := li.root.split(k1)
+	b, c := bc.split(k2 - k1)
+	return a, b, c
+}
+
+// Random by subtree size (keeps the tree balanced in expectation without priorities).
+func (li *LineIndex) merge(a, b *liNode) *liNode {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	if li.rand(a.n+b.n) < a.n {
+		a.right = li.merge(a.right, b)
+		a.update()
+		return a
+	}
+	b.left = li.merge(a, b.left)
+	b.update()
+	return b
+}
+
+func (li *LineIndex) rand(n int) int {
+	// xorshift
+	x := li.seed
+	if x == 0 {
+		x = 2463534242
+	}
+	x ^= x << 13
+	x ^= x >> 17
+	x ^= x << 5
+	li.seed = x
+	return int(x % uint32(n))
+}
+
+//----------
+
+// Line of the index tree, with the totals of its subtree.
+type liNode struct {
+	left, right *liNode
+	len         int // line length (including the newline)
+	n           int // lines in the subtree
+	sum         int // bytes in the subtree
+}
+
+// Balanced tree with the lines in order.
+func liBuild(lens []int) *liNode {
+	if len(lens) == 0 {
+		return nil
+	}
+	k := len(lens) / 2
+	nd := &liNode{len: lens[k]}
+	nd.left = liBuild(lens[:k])
+	nd.right = liBuild(lens[k+1:])
+	nd.update()
+	return nd
+}
+
+func (nd *liNode) update() {
+	nd.n = 1 + nd.left.lines()
+	nd.sum = nd.len + nd.left.bytes()
+	if nd.right != nil {
+		nd.n += nd.right.n
+		nd.sum += nd.right.sum
+	}
+}
+
+func (nd *liNode) lines() int {
+	if nd == nil {
+		return 0
+	}
+	return nd.n
+}
+func (nd *liNode) bytes() int {
+	if nd == nil {
+		return 0
+	}
+	return nd.sum
+}
+
+func (nd *liNode) lineStart(line int) int {
+	s := 0
+	for nd != nil {
+		ln := nd.left.lines()
+		switch {
+		case line < ln:
+			nd = nd.left
+		case line == ln:
+			return s + nd.left.bytes()
+		default:
+			s += nd.left.bytes() + nd.len
+			line -= ln + 1
+			nd = nd.right
+		}
+	}
+	return s
+}
+
+// Line and line start of the offset. Offsets at (or after) the end are in the last line.
+func (nd *liNode) lineOf(offset int) (int, int) {
+	if offset >= nd.bytes() {
+		k := nd.lines() - 1
+		return k, nd.lineStart(k)
+	}
+	k, s := 0, 0
+	for nd != nil {
+		lb := nd.left.bytes()
+		switch {
+		case offset < lb:
+			nd = nd.left
+		case offset < lb+nd.len:
+			return k + nd.left.lines(), s + lb
+		default:
+			k += nd.left.lines() + 1
+			s += lb + nd.len
+			offset -= lb + nd.len
+			nd = nd.right
+		}
+	}
+	return k, s
+}
+
+// Splits in the first k lines and the rest.
+func (nd *liNode) split(k int) (*liNode, *liNode) {
+	if nd == nil {
+		return nil, nil
+	}
+	if ln := nd.left.lines(); k <= ln {
+		a, b := nd.left.split(k)
+		nd.left = b
+		nd.update()
+		return a, nd
+	}
+	a, b := nd.right.split(k - nd.left.lines() - 1)
+	nd.right = a
+	nd.update()
+	return nd, b
+}
+
+func (nd *liNode) height() int {
+	if nd == nil {
+		return 0
+	}
+	return 1 + mathutil.Biggest(nd.left.height(), nd.right.height())
+}
+
+//----------
+
+// Keeps a line index updated with the write operations.
+type LineIndexRW struct {
+	ReadWriter
+	li *LineIndex
+}
+
+func NewLineIndexRW(rw ReadWriter) *LineIndexRW {
+	return &LineIndexRW{ReadWriter: rw, li: &LineIndex{rd: rw}}
+}
+
+func (rw *LineIndexRW) LineIndex() *LineIndex {
+	return rw.li
+}
+
+func (rw *LineIndexRW) Insert(i int, p []byte) error {
+	if err := rw.ReadWriter.Insert(i, p); err != nil {
+		rw.li.reset()
+		return err
+	}
+	rw.li.insert(i, p)
+	return nil
+}
+
+func (rw *LineIndexRW) Delete(i, n int) error {
+	if err := rw.ReadWriter.Delete(i, n); err != nil {
+		rw.li.reset()
+		return err
+	}
+	rw.li.delete(i, n)
+	return nil
+}
+
+func (rw *LineIndexRW) Overwrite(i, n int, p []byte) error {
+	if err := rw.ReadWriter.Overwrite(i, n, p); err != nil {
+		rw.li.reset()
+		return err
+	}
+	rw.li.delete(i, n)
+	rw.li.insert(i, p)
+	return nil
+}
+
+//----------
+
+// Implemented by readers that keep a line index (and by wrappers that forward it).
+type LineIndexer interface {
+	LineIndex() *LineIndex
+}
+
+// Returns nil if the reader doesn't keep a line index.
+func ReaderLineIndex(rd Reader) *LineIndex {
+	if u, ok := rd.(LineIndexer); ok {
+		return u.LineIndex()
+	}
+	return nil
+}
@@ -150,6 +150,11 @@ func ExpandLastIndexFunc(r Reader, i int, truth bool, f func(rune) bool) int {
 //----------
 
 func LineStartIndex(r Reader, i int) (int, error) {
+	if li := ReaderLineIndex(r); li != nil {
+		if _, ls, ok, err := li.Line(i); err == nil && ok {
+			return ls, nil
+		}
+	}
 	k, size, err := NewLineLastIndex(r, i)
 	if err == io.EOF {
 		return 0, nil
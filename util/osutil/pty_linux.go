@@ -0,0 +1,92 @@
+package osutil
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// Opens a pseudo-terminal master/slave pair.
+func OpenPty() (master, slave *os.File, _ error) {
+	m, err := os.OpenFile("/dev/ptmx", os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	fd := int(m.Fd())
+	if err := unix.IoctlSetPointerInt(fd, unix.TIOCSPTLCK, 0); err != nil { // unlock
+		m.Close()
+		return nil, nil, err
+	}
+	n, err := unix.IoctlGetUint32(fd, unix.TIOCGPTN)
+	if err != nil {
+		m.Close()
+		return nil, nil, err
+	}
+	name := fmt.Sprintf("/dev/pts/%d", n)
+	s, err := os.OpenFile(name, os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		m.Close()
+		return nil, nil, err
+	}
+	return m, s, nil
+}
+
+func SetPtySize(f *os.File, cols, rows int) error {
+	ws := &unix.Winsize{Col: uint16(cols), Row: uint16(rows)}
+	return unix.IoctlSetWinsize(int(f.Fd()), unix.TIOCSWINSZ, ws)
+}
+
+func SetPtyEcho(f *os.File, on bool) error {
+	fd := int(f.Fd())
+	t, err := unix.IoctlGetTermios(fd, unix.TCGETS)
+	if err != nil {
+		return err
+	}
+	if on {
+		t.Lflag |= unix.ECHO
+	} else {
+		t.Lflag &^= unix.ECHO
+	}
+	return unix.IoctlSetTermios(fd, unix.TCSETS, t)
+}
+
+//----------
+
+// Runs the cmd with a pseudo-terminal as the controlling terminal (stdin/out/err). The returned master is used to communicate with the process and should be closed by the caller after Wait().
+func (cmd *Cmd) SetupPty(cols, rows int, echo bool) (*os.File, error) {
+	if cmd.setupCalled {
+		return nil, fmt.Errorf("setup already called")
+	}
+	cmd.setupCalled = true
+
+	master, slave, err := OpenPty()
+	if err != nil {
+		return nil, err
+	}
+	if err := SetPtySize(master, cols, rows); err != nil {
+		master.Close()
+		slave.Close()
+		return nil, err
+	}
+	if err := SetPtyEcho(slave, echo); err != nil {
+		master.Close()
+		slave.Close()
+		return nil, err
+	}
+
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = slave, slave, slave
+	cmd.SysProcAttr = &unix.SysProcAttr{
+		Setsid:  true,
+		Setctty: true,
+		Ctty:    0, // stdin in the child
+	}
+
+	// the slave is only used by the child process, close after start
+	cmd.addCopyCloser(slave)
+	cmd.copy.fns = append(cmd.copy.fns, func() {
+		cmd.closeCopyCloser(slave)
+	})
+
+	return master, nil
+}
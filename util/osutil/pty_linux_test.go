@@ -0,0 +1,41 @@
+package osutil
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestCmdPty(t *testing.T) {
+	ctx := context.Background()
+	cmd := NewCmd(ctx, "sh", "-c", "stty size; read x; echo got:$x")
+	master, err := cmd.SetupPty(80, 24, false)
+	if err != nil {
+		t.Skip(err) // ex: no /dev/ptmx
+	}
+	defer master.Close()
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := master.Write([]byte("abc\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := &bytes.Buffer{}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = io.Copy(buf, master) // ends with an error after the slave closes
+	}()
+	if err := cmd.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	<-done
+
+	s := strings.Replace(buf.String(), "\r\n", "\n", -1)
+	if s != "24 80\ngot:abc\n" {
+		t.Fatalf("%q", s)
+	}
+}
@@ -0,0 +1,26 @@
+// +build !linux
+
+package osutil
+
+import (
+	"fmt"
+	"os"
+)
+
+func OpenPty() (master, slave *os.File, _ error) {
+	return nil, nil, fmt.Errorf("pty: not supported")
+}
+
+func SetPtySize(f *os.File, cols, rows int) error {
+	return fmt.Errorf("pty: not supported")
+}
+
+func SetPtyEcho(f *os.File, on bool) error {
+	return fmt.Errorf("pty: not supported")
+}
+
+//----------
+
+func (cmd *Cmd) SetupPty(cols, rows int, echo bool) (*os.File, error) {
+	return nil, fmt.Errorf("pty: not supported")
+}
@@ -180,6 +180,81 @@ func TestParseResource24(t *testing.T) {
 	testParseResourcePath(t, s, len(s), "c:/a/b.txt")
 }
 
+func TestParseResourceFormats(t *testing.T) {
+	type tcase struct {
+		s                 string
+		index             int
+		path              string
+		line, col, offset int
+	}
+	tcases := []tcase{
+		// offset
+		{"/a/b.txt:#123", 3, "/a/b.txt", 0, 0, 123},
+		{"/a/b.txt:#123", 11, "/a/b.txt", 0, 0, 123},
+		{"/a/b.txt:#", 3, "/a/b.txt", 0, 0, -1},
+		// python traceback
+		{`  File "/a/b.py", line 12, in f`, 12, "/a/b.py", 12, 0, -1},
+		{`  File "b.py", line 3`, 10, "b.py", 3, 0, -1},
+		// js/rust/java stack frames
+		{"    at f (/a/b.js:3:5)", 12, "/a/b.js", 3, 5, -1},
+		{"    at /a/b.js:3:5", 10, "/a/b.js", 3, 5, -1},
+		{"  --> src/main.rs:10:5", 9, "src/main.rs", 10, 5, -1},
+		{"\tat a.B.f(B.java:42)", 12, "B.java", 42, 0, -1},
+		// msvc
+		{"c.cpp(12,5): error C2065", 2, "c.cpp", 12, 5, -1},
+		{"c.cpp(12): warning C4101", 2, "c.cpp", 12, 0, -1},
+		{"c.cpp(12", 2, "c.cpp", 0, 0, -1},
+	}
+	for _, tc := range tcases {
+		rd := iorw.NewStringReader(tc.s)
+		u, err := ParseResource(rd, tc.index)
+		if err != nil {
+			t.Fatalf("%q: %v", tc.s, err)
+		}
+		if u.Path != tc.path || u.Line != tc.line || u.Column != tc.col || u.Offset != tc.offset {
+			t.Fatalf("%q:\n%#v", tc.s, u)
+		}
+	}
+}
+
+func TestParseResourceByteOffsets(t *testing.T) {
+	type tcase struct {
+		s                 string
+		index             int
+		path              string
+		line, col, offset int
+	}
+	tcases := []tcase{
+		// grep -b
+		{"/a/b.txt:1234:some text", 3, "/a/b.txt", 0, 0, 1234},
+		{"b.txt:0:first line", 2, "b.txt", 0, 0, 0},
+		// grep -nb
+		{"/a/b.txt:12:1234:some text", 3, "/a/b.txt", 12, 0, 1234},
+		// explicit offset
+		{"/a/b.txt:#123", 3, "/a/b.txt", 0, 0, 123},
+		{"/a/b.txt", 3, "/a/b.txt", 0, 0, -1},
+	}
+	for _, tc := range tcases {
+		rd := iorw.NewStringReader(tc.s)
+		u, err := ParseResourceByteOffsets(rd, tc.index)
+		if err != nil {
+			t.Fatalf("%q: %v", tc.s, err)
+		}
+		if u.Path != tc.path || u.Line != tc.line || u.Column != tc.col || u.Offset != tc.offset {
+			t.Fatalf("%q:\n%#v", tc.s, u)
+		}
+	}
+
+	// default parse: grep -b output is a line
+	u, err := ParseResource(iorw.NewStringReader("/a/b.txt:1234:some text"), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Line != 1234 || u.Offset != -1 {
+		t.Fatalf("%#v", u)
+	}
+}
+
 //----------
 
 func testParseResourcePath(t *testing.T, str string, index int, estr string) {
@@ -264,6 +339,32 @@ func TestLineColumnIndex1(t *testing.T) {
 	}
 }
 
+func TestLineColumnIndexFast(t *testing.T) {
+	// compare the line index results with the scanning implementation
+	for _, s := range []string{"", "\n", "123\n123\n123", "αβ\nγ\n\nabc\n", "a\xffb\nα"} {
+		rw := iorw.NewLineIndexRW(iorw.NewBytesReadWriter([]byte(s)))
+		if iorw.ReaderLineIndex(rw) == nil {
+			t.Fatal("no line index")
+		}
+		for l := 0; l <= 6; l++ {
+			for c := 0; c <= 6; c++ {
+				i1, err1 := LineColumnIndex(rw, l, c)
+				i2, err2 := LineColumnIndex(rw.ReadWriter, l, c)
+				if i1 != i2 || (err1 == nil) != (err2 == nil) {
+					t.Fatalf("%q: %v:%v: %v %v, %v %v", s, l, c, i1, err1, i2, err2)
+				}
+			}
+		}
+		for i := -1; i <= rw.Max()+1; i++ {
+			l1, c1, err1 := IndexLineColumn(rw, i)
+			l2, c2, err2 := IndexLineColumn(rw.ReadWriter, i)
+			if l1 != l2 || c1 != c2 || (err1 == nil) != (err2 == nil) {
+				t.Fatalf("%q: %v: %v:%v %v, %v:%v %v", s, i, l1, c1, err1, l2, c2, err2)
+			}
+		}
+	}
+}
+
 //----------
 
 func TestDetectVar(t *testing.T) {
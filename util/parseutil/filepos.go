@@ -28,7 +28,7 @@ func ParseFilePos(str string) (*FilePos, error) {
 
 func NewFilePosFromResource(res *Resource) *FilePos {
 	return &FilePos{
-		Offset:   -1,
+		Offset:   res.Offset,
 		Filename: res.RawPath, // original string (unescaped)
 		Line:     res.Line,
 		Column:   res.Column,
@@ -36,18 +36,23 @@ var escapedInFilenames = excludeResourceRunes +
 //----------
 
 // parsed formats:
-// 	<filename:line?:col?>
-// 	<filename:#offset> # TODO
-// 	file://<filename:line?:col?> # filename should be absolute starting with "/"
+//
+//	<filename:line?:col?>
+//	<filename:#offset>
+//	<filename(line,col?)> # msvc
+//	<"filename", line N> # python tracebacks
+//	file://<filename:line?:col?> # filename should be absolute starting with "/"
 type Resource struct {
 	Path         string
 	RawPath      string
 	Line, Column int
+	Offset       int // -1 if not present
 
 	ExpandedMin, ExpandedMax int
 	PathSep                  rune
 	Escape                   rune
 	ParseVolume              bool
+	ByteOffsets              bool // ":<n>" is an offset, ":<line>:<n>" a line and an offset
 }
 
 func ParseResource(rd iorw.Reader, index int) (*Resource, error) {
@@ -56,11 +61,27 @@ func ParseResource(rd iorw.Reader, index int) (*Resource, error) {
 
 func ParseResource2(rd iorw.Reader, index int, sep, esc rune, parseVolume bool) (*Resource, error) {
 	res := &Resource{
+		Offset:      -1,
 		PathSep:     sep,
 		Escape:      esc,
 		ParseVolume: parseVolume,
 	}
+	return parseResource(rd, index, res)
+}
+
+// Locations with byte offsets instead of line/column (ex: "grep -b" output "file:1234:text", or "grep -nb" output "file:12:1234:text").
+func ParseResourceByteOffsets(rd iorw.Reader, index int) (*Resource, error) {
+	res := &Resource{
+		Offset:      -1,
+		PathSep:     PathSeparator,
+		Escape:      Escape,
+		ParseVolume: ParseVolume,
+		ByteOffsets: true,
+	}
+	return parseResource(rd, index, res)
+}
 
+func parseResource(rd iorw.Reader, index int, res *Resource) (*Resource, error) {
 	rp := &ResParser2{res: res}
 	if err := rp.parse(rd, index); err != nil {
 		return nil, err
@@ -93,6 +114,7 @@ func (rp *ResParser2) expandLeft(rd iorw.Reader, index int) int {
 	rp.sc.Reverse = true // to the left
 	// worst case scenario left expansion
 	_, _ = rp.integer() // column
+	_ = rp.rune('#')    // offset
 	_ = rp.colon()
 	_, _ = rp.integer() // line
 	_ = rp.colon()
@@ -119,30 +141,126 @@ func (rp *ResParser2) parse2(rd iorw.Reader, index int) bool {
 	rp.res.RawPath = s
 	rp.res.Path = RemoveFilenameEscapes(s, rp.res.Escape, rp.res.PathSep)
 
-	// line/column
-	if rp.colon() {
+	// location
+	for _, fn := range resLocationParsers {
+		if fn(rp) {
+			break
+		}
+	}
+
+	rp.res.ExpandedMin = index
+	rp.res.ExpandedMax = rp.sc.Pos
+
+	return true
+}
+
+//----------
+
+// Location formats after the path, the first to match is used.
+var resLocationParsers = []func(*ResParser2) bool{
+	(*ResParser2).locOffset,
+	(*ResParser2).locLineColumn,
+	(*ResParser2).locParenthesis,
+	(*ResParser2).locPythonLine,
+}
+
+// ":#<offset>" (ex: $edFileOffset)
+func (rp *ResParser2) locOffset() bool {
+	return rp.sc.RewindOnFalse(func() bool {
+		if !rp.colon() || !rp.rune('#') {
+			return false
+		}
 		v, ok := rp.integer()
-		if ok {
+		if !ok {
+			return false
+		}
+		rp.res.Offset = v
+		return true
+	})
+}
+
+// ":<line>:<col>" (ex: compilers, js/rust stack frames "at f (x.js:3:5)"). With byte offsets, ":<offset>" or ":<line>:<offset>".
+func (rp *ResParser2) locLineColumn() bool {
+	if !rp.colon() {
+		return false
+	}
+	v, ok := rp.integer()
+	if ok {
+		if rp.res.ByteOffsets {
+			rp.res.Offset = v
+		} else {
 			rp.res.Line = v
-			if rp.colon() {
-				v, ok := rp.integer()
-				if ok {
-					rp.res.Column = v
+		}
+		if rp.colon() {
+			v2, ok := rp.integer()
+			if ok {
+				if rp.res.ByteOffsets {
+					rp.res.Line, rp.res.Offset = v, v2
+				} else {
+					rp.res.Column = v2
 				}
 			}
 		}
 	}
+	return true
+}
 
-	rp.res.ExpandedMin = index
-	rp.res.ExpandedMax = rp.sc.Pos
+// "(<line>)" or "(<line>,<col>)" (ex: msvc "x.c(12,5): error")
+func (rp *ResParser2) locParenthesis() bool {
+	line, col := 0, 0
+	ok := rp.sc.RewindOnFalse(func() bool {
+		if !rp.rune('(') {
+			return false
+		}
+		v, ok := rp.integer()
+		if !ok {
+			return false
+		}
+		line = v
+		if rp.rune(',') {
+			v, ok := rp.integer()
+			if !ok {
+				return false
+			}
+			col = v
+		}
+		return rp.rune(')')
+	})
+	if ok {
+		rp.res.Line, rp.res.Column = line, col
+	}
+	return ok
+}
 
-	return true
+// `", line <line>` after the path (python tracebacks: `File "x.py", line 12, in f`)
+func (rp *ResParser2) locPythonLine() bool {
+	line := 0
+	ok := rp.sc.RewindOnFalse(func() bool {
+		if !rp.sc.Match.Sequence("\", line ") {
+			return false
+		}
+		rp.sc.Advance()
+		v, ok := rp.integer()
+		if !ok {
+			return false
+		}
+		line = v
+		return true
+	})
+	if ok {
+		rp.res.Line = line
+	}
+	return ok
 }
 
 //----------
 
 func (rp *ResParser2) colon() bool {
-	if rp.sc.Match.Rune(':') {
+	return rp.rune(':')
+}
+
+func (rp *ResParser2) rune(ru rune) bool {
+	if rp.sc.Match.Rune(ru) {
 		rp.sc.Advance()
 		return true
 	}
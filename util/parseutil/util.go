@@ -2,6 +2,7 @@ package parseutil
 
 import (
 	"fmt"
+	"io"
 	"net/url"
 	"path/filepath"
 	"strings"
@@ -188,6 +189,10 @@ func LineColumnIndex(rd iorw.Reader, line, column int) (int, error) {
 	}
 	column-- // make column 0 the first column
 
+	if li := iorw.ReaderLineIndex(rd); li != nil {
+		return lineColumnIndexFast(rd, li, line, column)
+	}
+
 	index := -1
 	l, lStart := 0, 0
 	ri := 0
@@ -226,6 +231,10 @@ func LineColumnIndex(rd iorw.Reader, line, column int) (int, error) {
 
 // Returned line/col values are one-based.
 func IndexLineColumn(rd iorw.Reader, index int) (int, int, error) {
+	if li := iorw.ReaderLineIndex(rd); li != nil {
+		return indexLineColumnFast(rd, li, index)
+	}
+
 	line, lineStart := 0, 0
 	ri := 0
 	for ri < index {
@@ -246,6 +255,72 @@ func IndexLineColumn(rd iorw.Reader, index int) (int, int, error) {
 
 //----------
 
+// Same results as the scanning implementation, using the reader line index. Line/col args are zero-based.
+func lineColumnIndexFast(rd iorw.Reader, li *iorw.LineIndex, line, column int) (int, error) {
+	ls, ok, err := li.LineStart(line)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		return 0, io.EOF
+	}
+	// line end (not including the newline)
+	le := rd.Max()
+	if ns, ok, err := li.LineStart(line + 1); err != nil {
+		return 0, err
+	} else if ok {
+		le = ns - 1
+	}
+	// tolerate bad columns
+	if ls+column > le {
+		return ls, nil
+	}
+	// column is in bytes, stop at the first rune boundary
+	ri := ls
+	for ri-ls < column {
+		_, size, err := rd.ReadRuneAt(ri)
+		if err != nil {
+			return ls, nil
+		}
+		ri += size
+	}
+	return ri, nil
+}
+
+// Same results as the scanning implementation, using the reader line index. Returned line/col values are one-based.
+func indexLineColumnFast(rd iorw.Reader, li *iorw.LineIndex, index int) (int, int, error) {
+	if index <= rd.Min() {
+		return 1, 1, nil
+	}
+	line, ls, ok, err := li.Line(index)
+	if err != nil {
+		return 0, 0, err
+	}
+	if !ok {
+		return 0, 0, io.EOF
+	}
+	// index might be in the middle of a rune: col is at the next rune boundary
+	ri := index
+	if index < rd.Max() {
+		b, err := rd.ReadNSliceAt(index, 1)
+		if err != nil {
+			return 0, 0, err
+		}
+		if !utf8.RuneStart(b[0]) {
+			for ri = ls; ri < index; {
+				_, size, err := rd.ReadRuneAt(ri)
+				if err != nil {
+					return 0, 0, err
+				}
+				ri += size
+			}
+		}
+	}
+	return line + 1, ri - ls + 1, nil
+}
+
+//----------
+
 func DetectEnvVar(str, name string) bool {
 	vstr := "$" + name
 	i := strings.Index(str, vstr)
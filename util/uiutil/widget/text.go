@@ -16,6 +16,7 @@ type Text struct {
 
 	Drawer   drawutil.Drawer
 	OnSetStr func()
+	OnScroll func() // rune offset (vertical scroll) changed
 
 	scrollable struct{ x, y bool }
 	ctx        ImageContext
@@ -32,7 +33,7 @@ func NewText(ctx ImageContext) *Text {
 	t.TextScroll.Text = t
 	t.TextScroll.Drawer = t.Drawer
 
-	rw := iorw.NewBytesReadWriter(nil)
+	rw := iorw.NewLineIndexRW(iorw.NewBytesReadWriter(nil))
 	t.SetRW(rw)
 
 	return t
@@ -107,6 +108,9 @@ func (t *Text) SetRuneOffset(v int) {
 	if t.scrollable.y && t.Drawer.RuneOffset() != v {
 		t.Drawer.SetRuneOffset(v)
 		t.MarkNeedsLayoutAndPaint()
+		if t.OnScroll != nil {
+			t.OnScroll()
+		}
 	}
 }
 
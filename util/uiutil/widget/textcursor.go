@@ -74,6 +74,9 @@ func (tc *TextCursor) SetIndex(index int) {
 		tc.state.index = index
 		tc.te.Drawer.SetCursorOffset(tc.state.index)
 		tc.te.MarkNeedsPaint()
+		if tc.te.OnCursorIndex != nil {
+			tc.te.OnCursorIndex()
+		}
 	}
 }
 
@@ -214,3 +217,7 @@ func (rw *writeOpHistoryRW) Overwrite(i, length int, p []byte) error {
 
 	return nil
 }
+
+func (rw *writeOpHistoryRW) LineIndex() *iorw.LineIndex {
+	return iorw.ReaderLineIndex(rw.ReadWriter)
+}
@@ -1,6 +1,7 @@
 package widget
 
 import (
+	"github.com/jmigpin/editor/util/drawutil/drawer4"
 	"github.com/jmigpin/editor/util/iout/iorw"
 	"github.com/jmigpin/editor/util/mathutil"
 )
@@ -9,9 +10,10 @@ type TextEdit struct {
 	*Text
 	ClipboardContext
 
-	TextCursor  *TextCursor
-	TextHistory *TextHistory
-	OnWriteOp   func(*RWWriteOpCb)
+	TextCursor    *TextCursor
+	TextHistory   *TextHistory
+	OnWriteOp     func(*RWWriteOpCb)
+	OnCursorIndex func() // cursor index changed
 
 	crw iorw.ReadWriter // write op callback rw: OnWriteOp(...)
 }
@@ -146,6 +148,16 @@ func (te *TextEdit) LastIndexFunc(offset int, truth bool, f func(rune) bool) (in
 
 //----------
 
+// Returns an index outside of folded content: the fold end if moving forward, or the fold start otherwise.
+func (te *TextEdit) FoldSkip(index int, forward bool) int {
+	if d, ok := te.Drawer.(*drawer4.Drawer); ok {
+		return d.FoldSkip(index, forward)
+	}
+	return index
+}
+
+//----------
+
 func (te *TextEdit) UpdateDuplicate(dup *TextEdit) {
 	dup.SetRW(te.Text.rw)               // share readwriter
 	dup.TextHistory.Use(te.TextHistory) // share history
@@ -162,22 +174,22 @@ func (te *TextEdit) UpdateWriteOp(u *RWWriteOpCb) {
 	// update cursor/selection position
 	tc := te.TextCursor
 	tci := tc.Index()
-	v1 := te.editValue(u.Type, s, e, e2, tci)
+	v1 := editValue(u.Type, s, e, e2, tci)
 	if !tc.SelectionOn() {
 		tc.SetIndex(tci + v1)
 	} else {
 		si := tc.SelectionIndex()
-		v3 := te.editValue(u.Type, s, e, e2, si)
+		v3 := editValue(u.Type, s, e, e2, si)
 		tc.SetSelection(si+v3, tci+v1)
 	}
 
 	// update offset position
 	ro := te.RuneOffset()
-	v2 := te.editValue(u.Type, s, e, e2, ro)
+	v2 := editValue(u.Type, s, e, e2, ro)
 	te.SetRuneOffset(ro + v2)
 }
 
-func (te *TextEdit) editValue(typ iorw.WriterOp, s, e, e2, o int) int {
+func editValue(typ iorw.WriterOp, s, e, e2, o int) int {
 	v := 0
 	if s < o {
 		k := mathutil.Smallest(e, o)
@@ -229,6 +241,10 @@ func (rw *writeOpCbRW) Overwrite(i, length int, p []byte) error {
 	return nil
 }
 
+func (rw *writeOpCbRW) LineIndex() *iorw.LineIndex {
+	return iorw.ReaderLineIndex(rw.ReadWriter)
+}
+
 //----------
 
 type RWWriteOpCb struct {
@@ -237,3 +253,9 @@ type RWWriteOpCb struct {
 	Length1 int
 	Length2 int
 }
+
+// Position of the offset after the write operation (updated like the cursor).
+func (u *RWWriteOpCb) UpdateOffset(o int) int {
+	s := u.Index
+	return o + editValue(u.Type, s, s+u.Length1, s+u.Length2, o)
+}
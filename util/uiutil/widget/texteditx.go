@@ -3,11 +3,13 @@ package widget
 import (
 	"fmt"
 	"image/color"
+	"sort"
 	"time"
 
 	"github.com/jmigpin/editor/util/drawutil"
 	"github.com/jmigpin/editor/util/drawutil/drawer4"
 	"github.com/jmigpin/editor/util/imageutil"
+	"github.com/jmigpin/editor/util/iout/iorw"
 )
 
 // textedit with extensions
@@ -41,11 +43,17 @@ func NewTextEditX(ctx ImageContext, cctx ClipboardContext) *TextEditX {
 
 		// setup colorize order
 		d.Opt.Colorize.Groups = []*drawer4.ColorizeGroup{
+			&d.Opt.LineNumbers.Group,
+			&d.Opt.Heatmap.Group,
+			&d.Opt.Changes.Group,
+			&d.Opt.Diff.Group,
+			&d.Opt.Diff.WordGroup,
+			&d.Opt.Marks.Group,
 			&d.Opt.SyntaxHighlight.Group,
 			&d.Opt.WordHighlight.Group,
 			&d.Opt.ParenthesisHighlight.Group,
-			{}, // 3=selection
-			{}, // 4=flash
+			{}, // 9=selection
+			{}, // 10=flash
 		}
 	}
 
@@ -69,7 +77,7 @@ func (te *TextEditX) Paint() {
 
 func (te *TextEditX) updateSelectionOpt() {
 	if d, ok := te.Drawer.(*drawer4.Drawer); ok {
-		g := d.Opt.Colorize.Groups[3]
+		g := d.Opt.Colorize.Groups[9]
 		if te.TextCursor.SelectionOn() {
 			// colors
 			pcol := te.TreeThemePaletteColor
@@ -170,7 +178,7 @@ func (te *TextEditX) updateFlashOpt() {
 }
 
 func (te *TextEditX) updateFlashOpt4(d *drawer4.Drawer) {
-	g := d.Opt.Colorize.Groups[4]
+	g := d.Opt.Colorize.Groups[10]
 	if !te.flash.index.on {
 		g.Ops = nil
 		return
@@ -226,6 +234,215 @@ func (te *TextEditX) EnableCursorWordHighlight(v bool) {
 
 //----------
 
+func (te *TextEditX) EnableLineNumbers(v bool) {
+	if d, ok := te.Drawer.(*drawer4.Drawer); ok {
+		if d.Opt.LineNumbers.On != v {
+			d.Opt.LineNumbers.On = v
+			te.MarkNeedsLayoutAndPaint()
+		}
+	}
+}
+
+// Width of the line numbers gutter (zero if not on).
+func (te *TextEditX) LineNumbersWidth() int {
+	if d, ok := te.Drawer.(*drawer4.Drawer); ok {
+		return d.LineNumbersWidth()
+	}
+	return 0
+}
+
+//----------
+
+// Line backgrounds shaded by level (ex: executed lines count). Entries must be ordered by offset.
+func (te *TextEditX) SetHeatmap(entries []*drawer4.HeatmapEntry) {
+	if d, ok := te.Drawer.(*drawer4.Drawer); ok {
+		d.Opt.Heatmap.On = len(entries) > 0
+		d.Opt.Heatmap.Entries = entries
+		te.MarkNeedsPaint()
+	}
+}
+
+//----------
+
+// Line backgrounds of changed lines (ex: git changes). Entries must be ordered by offset.
+func (te *TextEditX) SetChanges(entries []*drawer4.ChangeEntry) {
+	if d, ok := te.Drawer.(*drawer4.Drawer); ok {
+		d.Opt.Changes.On = len(entries) > 0
+		d.Opt.Changes.Entries = entries
+		te.MarkNeedsPaint()
+	}
+}
+
+//----------
+
+// Changed lines and words of a diff view. Entries must be ordered by offset.
+func (te *TextEditX) SetDiff(lines []*drawer4.ChangeEntry, words []*drawer4.DiffWord) {
+	if d, ok := te.Drawer.(*drawer4.Drawer); ok {
+		d.Opt.Diff.On = len(lines) > 0 || len(words) > 0
+		d.Opt.Diff.Lines = lines
+		d.Opt.Diff.Words = words
+		te.MarkNeedsPaint()
+	}
+}
+
+//----------
+
+// Line backgrounds of the lines with marks (ex: bookmarks). Offsets must be ordered.
+func (te *TextEditX) SetMarks(offsets []int) {
+	if d, ok := te.Drawer.(*drawer4.Drawer); ok {
+		d.Opt.Marks.On = len(offsets) > 0
+		d.Opt.Marks.Entries = offsets
+		te.MarkNeedsPaint()
+	}
+}
+
+//----------
+
+// Folded ranges (drawn as a placeholder), ordered by offset.
+func (te *TextEditX) Folds() []*drawer4.Fold {
+	if d, ok := te.Drawer.(*drawer4.Drawer); ok {
+		return d.Opt.Folds.Entries
+	}
+	return nil
+}
+
+// Invalid, nested and overlapping folds are discarded (the outer fold is kept).
+func (te *TextEditX) SetFolds(folds []*drawer4.Fold) {
+	d, ok := te.Drawer.(*drawer4.Drawer)
+	if !ok {
+		return
+	}
+	max := te.Len()
+	u := []*drawer4.Fold{}
+	for _, f := range folds {
+		if f.Start >= 0 && f.Start < f.End && f.End <= max {
+			u = append(u, f)
+		}
+	}
+	sort.Slice(u, func(a, b int) bool {
+		if u[a].Start == u[b].Start {
+			return u[a].End > u[b].End
+		}
+		return u[a].Start < u[b].Start
+	})
+	w := []*drawer4.Fold{}
+	for _, f := range u {
+		if len(w) > 0 && f.Start < w[len(w)-1].End {
+			continue
+		}
+		w = append(w, f)
+	}
+	d.Opt.Folds.On = len(w) > 0
+	d.Opt.Folds.Entries = w
+	d.ContentChanged() // visible content changed
+	te.MarkNeedsLayoutAndPaint()
+}
+
+func (te *TextEditX) UnfoldAll() {
+	te.SetFolds(nil)
+}
+
+// Unfolds the fold that starts at the line of the index, or folds the block that starts at that line. The block is the first of the given ranges starting at the line (ex: from lsproto), or otherwise computed from the content (indentation/parenthesis).
+func (te *TextEditX) ToggleFold(index int, ranges []*drawer4.Fold) {
+	rw := te.TextCursor.RW()
+	ls, err := iorw.LineStartIndex(rw, index)
+	if err != nil {
+		return
+	}
+	lineOf := func(f *drawer4.Fold) bool {
+		k, err := iorw.LineStartIndex(rw, f.Start)
+		return err == nil && k == ls
+	}
+
+	// unfold
+	folds := te.Folds()
+	for i, f := range folds {
+		if lineOf(f) {
+			u := append([]*drawer4.Fold{}, folds[:i]...)
+			te.SetFolds(append(u, folds[i+1:]...))
+			return
+		}
+	}
+
+	// fold
+	var fold *drawer4.Fold
+	for _, f := range ranges {
+		if lineOf(f) && (fold == nil || f.End > fold.End) {
+			fold = f
+		}
+	}
+	if fold == nil {
+		f, ok := drawer4.FoldAt(rw, index)
+		if !ok {
+			return
+		}
+		fold = f
+	}
+	// keep the cursor visible (a hidden cursor would reveal the fold)
+	tc := te.TextCursor
+	if ci := tc.Index(); ci > fold.Start && ci < fold.End {
+		tc.SetSelectionOff()
+		tc.SetIndex(fold.Start)
+	}
+	te.SetFolds(append(folds, fold))
+}
+
+// True if a fold starts at the line of the index, or the line starts a block computed from the content.
+func (te *TextEditX) FoldableLine(index int) bool {
+	rw := te.TextCursor.RW()
+	ls, err := iorw.LineStartIndex(rw, index)
+	if err != nil {
+		return false
+	}
+	for _, f := range te.Folds() {
+		if k, err := iorw.LineStartIndex(rw, f.Start); err == nil && k == ls {
+			return true
+		}
+	}
+	_, ok := drawer4.FoldAt(rw, index)
+	return ok
+}
+
+// Also updates the folds positions.
+func (te *TextEditX) UpdateWriteOp(u *RWWriteOpCb) {
+	te.TextEdit.UpdateWriteOp(u)
+	te.UpdateFoldsWriteOp(u)
+}
+
+// Folds before the edit are kept, folds after the edit are shifted, and folds with hidden content edited are removed.
+func (te *TextEditX) UpdateFoldsWriteOp(u *RWWriteOpCb) {
+	folds := te.Folds()
+	if len(folds) == 0 {
+		return
+	}
+	s, e, n := u.Index, u.Index+u.Length1, u.Length2
+	switch u.Type {
+	case iorw.InsertWOp:
+		e, n = s, u.Length1
+	case iorw.DeleteWOp:
+		n = 0
+	}
+	changed := false
+	w := []*drawer4.Fold{}
+	for _, f := range folds {
+		switch {
+		case s >= f.End: // edit after the fold
+			w = append(w, f)
+		case e <= f.Start: // edit before the fold
+			d := n - (e - s)
+			w = append(w, &drawer4.Fold{Start: f.Start + d, End: f.End + d})
+			changed = changed || d != 0
+		default: // hidden content edited
+			changed = true
+		}
+	}
+	if changed {
+		te.SetFolds(w)
+	}
+}
+
+//----------
+
 func (te *TextEditX) SetCommentStrings(a ...interface{}) {
 	cs := []*drawutil.SyntaxHighlightComment{}
 	firstLine := true
@@ -285,6 +502,35 @@ func (te *TextEditX) OnThemeChange() {
 		d.Opt.ParenthesisHighlight.Fg = pcol("text_parenthesis_fg")
 		d.Opt.ParenthesisHighlight.Bg = pcol("text_parenthesis_bg")
 
+		// line numbers
+		d.Opt.LineNumbers.Fg = pcol("text_linenumbers_fg")
+		d.Opt.LineNumbers.Bg = pcol("text_linenumbers_bg")
+		d.Opt.LineNumbers.Cursor.Fg = pcol("text_linenumbers_cursor_fg")
+		d.Opt.LineNumbers.Cursor.Bg = pcol("text_linenumbers_cursor_bg")
+		d.Opt.LineNumbers.Cursor.LineBg = pcol("text_linenumbers_cursor_line_bg")
+
+		// folds
+		d.Opt.Folds.Fg = pcol("text_fold_fg")
+		d.Opt.Folds.Bg = pcol("text_fold_bg")
+
+		// heatmap
+		d.Opt.Heatmap.Bg0 = pcol("text_bg")
+		d.Opt.Heatmap.Bg1 = pcol("text_heatmap_bg")
+
+		// changes
+		d.Opt.Changes.Added = pcol("text_change_added_bg")
+		d.Opt.Changes.Modified = pcol("text_change_modified_bg")
+		d.Opt.Changes.Deleted = pcol("text_change_deleted_bg")
+
+		// diff
+		d.Opt.Diff.Added = pcol("text_change_added_bg")
+		d.Opt.Diff.Modified = pcol("text_change_modified_bg")
+		d.Opt.Diff.Removed = pcol("text_change_deleted_bg")
+		d.Opt.Diff.Word = pcol("text_diff_word_bg")
+
+		// marks
+		d.Opt.Marks.Bg = pcol("text_mark_bg")
+
 		// syntax highlight
 		opt := &d.Opt.SyntaxHighlight
 		opt.Comment.Fg = pcol("text_colorize_comments_fg")
@@ -19,6 +19,9 @@ func (ts *TextScroll) SetScrollOffset(o image.Point) {
 	if ts.Drawer.ScrollOffset() != o {
 		ts.Drawer.SetScrollOffset(o)
 		ts.MarkNeedsLayoutAndPaint()
+		if ts.OnScroll != nil {
+			ts.OnScroll()
+		}
 	}
 }
 
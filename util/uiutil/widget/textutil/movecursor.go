@@ -32,7 +32,8 @@ func MoveCursorLeft(te *widget.TextEdit, sel bool) error {
 	if err != nil {
 		return err
 	}
-	tc.SetSelectionUpdate(sel, ci-size)
+	i := te.FoldSkip(ci-size, false)
+	tc.SetSelectionUpdate(sel, i)
 	return nil
 }
 
@@ -43,7 +44,8 @@ func MoveCursorRight(te *widget.TextEdit, sel bool) error {
 	if err != nil {
 		return err
 	}
-	tc.SetSelectionUpdate(sel, ci+size)
+	i := te.FoldSkip(ci+size, true)
+	tc.SetSelectionUpdate(sel, i)
 	return nil
 }
 